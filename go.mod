@@ -14,6 +14,7 @@ require (
 	github.com/pingcap/tidb/pkg/parser v0.0.0-20250909154457-ec3ade5dea22
 	github.com/schollz/progressbar/v3 v3.18.0
 	golang.org/x/sync v0.16.0
+	golang.org/x/term v0.34.0
 )
 
 require (
@@ -188,7 +189,6 @@ require (
 	golang.org/x/net v0.42.0 // indirect
 	golang.org/x/oauth2 v0.30.0 // indirect
 	golang.org/x/sys v0.35.0 // indirect
-	golang.org/x/term v0.34.0 // indirect
 	golang.org/x/text v0.28.0 // indirect
 	golang.org/x/time v0.12.0 // indirect
 	golang.org/x/tools v0.35.0 // indirect
@@ -3,7 +3,11 @@ package config
 import (
 	"context"
 	"fmt"
+	"math"
+	"slices"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/docker/go-units"
 	"github.com/pingcap/tidb/br/pkg/storage"
@@ -35,9 +39,136 @@ type CommonConfig struct {
 	FileFormat       string `toml:"format"`
 	UseStreamingMode bool   `toml:"use_streaming_mode"`
 	ChunkSize        string `toml:"chunk_size"`
+	// MaxMemory bounds the bytes of streamed chunks generated but not yet
+	// written, over all files, e.g. "512MiB". Generators wait while it is
+	// reached.
+	MaxMemory string `toml:"max_memory"`
+	// RateLimit caps the bytes written per second over all files, e.g.
+	// "50MiB", to leave room on a shared link. Unset means no limit.
+	RateLimit string `toml:"rate_limit"`
+	// MaxFileSize, e.g. "64MiB", writes the rows of all files as one sequence
+	// and starts the next file before a row that would make the current one
+	// larger, instead of giving every file the same rows. CSV and NDJSON only.
+	MaxFileSize string `toml:"max_file_bytes"`
+	// TotalRows, when set, replaces rows: it is split evenly across the
+	// files and the remainder goes to the last file.
+	TotalRows int `toml:"total_rows"`
+	// SkipUnsupportedColumns drops columns of unsupported types instead of
+	// failing the run.
+	SkipUnsupportedColumns bool `toml:"skip_unsupported_columns"`
+	// SQLDialect is the dialect of the schema file: mysql, postgres, sqlserver or sqlite.
+	SQLDialect string `toml:"sql_dialect"`
+	// Seed makes generation reproducible when non-zero, each file uses
+	// seed+fileNo. Zero means a random seed per file.
+	Seed int64 `toml:"seed"`
+	// Broadcast gives every file the same rows, derived from the row position
+	// in the file only. A broadcast=true table comment sets it for one table.
+	Broadcast bool `toml:"broadcast"`
+	// FolderSeed, when non-zero, assigns files to folders by a seeded hash
+	// instead of round-robin, giving an uneven but reproducible layout.
+	FolderSeed int64 `toml:"folder_seed"`
+	// FolderWidth is the zero-padded width of the folder number in partNNNNN
+	// folders, 5 when unset.
+	FolderWidth int `toml:"folder_width"`
+	// FileOrder is the order files are started in: ascending (default),
+	// descending or random, shuffled by seed.
+	FileOrder string `toml:"file_order"`
+	// LogFile, when set, is a local file that gets one JSON line per
+	// generated file as it finishes, with its rows, bytes, duration and error.
+	LogFile string `toml:"log_file"`
+	// SummaryJSON is where the run summary is written as JSON, "-" means stdout.
+	SummaryJSON string `toml:"summary_json"`
+	// ColumnTiming records how long each column takes to generate and prints
+	// a breakdown in the summary.
+	ColumnTiming bool `toml:"column_timing"`
+	// Resume skips files that already exist with a non-zero size.
+	Resume bool `toml:"resume"`
+	// Append keeps the number of files but starts after the largest file
+	// number already under path, so a run adds files instead of rewriting
+	// them.
+	Append bool `toml:"append"`
+	// ProgressDetail lists every file with its state and bytes under the
+	// progress box, for runs of up to 32 files.
+	ProgressDetail bool `toml:"progress_detail"`
+	// EmitSchema writes <prefix>.schema.json describing the columns next to
+	// the generated files.
+	EmitSchema bool `toml:"emit_schema"`
+	// PartitionBy names a low-cardinality column whose values split every
+	// file into Hive-style <column>=<value>/ directories. The column is left
+	// out of the files.
+	PartitionBy string `toml:"partition_by"`
+	// Manifest writes <prefix>_manifest.json with the size, CRC-32C and rows
+	// of every file written in the run.
+	Manifest bool `toml:"manifest"`
+
+	// RowWidthProfile makes the string columns of each row all wide or all
+	// narrow, instead of picking every length uniformly.
+	RowWidthProfile *RowWidthProfile `toml:"row_width_profile"`
+
+	// PostHook runs a command after a successful run.
+	PostHook *PostHook `toml:"post_hook"`
+
+	// RunWindow restricts generation to a daily wall-clock window in local
+	// time, e.g. "22:00-06:00". New files wait while it is closed.
+	RunWindow string `toml:"run_window"`
+
+	// FileNameTemplate is a text/template for the file names, e.g.
+	// `{{.Prefix}}-{{printf "%05d" .FileNo}}-of-{{printf "%05d" .Total}}.{{.Suffix}}`.
+	FileNameTemplate string `toml:"filename_template"`
+
+	// WriterConcurrency is the number of parts a remote file uploads in
+	// parallel. Defaults to 8.
+	WriterConcurrency int `toml:"writer_concurrency"`
+
+	// MaxRetries is how many times a file that failed with a storage error
+	// is generated again from scratch. Defaults to 0, no retries.
+	MaxRetries int `toml:"max_retries"`
+	// RetryBackoff is the wait before the first retry, e.g. "2s", doubled
+	// for each later one. Defaults to 1s.
+	RetryBackoff string `toml:"retry_backoff"`
+
+	// LocalBufferSize is the write buffer of each file when common.path is
+	// a local directory, e.g. "4MiB". Defaults to 1MiB.
+	LocalBufferSize string `toml:"local_buffer_size"`
 
 	// ChunkSizeBytes is derived at runtime and not read from config.
 	ChunkSizeBytes int `toml:"-"`
+	// MaxMemoryBytes is derived from MaxMemory and not read from config.
+	MaxMemoryBytes int64 `toml:"-"`
+	// RateLimitBytes is derived from RateLimit and not read from config.
+	RateLimitBytes int64 `toml:"-"`
+	// MaxFileBytes is derived from MaxFileSize and not read from config.
+	MaxFileBytes int64 `toml:"-"`
+	// LocalBufferSizeBytes is derived from LocalBufferSize and not read from config.
+	LocalBufferSizeBytes int `toml:"-"`
+	// RetryBackoffDuration is derived from RetryBackoff and not read from config.
+	RetryBackoffDuration time.Duration `toml:"-"`
+	// RunWindowRange is derived from RunWindow and not read from config.
+	RunWindowRange *RunWindow `toml:"-"`
+	// FileNameTmpl is parsed from FileNameTemplate and not read from config.
+	FileNameTmpl *template.Template `toml:"-"`
+}
+
+// defaultRetryBackoff is the wait before the first retry of a file.
+const defaultRetryBackoff = time.Second
+
+// defaultPostHookTimeout bounds a post_hook without a timeout.
+const defaultPostHookTimeout = 10 * time.Minute
+
+// PostHook is a command run after a successful run, with the summary in its
+// environment.
+type PostHook struct {
+	Command string   `toml:"command"`
+	Args    []string `toml:"args"`
+	Timeout string   `toml:"timeout"`
+
+	// TimeoutDuration is derived from Timeout and not read from config.
+	TimeoutDuration time.Duration `toml:"-"`
+}
+
+// RowWidthProfile sets the share of wide rows, the rest are narrow.
+type RowWidthProfile struct {
+	WidePercent int `toml:"wide_percent"`
 }
 
 type ParquetConfig struct {
@@ -45,37 +176,262 @@ type ParquetConfig struct {
 	NumRowGroups int    `toml:"row_groups"`
 	Compression  string `toml:"compression"`
 
+	// RowGroupSize sizes row groups by their estimated uncompressed bytes,
+	// e.g. "128MiB", instead of splitting files into row_groups groups.
+	RowGroupSize string `toml:"row_group_bytes"`
+	// MaxColumnChunkSize caps the estimated size of every column chunk,
+	// e.g. "1MiB", by splitting files into more row groups.
+	MaxColumnChunkSize string `toml:"max_column_chunk_bytes"`
+	// TargetCompressedSize keeps appending row groups until the written
+	// file reaches this size, instead of stopping after row_groups groups.
+	TargetCompressedSize string `toml:"target_compressed_size"`
+	// SingleFile writes all file numbers into one file, one row group each.
+	SingleFile bool `toml:"single_file"`
+	// LayoutReference is a local Parquet file whose row group sizes and
+	// average page size every generated file replicates.
+	LayoutReference string `toml:"layout_reference"`
+	// RowGroupConcurrency generates up to this many row groups of a file in
+	// parallel. 0 or 1 generates them one by one.
+	RowGroupConcurrency int `toml:"row_group_concurrency"`
+	// ConvertRowGroupRows is the number of rows per row group when
+	// converting CSV to Parquet.
+	ConvertRowGroupRows int `toml:"convert_row_group_rows"`
+	// ConvertCheckpoint is a local file recording the progress of a CSV to
+	// Parquet conversion, so a failed conversion can be resumed.
+	ConvertCheckpoint string `toml:"convert_checkpoint"`
+	// EmitIndex writes <prefix>_index.json with the row groups of every file.
+	EmitIndex bool `toml:"emit_index"`
+	// Dialect picks logical types for a target system, "" or "bigquery".
+	Dialect string `toml:"dialect"`
+	// UniformRowGroups are the indices of the row groups in each file that
+	// are written as UniformMode, "all_null" or "constant".
+	UniformRowGroups []int  `toml:"uniform_row_groups"`
+	UniformMode      string `toml:"uniform_mode"`
+
 	// PageSizeBytes is derived at runtime and not read from config.
 	PageSizeBytes int64 `toml:"-"`
+	// RowGroupSizeBytes is derived at runtime and not read from config.
+	RowGroupSizeBytes int64 `toml:"-"`
+	// MaxColumnChunkBytes is derived at runtime and not read from config.
+	MaxColumnChunkBytes int64 `toml:"-"`
+	// TargetCompressedSizeBytes is derived at runtime and not read from config.
+	TargetCompressedSizeBytes int64 `toml:"-"`
+	// RowGroupRows is derived from LayoutReference and not read from config.
+	RowGroupRows []int `toml:"-"`
 }
 
 type CSVConfig struct {
-	Base64    bool   `toml:"base64"`
+	Base64 bool `toml:"base64"`
+	// Separator and EndLine may use the escapes of UnescapeCSV, e.g. \t.
 	Separator string `toml:"separator,omitempty"`
 	EndLine   string `toml:"endline,omitempty"`
+	// Compression is "none" (default) or "gzip", gzip files end with .gz.
+	Compression string `toml:"compression,omitempty"`
+	// GzipMemberPerChunk closes a gzip member after every chunk, producing
+	// concatenated gzip members that can be decompressed independently.
+	GzipMemberPerChunk bool `toml:"gzip_member_per_chunk,omitempty"`
+	// NullString is how NULL is written and read, \N when unset. It can be
+	// empty.
+	NullString *string `toml:"null_string,omitempty"`
+	// AllowRaggedRows pads short rows with NULLs and truncates long ones when
+	// converting CSV to Parquet, instead of failing.
+	AllowRaggedRows bool `toml:"allow_ragged_rows,omitempty"`
+	// InferSchema marks CSV converted to Parquet as starting with a header
+	// row. Without -sql the columns are named after it and their types are
+	// guessed from the first InferSampleRows rows.
+	InferSchema bool `toml:"infer_schema,omitempty"`
+	// InferSampleRows is the number of rows sampled by InferSchema, 1000 when
+	// unset.
+	InferSampleRows int `toml:"infer_sample_rows,omitempty"`
+	// InferAsString makes every inferred column a string.
+	InferAsString bool `toml:"infer_as_string,omitempty"`
+	// Quote is "never" (default), "necessary" to quote fields holding the
+	// separator, a quote or a line break, or "always".
+	Quote string `toml:"quote,omitempty"`
+}
+
+// defaultInferSampleRows is the number of rows sampled by csv.infer_schema
+// without csv.infer_sample_rows.
+const defaultInferSampleRows = 1000
+
+// QuoteMode returns the lowercase csv.quote, "never" when unset.
+func (c *CSVConfig) QuoteMode() string {
+	if mode := strings.ToLower(strings.TrimSpace(c.Quote)); mode != "" {
+		return mode
+	}
+	return "never"
+}
+
+// IsGzip reports whether CSV files are gzip compressed.
+func (c *CSVConfig) IsGzip() bool {
+	return strings.EqualFold(strings.TrimSpace(c.Compression), "gzip")
+}
+
+// NDJSONConfig holds the options of common.format = "ndjson".
+type NDJSONConfig struct {
+	// Compression is "none" (default) or "gzip", gzip files end with .gz
+	// and hold a single gzip member.
+	Compression string `toml:"compression,omitempty"`
+}
+
+// IsGzip reports whether NDJSON files are gzip compressed.
+func (c *NDJSONConfig) IsGzip() bool {
+	return strings.EqualFold(strings.TrimSpace(c.Compression), "gzip")
+}
+
+// FileSuffix returns the suffix of NDJSON files, with .gz when gzipped.
+func (c *NDJSONConfig) FileSuffix() string {
+	if c.IsGzip() {
+		return "ndjson.gz"
+	}
+	return "ndjson"
 }
 
 type Config struct {
 	Common    CommonConfig  `toml:"common"`
 	Parquet   ParquetConfig `toml:"parquet"`
 	CSV       CSVConfig     `toml:"csv"`
+	NDJSON    NDJSONConfig  `toml:"ndjson"`
 	S3Config  *S3Config     `toml:"s3,omitempty"`
 	GCSConfig *GCSConfig    `toml:"gcs,omitempty"`
+	// SyntheticSchema builds the table from column groups instead of -sql.
+	SyntheticSchema []SyntheticColumns `toml:"synthetic_schema,omitempty"`
+}
+
+// MaxSyntheticColumns is the most columns synthetic_schema can build, the
+// column limit of a MySQL table.
+const MaxSyntheticColumns = 4096
+
+// SyntheticColumns is a group of columns of one type in synthetic_schema.
+type SyntheticColumns struct {
+	Columns int `toml:"columns"`
+	// Type is the column type as written in SQL, e.g. int or varchar(64).
+	Type string `toml:"type"`
+	// Options are column options as written in a column comment.
+	Options string `toml:"options,omitempty"`
 }
 
 // Normalize resolves derived config values after loading.
 func Normalize(cfg *Config) error {
+	if cfg.Common.TotalRows > 0 {
+		if cfg.Common.Rows > 0 {
+			return fmt.Errorf("common.rows and common.total_rows cannot both be set")
+		}
+		if files := cfg.Common.EndFileNo - cfg.Common.StartFileNo; files > 0 {
+			cfg.Common.Rows = cfg.Common.TotalRows / files
+		}
+	}
+
 	chunkBytes, err := cfg.Common.resolveChunkSizeBytes()
 	if err != nil {
 		return err
 	}
 	cfg.Common.ChunkSizeBytes = chunkBytes
 
+	if cfg.Common.LocalBufferSizeBytes, err = cfg.Common.resolveLocalBufferSizeBytes(); err != nil {
+		return err
+	}
+	if cfg.Common.MaxMemoryBytes, err = cfg.Common.resolveMaxMemoryBytes(); err != nil {
+		return err
+	}
+	if cfg.Common.RateLimitBytes, err = cfg.Common.resolveRateLimitBytes(); err != nil {
+		return err
+	}
+	if cfg.Common.MaxFileBytes, err = cfg.Common.resolveMaxFileBytes(); err != nil {
+		return err
+	}
+	if cfg.Common.WriterConcurrency < 0 {
+		return fmt.Errorf("common.writer_concurrency must be positive, got %d", cfg.Common.WriterConcurrency)
+	} else if cfg.Common.WriterConcurrency == 0 {
+		cfg.Common.WriterConcurrency = defaultWriterConcurrency
+	}
+
+	if cfg.Common.MaxRetries < 0 {
+		return fmt.Errorf("common.max_retries must be >= 0, got %d", cfg.Common.MaxRetries)
+	}
+	cfg.Common.RetryBackoffDuration = defaultRetryBackoff
+	if cfg.Common.RetryBackoff != "" {
+		if cfg.Common.RetryBackoffDuration, err = time.ParseDuration(cfg.Common.RetryBackoff); err != nil || cfg.Common.RetryBackoffDuration <= 0 {
+			return fmt.Errorf("invalid common.retry_backoff %q", cfg.Common.RetryBackoff)
+		}
+	}
+
+	if cfg.Common.RunWindow != "" {
+		if cfg.Common.RunWindowRange, err = parseRunWindow(cfg.Common.RunWindow); err != nil {
+			return err
+		}
+	}
+
+	if h := cfg.Common.PostHook; h != nil {
+		h.TimeoutDuration = defaultPostHookTimeout
+		if h.Timeout != "" {
+			if h.TimeoutDuration, err = time.ParseDuration(h.Timeout); err != nil || h.TimeoutDuration <= 0 {
+				return fmt.Errorf("invalid common.post_hook.timeout %q", h.Timeout)
+			}
+		}
+	}
+
+	if cfg.Common.FileNameTemplate != "" {
+		if cfg.Common.FileNameTmpl, err = parseFileNameTemplate(cfg.Common.FileNameTemplate); err != nil {
+			return err
+		}
+	}
+
+	if cfg.CSV.InferSampleRows < 0 {
+		return fmt.Errorf("csv.infer_sample_rows must be positive, got %d", cfg.CSV.InferSampleRows)
+	} else if cfg.CSV.InferSampleRows == 0 {
+		cfg.CSV.InferSampleRows = defaultInferSampleRows
+	}
+
 	pageBytes, err := cfg.Parquet.resolvePageSizeBytes()
 	if err != nil {
 		return err
 	}
 	cfg.Parquet.PageSizeBytes = pageBytes
+
+	targetBytes, err := cfg.Parquet.resolveTargetCompressedSizeBytes()
+	if err != nil {
+		return err
+	}
+	cfg.Parquet.TargetCompressedSizeBytes = targetBytes
+
+	rowGroupBytes, err := cfg.Parquet.resolveRowGroupSizeBytes()
+	if err != nil {
+		return err
+	}
+	cfg.Parquet.RowGroupSizeBytes = rowGroupBytes
+
+	if cfg.Parquet.MaxColumnChunkBytes, err = cfg.Parquet.resolveMaxColumnChunkBytes(); err != nil {
+		return err
+	}
+
+	return cfg.resolveParquetLayout()
+}
+
+// resolveParquetLayout replaces rows, row_groups and page_size with the
+// layout of parquet.layout_reference.
+func (cfg *Config) resolveParquetLayout() error {
+	if cfg.Parquet.LayoutReference == "" {
+		return nil
+	}
+	if cfg.Common.Rows > 0 || cfg.Common.TotalRows > 0 {
+		return fmt.Errorf("common.rows and common.total_rows cannot be set with parquet.layout_reference")
+	}
+
+	layout, err := ReadParquetLayout(cfg.Parquet.LayoutReference)
+	if err != nil {
+		return err
+	}
+	rows := 0
+	for _, n := range layout.RowGroupRows {
+		rows += n
+	}
+	cfg.Common.Rows = rows
+	cfg.Parquet.NumRowGroups = len(layout.RowGroupRows)
+	cfg.Parquet.RowGroupRows = layout.RowGroupRows
+	if layout.PageSizeBytes > 0 {
+		cfg.Parquet.PageSizeBytes = layout.PageSizeBytes
+	}
 	return nil
 }
 
@@ -88,22 +444,83 @@ func Validate(cfg *Config) error {
 	}
 	if cfg.Common.Prefix == "" {
 		errs = append(errs, "common.prefix is required")
+	} else if _, err := ExpandPrefix(cfg.Common.Prefix); err != nil {
+		errs = append(errs, err.Error())
 	}
 	if cfg.Common.EndFileNo <= cfg.Common.StartFileNo {
 		errs = append(errs, "common.end_fileno must be greater than common.start_fileno")
 	}
-	if cfg.Common.Rows <= 0 {
+	if files := cfg.Common.EndFileNo - cfg.Common.StartFileNo; cfg.Common.TotalRows > 0 && files > 0 &&
+		cfg.Common.TotalRows < files {
+		errs = append(errs, "common.total_rows must be at least the number of files")
+	} else if cfg.Common.Rows <= 0 {
 		errs = append(errs, "common.rows must be greater than 0")
 	}
 	if cfg.Common.Folders < 0 {
 		errs = append(errs, "common.folders must be >= 0")
 	}
+	if cfg.Common.FolderWidth < 0 || cfg.Common.FolderWidth > maxFolderWidth {
+		errs = append(errs, fmt.Sprintf("common.folder_width must be between 1 and %d", maxFolderWidth))
+	}
+	if cfg.Common.MaxMemory != "" && !cfg.Common.UseStreamingMode {
+		errs = append(errs, "common.max_memory requires common.use_streaming_mode")
+	}
+	if cfg.Common.Append && cfg.Common.FileNameTemplate != "" {
+		errs = append(errs, "common.append cannot be used with common.filename_template")
+	}
+	if cfg.Common.PartitionBy != "" {
+		switch {
+		case cfg.Common.Resume:
+			errs = append(errs, "common.partition_by cannot be used with common.resume")
+		case cfg.Common.Append:
+			errs = append(errs, "common.partition_by cannot be used with common.append")
+		case cfg.Common.MaxMemory != "":
+			errs = append(errs, "common.partition_by cannot be used with common.max_memory")
+		case cfg.Parquet.SingleFile:
+			errs = append(errs, "common.partition_by cannot be used with parquet.single_file")
+		case cfg.Parquet.EmitIndex:
+			errs = append(errs, "common.partition_by cannot be used with parquet.emit_index")
+		case cfg.Parquet.TargetCompressedSizeBytes > 0:
+			errs = append(errs, "common.partition_by cannot be used with parquet.target_compressed_size")
+		case cfg.Parquet.LayoutReference != "":
+			errs = append(errs, "common.partition_by cannot be used with parquet.layout_reference")
+		case len(cfg.Parquet.UniformRowGroups) > 0:
+			errs = append(errs, "common.partition_by cannot be used with parquet.uniform_row_groups")
+		case cfg.Parquet.RowGroupConcurrency > 1:
+			errs = append(errs, "common.partition_by cannot be used with parquet.row_group_concurrency")
+		}
+	}
+	switch cfg.Common.FileOrder {
+	case "", "ascending", "descending", "random":
+	default:
+		errs = append(errs, "common.file_order must be ascending, descending or random")
+	}
+	if h := cfg.Common.PostHook; h != nil && strings.TrimSpace(h.Command) == "" {
+		errs = append(errs, "common.post_hook.command is required")
+	}
+	if p := cfg.Common.RowWidthProfile; p != nil && (p.WidePercent < 0 || p.WidePercent > 100) {
+		errs = append(errs, "common.row_width_profile.wide_percent must be between 0 and 100")
+	}
+
+	total := 0
+	for _, g := range cfg.SyntheticSchema {
+		if g.Columns <= 0 {
+			errs = append(errs, fmt.Sprintf("synthetic_schema.columns must be greater than 0 for type %q", g.Type))
+		}
+		if strings.TrimSpace(g.Type) == "" {
+			errs = append(errs, "synthetic_schema.type is required")
+		}
+		total += max(g.Columns, 0)
+	}
+	if total > MaxSyntheticColumns {
+		errs = append(errs, fmt.Sprintf("synthetic_schema has %d columns, at most %d are supported", total, MaxSyntheticColumns))
+	}
 
 	format := strings.ToLower(strings.TrimSpace(cfg.Common.FileFormat))
 	switch format {
-	case "csv", "parquet":
+	case "csv", "parquet", "ndjson":
 	default:
-		errs = append(errs, "common.format must be csv or parquet")
+		errs = append(errs, "common.format must be csv, parquet or ndjson")
 	}
 
 	if cfg.Common.ChunkSize != "" && cfg.Common.ChunkSizeBytes <= 0 {
@@ -111,14 +528,138 @@ func Validate(cfg *Config) error {
 	}
 
 	if format == "parquet" {
-		if cfg.Parquet.NumRowGroups <= 0 {
+		if cfg.Parquet.RowGroupSizeBytes > 0 {
+			// row_group_bytes decides the row groups, row_groups is ignored.
+			if cfg.Parquet.LayoutReference != "" {
+				errs = append(errs, "parquet.row_group_bytes cannot be used with parquet.layout_reference")
+			}
+		} else if cfg.Parquet.NumRowGroups <= 0 {
 			errs = append(errs, "parquet.row_groups must be greater than 0")
+		} else if len(cfg.Parquet.RowGroupRows) > 0 {
+			// The reference layout decides the row groups.
+		} else if cfg.Common.TotalRows > 0 && cfg.Common.Rows > 0 {
+			last := cfg.Common.RowsForFile(cfg.Common.EndFileNo - 1)
+			if cfg.Common.Rows%cfg.Parquet.NumRowGroups != 0 || last%cfg.Parquet.NumRowGroups != 0 {
+				errs = append(errs, fmt.Sprintf(
+					"parquet.row_groups must divide the rows of every file: common.total_rows splits into %d rows per file and %d rows in the last file",
+					cfg.Common.Rows, last))
+			}
 		} else if cfg.Common.Rows > 0 && cfg.Common.Rows%cfg.Parquet.NumRowGroups != 0 {
 			errs = append(errs, "parquet.row_groups must divide common.rows")
 		}
 		if cfg.Parquet.PageSizeBytes <= 0 {
 			errs = append(errs, "parquet.page_size must be greater than 0")
 		}
+		if cfg.Parquet.MaxColumnChunkBytes > 0 && cfg.Parquet.LayoutReference != "" {
+			errs = append(errs, "parquet.max_column_chunk_bytes cannot be used with parquet.layout_reference")
+		}
+		if cfg.Parquet.LayoutReference != "" && cfg.Parquet.TargetCompressedSizeBytes > 0 {
+			errs = append(errs, "parquet.layout_reference cannot be used with parquet.target_compressed_size")
+		}
+		if cfg.Parquet.RowGroupConcurrency < 0 {
+			errs = append(errs, "parquet.row_group_concurrency must be >= 0")
+		} else if cfg.Parquet.RowGroupConcurrency > 1 && cfg.Parquet.TargetCompressedSizeBytes > 0 {
+			errs = append(errs, "parquet.row_group_concurrency cannot be used with parquet.target_compressed_size")
+		}
+	}
+
+	if cfg.Common.MaxFileBytes > 0 {
+		switch {
+		case format != "csv" && format != "ndjson":
+			errs = append(errs, "common.max_file_bytes requires common.format = csv or ndjson")
+		case cfg.CSV.IsGzip() && format == "csv", cfg.NDJSON.IsGzip() && format == "ndjson":
+			errs = append(errs, "common.max_file_bytes cannot be used with compression")
+		case cfg.Common.PartitionBy != "":
+			errs = append(errs, "common.max_file_bytes cannot be used with common.partition_by")
+		case cfg.Common.Resume:
+			errs = append(errs, "common.max_file_bytes cannot be used with common.resume")
+		case cfg.Common.Append:
+			errs = append(errs, "common.max_file_bytes cannot be used with common.append")
+		case cfg.Common.Folders > 1:
+			errs = append(errs, "common.max_file_bytes cannot be used with common.folders")
+		case cfg.Common.FileNameTemplate != "":
+			errs = append(errs, "common.max_file_bytes cannot be used with common.filename_template")
+		case cfg.Common.MaxMemory != "":
+			errs = append(errs, "common.max_file_bytes cannot be used with common.max_memory")
+		case cfg.Common.Broadcast:
+			errs = append(errs, "common.max_file_bytes cannot be used with common.broadcast")
+		}
+	}
+
+	if cfg.Parquet.EmitIndex && format != "parquet" {
+		errs = append(errs, "parquet.emit_index requires common.format = parquet")
+	}
+
+	switch cfg.Parquet.Dialect {
+	case "", "bigquery":
+	default:
+		errs = append(errs, "parquet.dialect must be empty or bigquery")
+	}
+
+	if len(cfg.Parquet.UniformRowGroups) > 0 {
+		if format != "parquet" {
+			errs = append(errs, "parquet.uniform_row_groups requires common.format = parquet")
+		}
+		switch cfg.Parquet.UniformMode {
+		case "all_null", "constant":
+		default:
+			errs = append(errs, "parquet.uniform_mode must be all_null or constant")
+		}
+		if slices.ContainsFunc(cfg.Parquet.UniformRowGroups, func(i int) bool { return i < 0 }) {
+			errs = append(errs, "parquet.uniform_row_groups must be >= 0")
+		}
+	} else if cfg.Parquet.UniformMode != "" {
+		errs = append(errs, "parquet.uniform_mode requires parquet.uniform_row_groups")
+	}
+
+	if cfg.Parquet.SingleFile {
+		files := cfg.Common.EndFileNo - cfg.Common.StartFileNo
+		if format != "parquet" {
+			errs = append(errs, "parquet.single_file requires common.format = parquet")
+		}
+		if cfg.Parquet.TargetCompressedSizeBytes > 0 {
+			errs = append(errs, "parquet.single_file cannot be used with parquet.target_compressed_size")
+		}
+		if cfg.Parquet.LayoutReference != "" {
+			errs = append(errs, "parquet.single_file cannot be used with parquet.layout_reference")
+		}
+		if cfg.Parquet.RowGroupSizeBytes > 0 {
+			errs = append(errs, "parquet.single_file cannot be used with parquet.row_group_bytes")
+		}
+		if cfg.Parquet.MaxColumnChunkBytes > 0 {
+			errs = append(errs, "parquet.single_file cannot be used with parquet.max_column_chunk_bytes")
+		}
+		if files > math.MaxInt16 {
+			errs = append(errs, fmt.Sprintf("parquet.single_file supports at most %d file numbers", math.MaxInt16))
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(cfg.CSV.Compression)) {
+	case "", "none", "gzip":
+	default:
+		errs = append(errs, "csv.compression must be none or gzip")
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.NDJSON.Compression)) {
+	case "", "none", "gzip":
+	default:
+		errs = append(errs, "ndjson.compression must be none or gzip")
+	}
+	if cfg.CSV.GzipMemberPerChunk && !cfg.CSV.IsGzip() {
+		errs = append(errs, "csv.gzip_member_per_chunk requires csv.compression = gzip")
+	}
+	switch cfg.CSV.QuoteMode() {
+	case "never", "necessary", "always":
+	default:
+		errs = append(errs, "csv.quote must be never, necessary or always")
+	}
+	sep, sepErr := UnescapeCSV(cfg.CSV.Separator)
+	if sepErr != nil {
+		errs = append(errs, "csv.separator "+sepErr.Error())
+	} else if strings.ContainsAny(sep, "\r\n\"") {
+		errs = append(errs, "csv.separator must not contain a line break or a double quote")
+	}
+	if _, err := UnescapeCSV(cfg.CSV.EndLine); err != nil {
+		errs = append(errs, "csv.endline "+err.Error())
 	}
 
 	if cfg.S3Config != nil && cfg.GCSConfig != nil {
@@ -139,6 +680,15 @@ func Validate(cfg *Config) error {
 	return fmt.Errorf("%s", strings.TrimRight(sb.String(), "\n"))
 }
 
+// RowsForFile returns the number of rows in a file. With total_rows set, the
+// last file also takes the remainder of the split.
+func (c *CommonConfig) RowsForFile(fileNo int) int {
+	if c.TotalRows > 0 && fileNo == c.EndFileNo-1 {
+		return c.Rows + c.TotalRows%(c.EndFileNo-c.StartFileNo)
+	}
+	return c.Rows
+}
+
 func (c *CommonConfig) resolveChunkSizeBytes() (int, error) {
 	if c.ChunkSize != "" {
 		bytes, err := units.FromHumanSize(c.ChunkSize)
@@ -153,6 +703,75 @@ func (c *CommonConfig) resolveChunkSizeBytes() (int, error) {
 	return 0, nil
 }
 
+// defaultWriterConcurrency is the upload concurrency of each file without
+// writer_concurrency.
+const defaultWriterConcurrency = 8
+
+// WriterOption returns the options to create files in the store with.
+func (c *CommonConfig) WriterOption() *storage.WriterOption {
+	return &storage.WriterOption{Concurrency: c.WriterConcurrency}
+}
+
+// defaultLocalBufferSize is the write buffer of local files without
+// local_buffer_size.
+const defaultLocalBufferSize = 1 << 20
+
+func (c *CommonConfig) resolveLocalBufferSizeBytes() (int, error) {
+	if c.LocalBufferSize == "" {
+		return defaultLocalBufferSize, nil
+	}
+	bytes, err := units.FromHumanSize(c.LocalBufferSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid local_buffer_size %q: %w", c.LocalBufferSize, err)
+	}
+	if bytes <= 0 {
+		return 0, fmt.Errorf("invalid local_buffer_size %q: must be greater than 0", c.LocalBufferSize)
+	}
+	return int(bytes), nil
+}
+
+func (c *CommonConfig) resolveMaxMemoryBytes() (int64, error) {
+	if c.MaxMemory == "" {
+		return 0, nil
+	}
+	bytes, err := units.FromHumanSize(c.MaxMemory)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max_memory %q: %w", c.MaxMemory, err)
+	}
+	if bytes <= 0 {
+		return 0, fmt.Errorf("invalid max_memory %q: must be greater than 0", c.MaxMemory)
+	}
+	return bytes, nil
+}
+
+func (c *CommonConfig) resolveRateLimitBytes() (int64, error) {
+	if c.RateLimit == "" {
+		return 0, nil
+	}
+	bytes, err := units.FromHumanSize(c.RateLimit)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate_limit %q: %w", c.RateLimit, err)
+	}
+	if bytes <= 0 {
+		return 0, fmt.Errorf("invalid rate_limit %q: must be greater than 0", c.RateLimit)
+	}
+	return bytes, nil
+}
+
+func (c *CommonConfig) resolveMaxFileBytes() (int64, error) {
+	if c.MaxFileSize == "" {
+		return 0, nil
+	}
+	bytes, err := units.FromHumanSize(c.MaxFileSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max_file_bytes %q: %w", c.MaxFileSize, err)
+	}
+	if bytes <= 0 {
+		return 0, fmt.Errorf("invalid max_file_bytes %q: must be greater than 0", c.MaxFileSize)
+	}
+	return bytes, nil
+}
+
 func (c *ParquetConfig) resolvePageSizeBytes() (int64, error) {
 	if c.PageSize != "" {
 		bytes, err := units.FromHumanSize(c.PageSize)
@@ -167,6 +786,48 @@ func (c *ParquetConfig) resolvePageSizeBytes() (int64, error) {
 	return defaultPageSizeBytes, nil
 }
 
+func (c *ParquetConfig) resolveRowGroupSizeBytes() (int64, error) {
+	if c.RowGroupSize == "" {
+		return 0, nil
+	}
+	bytes, err := units.FromHumanSize(c.RowGroupSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid row_group_bytes %q: %w", c.RowGroupSize, err)
+	}
+	if bytes <= 0 {
+		return 0, fmt.Errorf("invalid row_group_bytes %q: must be greater than 0", c.RowGroupSize)
+	}
+	return bytes, nil
+}
+
+func (c *ParquetConfig) resolveMaxColumnChunkBytes() (int64, error) {
+	if c.MaxColumnChunkSize == "" {
+		return 0, nil
+	}
+	bytes, err := units.FromHumanSize(c.MaxColumnChunkSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max_column_chunk_bytes %q: %w", c.MaxColumnChunkSize, err)
+	}
+	if bytes <= 0 {
+		return 0, fmt.Errorf("invalid max_column_chunk_bytes %q: must be greater than 0", c.MaxColumnChunkSize)
+	}
+	return bytes, nil
+}
+
+func (c *ParquetConfig) resolveTargetCompressedSizeBytes() (int64, error) {
+	if c.TargetCompressedSize == "" {
+		return 0, nil
+	}
+	bytes, err := units.FromHumanSize(c.TargetCompressedSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid target_compressed_size %q: %w", c.TargetCompressedSize, err)
+	}
+	if bytes <= 0 {
+		return 0, fmt.Errorf("invalid target_compressed_size %q: must be greater than 0", c.TargetCompressedSize)
+	}
+	return bytes, nil
+}
+
 // GetStore initializes and returns an ExternalStorage instance based on the provided configuration.
 func GetStore(c *Config) (storage.ExternalStorage, error) {
 	var op *storage.BackendOptions
@@ -192,3 +853,13 @@ func GetStore(c *Config) (storage.ExternalStorage, error) {
 
 	return storage.NewWithDefaultOpt(context.Background(), s)
 }
+
+// LocalDir returns the directory of common.path if it is on the local
+// filesystem, either a plain path or a file:// URL.
+func LocalDir(c *Config) (string, bool) {
+	b, err := storage.ParseBackend(c.Common.Path, nil)
+	if err != nil || b.GetLocal() == nil {
+		return "", false
+	}
+	return b.GetLocal().Path, true
+}
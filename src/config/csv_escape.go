@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UnescapeCSV decodes the escape sequences \t, \n, \r, \\ and \xHH in a
+// csv.separator or csv.endline, so separator = '\t' in a TOML literal string
+// gives a real tab.
+func UnescapeCSV(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 == len(s) {
+			return "", fmt.Errorf("%q ends with a lone backslash", s)
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case '\\':
+			b.WriteByte('\\')
+		case 'x':
+			if i+3 > len(s) {
+				return "", fmt.Errorf("%q has a \\x escape without two hex digits", s)
+			}
+			v, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("%q has a \\x escape without two hex digits", s)
+			}
+			b.WriteByte(byte(v))
+			i += 2
+		default:
+			return "", fmt.Errorf("%q has an unknown escape \\%c", s, s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// IsTSV reports whether the separator is a tab, which gives .tsv files.
+func (c *CSVConfig) IsTSV() bool {
+	sep, err := UnescapeCSV(c.Separator)
+	return err == nil && sep == "\t"
+}
+
+// FileSuffix returns the suffix of CSV files: csv, or tsv with a tab
+// separator, plus .gz with gzip compression.
+func (c *CSVConfig) FileSuffix() string {
+	suffix := "csv"
+	if c.IsTSV() {
+		suffix = "tsv"
+	}
+	if c.IsGzip() {
+		suffix += ".gz"
+	}
+	return suffix
+}
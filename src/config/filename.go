@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// FileNameData holds the variables of common.filename_template.
+type FileNameData struct {
+	FileNo int
+	// Total is common.end_fileno, so names like "data-00042-of-00100" stay
+	// the same when a run only covers part of the files.
+	Total  int
+	Prefix string
+	Suffix string
+	// Folder is the folder of the file, 0 when common.folders <= 1.
+	Folder int
+}
+
+// parseFileNameTemplate parses a filename_template and renders it once so
+// unknown variables are reported before generation starts.
+func parseFileNameTemplate(s string) (*template.Template, error) {
+	tmpl, err := template.New("filename_template").Option("missingkey=error").Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filename_template: %w", err)
+	}
+	if _, err := renderFileName(tmpl, FileNameData{Total: 1, Prefix: "p", Suffix: "csv"}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+func renderFileName(tmpl *template.Template, data FileNameData) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("invalid filename_template: %w", err)
+	}
+	name := strings.TrimPrefix(b.String(), "/")
+	if name == "" {
+		return "", fmt.Errorf("filename_template renders an empty name for file %d", data.FileNo)
+	}
+	return name, nil
+}
+
+const (
+	// defaultFolderWidth gives part00000 folders without folder_width.
+	defaultFolderWidth = 5
+	maxFolderWidth     = 20
+)
+
+// FileName returns the object name of a file relative to common.path, from
+// filename_template if set, or prefix.N.suffix under partNNNNN/ folders.
+func (c *CommonConfig) FileName(data FileNameData) (string, error) {
+	if c.FileNameTmpl != nil {
+		return renderFileName(c.FileNameTmpl, data)
+	}
+	if c.Folders <= 1 {
+		return fmt.Sprintf("%s.%d.%s", data.Prefix, data.FileNo, data.Suffix), nil
+	}
+	return fmt.Sprintf("%s/%s.%d.%s", c.FolderName(data.Folder), data.Prefix, data.FileNo, data.Suffix), nil
+}
+
+// FolderName returns the name of a partNNNNN folder, padded to
+// folder_width digits.
+func (c *CommonConfig) FolderName(folder int) string {
+	width := c.FolderWidth
+	if width == 0 {
+		width = defaultFolderWidth
+	}
+	return fmt.Sprintf("part%0*d", width, folder)
+}
+
+// ParseFileNo returns N of a file named prefix.N.suffix, without folders,
+// and false for any other name.
+func ParseFileNo(name, prefix, suffix string) (int, bool) {
+	rest, ok := strings.CutPrefix(name, prefix+".")
+	if !ok {
+		return 0, false
+	}
+	num, ok := strings.CutSuffix(rest, "."+suffix)
+	if !ok || num == "" || strings.TrimLeft(num, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(num)
+	return n, err == nil
+}
+
+// FileSuffix returns the suffix of the files of common.file_format.
+func (c *Config) FileSuffix() string {
+	switch strings.ToLower(c.Common.FileFormat) {
+	case "csv":
+		return c.CSV.FileSuffix()
+	case "ndjson":
+		return c.NDJSON.FileSuffix()
+	default:
+		return strings.ToLower(c.Common.FileFormat)
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/parquet/file"
+)
+
+// ParquetLayout is the row group and page structure of a reference file.
+type ParquetLayout struct {
+	RowGroupRows []int
+	// PageSizeBytes is the average uncompressed data page size.
+	PageSizeBytes int64
+}
+
+// ReadParquetLayout reads the row group sizes of a local Parquet file from its
+// metadata, and scans the page headers to get the average data page size.
+func ReadParquetLayout(path string) (*ParquetLayout, error) {
+	reader, err := file.OpenParquetFile(path, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open layout reference %s: %w", path, err)
+	}
+	defer reader.Close()
+
+	layout := &ParquetLayout{}
+	var pages, pageBytes int64
+	for rg := range reader.NumRowGroups() {
+		rgr := reader.RowGroup(rg)
+		layout.RowGroupRows = append(layout.RowGroupRows, int(rgr.NumRows()))
+		for col := range rgr.NumColumns() {
+			pr, err := rgr.GetColumnPageReader(col)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read pages of %s: %w", path, err)
+			}
+			for pr.Next() {
+				if p, ok := pr.Page().(file.DataPage); ok {
+					pages++
+					pageBytes += int64(p.UncompressedSize())
+				}
+			}
+			if err := pr.Err(); err != nil {
+				return nil, fmt.Errorf("failed to read pages of %s: %w", path, err)
+			}
+		}
+	}
+
+	if len(layout.RowGroupRows) == 0 {
+		return nil, fmt.Errorf("layout reference %s has no row groups", path)
+	}
+	if pages > 0 {
+		layout.PageSizeBytes = max(pageBytes/pages, 1)
+	}
+	return layout, nil
+}
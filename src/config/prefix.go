@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxExpandedPrefixes bounds the datasets a brace-expanded prefix gives.
+const maxExpandedPrefixes = 1024
+
+// ExpandPrefix expands brace groups in common.prefix like a shell does, e.g.
+// events_{2023,2024} gives events_2023 and events_2024. Several groups give
+// every combination in order; groups cannot be nested. A prefix without
+// braces is returned as is.
+func ExpandPrefix(prefix string) ([]string, error) {
+	prefixes := []string{""}
+	rest := prefix
+	for rest != "" {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			prefixes = appendToAll(prefixes, []string{rest})
+			break
+		}
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("common.prefix %q has an unmatched '}'", prefix)
+		}
+		end := strings.IndexAny(rest[open+1:], "{}")
+		if end < 0 || rest[open+1+end] == '{' {
+			return nil, fmt.Errorf("common.prefix %q has an unclosed or nested '{'", prefix)
+		}
+		alts := strings.Split(rest[open+1:open+1+end], ",")
+		if len(alts) < 2 {
+			return nil, fmt.Errorf("common.prefix %q has a brace group without a comma", prefix)
+		}
+		prefixes = appendToAll(prefixes, []string{rest[:open]})
+		prefixes = appendToAll(prefixes, alts)
+		if len(prefixes) > maxExpandedPrefixes {
+			return nil, fmt.Errorf("common.prefix %q expands to more than %d prefixes", prefix, maxExpandedPrefixes)
+		}
+		rest = rest[open+1+end+1:]
+	}
+
+	seen := make(map[string]struct{}, len(prefixes))
+	for _, p := range prefixes {
+		if p == "" {
+			return nil, fmt.Errorf("common.prefix %q expands to an empty prefix", prefix)
+		}
+		if _, ok := seen[p]; ok {
+			return nil, fmt.Errorf("common.prefix %q expands to %q twice", prefix, p)
+		}
+		seen[p] = struct{}{}
+	}
+	return prefixes, nil
+}
+
+// appendToAll returns every prefix followed by every suffix.
+func appendToAll(prefixes, suffixes []string) []string {
+	out := make([]string, 0, len(prefixes)*len(suffixes))
+	for _, p := range prefixes {
+		for _, s := range suffixes {
+			out = append(out, p+s)
+		}
+	}
+	return out
+}
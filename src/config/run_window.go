@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RunWindow is a daily wall-clock window in local time, such as 22:00-06:00.
+// A window whose end is not after its start spans midnight.
+type RunWindow struct {
+	// Start and End are offsets from midnight.
+	Start time.Duration
+	End   time.Duration
+}
+
+// parseRunWindow parses a window like "22:00-06:00".
+func parseRunWindow(s string) (*RunWindow, error) {
+	startStr, endStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid run_window %q: expected HH:MM-HH:MM", s)
+	}
+	start, err := parseClock(startStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid run_window %q: %w", s, err)
+	}
+	end, err := parseClock(endStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid run_window %q: %w", s, err)
+	}
+	if start == end {
+		return nil, fmt.Errorf("invalid run_window %q: start and end must differ", s)
+	}
+	return &RunWindow{Start: start, End: end}, nil
+}
+
+// parseClock parses HH:MM into an offset from midnight.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Until returns how long after t the window opens, 0 if it is open at t.
+func (w *RunWindow) Until(t time.Time) time.Duration {
+	t = t.Local()
+	now := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+
+	var open bool
+	if w.Start < w.End {
+		open = now >= w.Start && now < w.End
+	} else {
+		open = now >= w.Start || now < w.End
+	}
+	if open {
+		return 0
+	}
+
+	// Build the opening time from the wall clock, so DST changes are handled.
+	hour, minute := int(w.Start/time.Hour), int(w.Start%time.Hour/time.Minute)
+	next := time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, t.Location())
+	if !next.After(t) {
+		next = time.Date(t.Year(), t.Month(), t.Day()+1, hour, minute, 0, 0, t.Location())
+	}
+	return next.Sub(t)
+}
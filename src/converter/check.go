@@ -0,0 +1,36 @@
+package converter
+
+import (
+	"dataWriter/src/config"
+	"dataWriter/src/spec"
+	"dataWriter/src/util"
+
+	"github.com/pingcap/errors"
+)
+
+// CheckCSVRecord checks that a CSV record has a field per column and that
+// every field decodes as ConvertCSVToParquet would read it and fits the
+// column's declared type.
+func CheckCSVRecord(specs []*spec.ColumnSpec, record string, cfg config.CSVConfig) error {
+	fields, err := splitRecord(util.NewCSVQuoter(cfg), specs, record)
+	if err != nil {
+		return errors.Annotatef(err, "%s", quoteRecord(record))
+	}
+	if len(fields) != len(specs) {
+		return errors.Errorf("expected %d fields, got %d: %s", len(specs), len(fields), quoteRecord(record))
+	}
+	nullString := util.CSVNullString(cfg)
+	for i, field := range fields {
+		c := specs[i]
+		field, isNull, err := decodeField(c, field, cfg.Base64, nullString)
+		if err == nil && !isNull {
+			if _, err = c.ParseParquetValue(field); err == nil {
+				err = c.CheckValue(field)
+			}
+		}
+		if err != nil {
+			return errors.Annotatef(err, "column %s: %s", c.OrigName, quoteRecord(record))
+		}
+	}
+	return nil
+}
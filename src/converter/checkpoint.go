@@ -0,0 +1,67 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pingcap/errors"
+)
+
+// Checkpoint is the progress of a CSV to Parquet conversion with
+// parquet.convert_checkpoint. It is written as JSON, e.g.
+//
+//	{"input":"data.csv","offset":52428800,"rows":400000,"part":0}
+//
+// after every output part, which holds one row group: offset is the byte
+// offset of the first CSV line not converted yet, rows the lines converted
+// before it (without the header of csv.infer_schema), and part the number of
+// the last part closed, 0 for the output itself and n for the continuation
+// ContinuationName(output, n).
+type Checkpoint struct {
+	Input  string `json:"input"`
+	Offset int64  `json:"offset"`
+	Rows   int    `json:"rows"`
+	Part   int    `json:"part"`
+}
+
+// LoadCheckpoint reads the checkpoint at path, or returns nil if there is
+// none.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to read checkpoint %s", path)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, errors.Annotatef(err, "invalid checkpoint %s", path)
+	}
+	return &cp, nil
+}
+
+// save replaces the checkpoint at path. It writes a temporary file first, so
+// an interrupted save leaves the previous checkpoint.
+func (cp *Checkpoint) save(path string) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return errors.Annotatef(err, "failed to write checkpoint %s", path)
+	}
+	return errors.Annotatef(os.Rename(tmp, path), "failed to write checkpoint %s", path)
+}
+
+// ContinuationName returns the name of part n of a conversion to output,
+// e.g. data.1.parquet for data.parquet.
+func ContinuationName(output string, part int) string {
+	if part == 0 {
+		return output
+	}
+	return fmt.Sprintf("%s.%d.parquet", strings.TrimSuffix(output, ".parquet"), part)
+}
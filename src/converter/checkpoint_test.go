@@ -0,0 +1,142 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dataWriter/src/config"
+	"dataWriter/src/spec"
+)
+
+// convertToFile converts csvPath into the Parquet file output and its
+// continuations, returning the parts written.
+func convertToFile(t *testing.T, csvPath, output string, specs []*spec.ColumnSpec, cfg *config.Config, resume *Checkpoint) ([]string, error) {
+	t.Helper()
+	var parts []string
+	_, err := ConvertCSVToParquet(csvPath, func(part int) (io.WriteCloser, error) {
+		name := ContinuationName(output, part)
+		parts = append(parts, name)
+		return os.Create(name)
+	}, specs, cfg, resume)
+	return parts, err
+}
+
+// parquetCSV converts Parquet files back to CSV, one after another.
+func parquetCSV(t *testing.T, cfg *config.Config, paths ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, path := range paths {
+		if err := ConvertParquetToCSV(path, &buf, cfg.CSV, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestConvertResumesFromCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	sqlPath := filepath.Join(dir, "t.sql")
+	if err := os.WriteFile(sqlPath, []byte("CREATE TABLE t (id bigint, s varchar(20));"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	specs, err := spec.GetSpecFromSQL(sqlPath, spec.ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for i := range 1000 {
+		lines = append(lines, fmt.Sprintf("%d,s%d", i, i*7))
+	}
+	good := strings.Join(lines, "\n") + "\n"
+	// Line 551 doesn't parse, the conversion fails in the sixth row group.
+	lines[550] = "x50,s3850"
+	bad := strings.Join(lines, "\n") + "\n"
+	csvPath := filepath.Join(dir, "t.csv")
+	if err := os.WriteFile(csvPath, []byte(bad), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoint := filepath.Join(dir, "t.checkpoint")
+	cfg := &config.Config{Parquet: config.ParquetConfig{
+		PageSizeBytes:       1 << 20,
+		ConvertRowGroupRows: 100,
+		ConvertCheckpoint:   checkpoint,
+	}}
+	output := filepath.Join(dir, "t.parquet")
+	parts, err := convertToFile(t, csvPath, output, specs, cfg, nil)
+	if err == nil || !strings.Contains(err.Error(), "line 551") {
+		t.Fatalf("conversion of the bad line: %v", err)
+	}
+	cp, err := LoadCheckpoint(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Checkpoint{Input: csvPath, Offset: int64(strings.Index(good, "\n500,") + 1), Rows: 500, Part: 4}
+	if cp == nil || *cp != want {
+		t.Fatalf("checkpoint = %+v, want %+v", cp, want)
+	}
+	// Every row group is a complete part: the parts up to the checkpoint
+	// hold the rows before it, and the part after it none.
+	if len(parts) != 6 {
+		t.Fatalf("failed conversion wrote parts %v, want 6", parts)
+	}
+	if got := parquetCSV(t, cfg, parts...); string(got) != good[:want.Offset] {
+		t.Fatalf("failed parts hold %d bytes of CSV, want the %d bytes before the checkpoint", len(got), want.Offset)
+	}
+
+	// Fix the line and resume into the continuation files.
+	if err := os.WriteFile(csvPath, []byte(good), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resumedParts, err := convertToFile(t, csvPath, output, specs, cfg, cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumedParts[0] != ContinuationName(output, 5) {
+		t.Errorf("resumed conversion starts with %s, want part 5", resumedParts[0])
+	}
+	if _, err := os.Stat(checkpoint); !os.IsNotExist(err) {
+		t.Errorf("checkpoint is left after the conversion succeeded: %v", err)
+	}
+
+	// The parts hold the rows of an uninterrupted conversion.
+	full := filepath.Join(dir, "full.parquet")
+	plain := *cfg
+	plain.Parquet.ConvertCheckpoint = ""
+	fullParts, err := convertToFile(t, csvPath, full, specs, &plain, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fullParts) != 1 {
+		t.Errorf("conversion without checkpoint wrote parts %v", fullParts)
+	}
+	resumed := parquetCSV(t, cfg, append(parts[:5], resumedParts...)...)
+	if uninterrupted := parquetCSV(t, cfg, full); !bytes.Equal(resumed, uninterrupted) {
+		t.Errorf("resumed parts hold %d bytes of CSV, the uninterrupted conversion %d", len(resumed), len(uninterrupted))
+	}
+	if string(resumed) != good {
+		t.Error("resumed parts don't convert back to the input")
+	}
+}
+
+func TestContinuationName(t *testing.T) {
+	for _, tc := range []struct {
+		output string
+		part   int
+		want   string
+	}{
+		{"data.parquet", 0, "data.parquet"},
+		{"data.parquet", 2, "data.2.parquet"},
+		{"s3://bucket/dir/t.parquet", 1, "s3://bucket/dir/t.1.parquet"},
+	} {
+		if got := ContinuationName(tc.output, tc.part); got != tc.want {
+			t.Errorf("ContinuationName(%q, %d) = %q, want %q", tc.output, tc.part, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,540 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"os"
+	"strconv"
+
+	"dataWriter/src/config"
+	"dataWriter/src/spec"
+	"dataWriter/src/util"
+
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/schema"
+	"github.com/docker/go-units"
+	"github.com/pingcap/errors"
+)
+
+// defaultRowGroupRows is the number of rows per row group when
+// parquet.convert_row_group_rows is not set.
+const defaultRowGroupRows = 100_000
+
+// maxLineSize bounds the length of a single CSV line.
+const maxLineSize = units.GiB
+
+// maxRaggedWarnings bounds the ragged rows logged one by one, the rest are
+// only counted.
+const maxRaggedWarnings = 10
+
+// maxQuotedRecord bounds the length of a record quoted in an error.
+const maxQuotedRecord = 256
+
+// Output creates part n of the output of a conversion: part 0 is the output
+// itself and part n > 0 its continuation ContinuationName(output, n).
+type Output func(part int) (io.WriteCloser, error)
+
+// ConvertCSVToParquet converts a CSV file written by the CSV generator into
+// Parquet written to out, using the column specs from the SQL schema. Fields
+// equal to csv.null_string become NULLs and the header row of
+// csv.infer_schema is skipped. Rows are streamed: only
+// one row group is held in memory at a time. It returns the number of rows.
+//
+// Without parquet.convert_checkpoint all rows go to part 0. With it, every
+// row group goes to a part of its own, which is closed, footer included,
+// before the Checkpoint naming it is saved, so the parts up to a checkpoint
+// are complete even when the process is killed. The checkpoint is removed
+// when the conversion succeeds. resume, when not nil, is the checkpoint of
+// an interrupted conversion: the CSV is read from its offset and the rows
+// after it go to the parts after resume.Part.
+func ConvertCSVToParquet(csvPath string, out Output, specs []*spec.ColumnSpec, cfg *config.Config, resume *Checkpoint) (int, error) {
+	in, err := os.Open(csvPath)
+	if err != nil {
+		return 0, errors.Annotatef(err, "failed to open csv file: %s", csvPath)
+	}
+	defer in.Close()
+
+	// offset is the number of bytes of the lines scanned so far.
+	var offset int64
+	cp := Checkpoint{Input: csvPath, Part: -1}
+	if resume != nil {
+		if resume.Input != csvPath {
+			return 0, errors.Errorf("checkpoint is for %s, not %s", resume.Input, csvPath)
+		}
+		if _, err := in.Seek(resume.Offset, io.SeekStart); err != nil {
+			return 0, errors.Annotatef(err, "failed to seek csv file: %s", csvPath)
+		}
+		offset = resume.Offset
+		cp = *resume
+	}
+
+	// w writes the current part to dst, both are nil between parts.
+	part := cp.Part + 1
+	var (
+		w   *ParquetRowWriter
+		dst io.WriteCloser
+	)
+	openPart := func() error {
+		var err error
+		if dst, err = out(part); err != nil {
+			return errors.Trace(err)
+		}
+		w, err = NewParquetRowWriter(dst, specs, cfg)
+		return errors.Trace(err)
+	}
+	closePart := func(closeRows func() error) error {
+		err := closeRows()
+		if closeErr := dst.Close(); closeErr != nil && err == nil {
+			err = errors.Annotatef(closeErr, "failed to close output part %d", part)
+		}
+		w, dst = nil, nil
+		return err
+	}
+
+	nullString := util.CSVNullString(cfg.CSV)
+	quoter := util.NewCSVQuoter(cfg.CSV)
+	scanner := newLineScanner(in, cfg.CSV, &offset)
+	// The header row of csv.infer_schema only names the columns.
+	headerLines := 0
+	if cfg.CSV.InferSchema {
+		headerLines = 1
+		if resume == nil && !scanner.Scan() {
+			headerLines = 0
+		}
+	}
+
+	rows, ragged := 0, 0
+	err = func() error {
+		for scanner.Scan() {
+			if w == nil {
+				if err := openPart(); err != nil {
+					return err
+				}
+			}
+			line := headerLines + cp.Rows + rows + 1
+			record := scanner.Text()
+			fields, err := splitRecord(quoter, specs, record)
+			if err != nil {
+				return errors.Annotatef(err, "line %d: %s", line, quoteRecord(record))
+			}
+			if len(fields) != len(specs) {
+				if !cfg.CSV.AllowRaggedRows {
+					return errors.Errorf("line %d: expected %d fields, got %d: %s", line, len(specs), len(fields), quoteRecord(record))
+				}
+				if ragged < maxRaggedWarnings {
+					util.Warnf("line %d: expected %d fields, got %d, padding with NULLs or dropping extra fields: %s",
+						line, len(specs), len(fields), quoteRecord(record))
+				}
+				ragged++
+				fields = fitFields(fields, len(specs), nullString)
+			}
+			for i, field := range fields {
+				if err := convertValue(specs[i], field, cfg.CSV.Base64, nullString, w.buffers[i]); err != nil {
+					return errors.Annotatef(err, "line %d, column %s: %s", line, specs[i].OrigName, quoteRecord(record))
+				}
+			}
+			rows++
+			if err := w.endRow(); err != nil {
+				return errors.Trace(err)
+			}
+			if w.pending == 0 && cfg.Parquet.ConvertCheckpoint != "" {
+				// A row group was written, everything before offset is in
+				// this part once it is closed.
+				if err := closePart(w.Close); err != nil {
+					return err
+				}
+				done := Checkpoint{Input: csvPath, Offset: offset, Rows: cp.Rows + rows, Part: part}
+				if err := done.save(cfg.Parquet.ConvertCheckpoint); err != nil {
+					return err
+				}
+				part++
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return errors.Annotatef(err, "failed to read csv file: %s", csvPath)
+		}
+		// An empty input still gets an output.
+		if w == nil && part == 0 {
+			return openPart()
+		}
+		return nil
+	}()
+	if ragged > 0 {
+		util.Warnf("%d of %d lines had the wrong number of fields", ragged, rows)
+	}
+	if err != nil {
+		if dst != nil {
+			// With a checkpoint the current part holds no row group yet and
+			// is replaced on resume, its footer keeps it readable meanwhile.
+			closeRows := func() error { return nil }
+			if cfg.Parquet.ConvertCheckpoint != "" {
+				closeRows = w.closeWritten
+			}
+			if closeErr := closePart(closeRows); closeErr != nil {
+				util.Warnf("failed to close output part %d: %v", part, closeErr)
+			}
+		}
+		return rows, err
+	}
+
+	if w != nil {
+		if err := closePart(w.Close); err != nil {
+			return rows, errors.Trace(err)
+		}
+	}
+	if cfg.Parquet.ConvertCheckpoint != "" {
+		if err := os.Remove(cfg.Parquet.ConvertCheckpoint); err != nil && !os.IsNotExist(err) {
+			return rows, errors.Annotatef(err, "failed to remove checkpoint %s", cfg.Parquet.ConvertCheckpoint)
+		}
+	}
+	return rows, nil
+}
+
+// ParquetRowWriter writes rows of fields formatted like spec.GenerateRawField
+// as Parquet, with parquet.convert_row_group_rows rows per row group. Only
+// one row group is held in memory at a time.
+type ParquetRowWriter struct {
+	w            *file.Writer
+	specs        []*spec.ColumnSpec
+	buffers      []columnBuffer
+	rowGroupRows int
+	pending      int
+}
+
+// NewParquetRowWriter returns a writer of Parquet with the columns of specs
+// to out, which is not closed.
+func NewParquetRowWriter(out io.Writer, specs []*spec.ColumnSpec, cfg *config.Config) (*ParquetRowWriter, error) {
+	w, err := newParquetFileWriter(out, specs, cfg.Parquet)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	buffers := make([]columnBuffer, len(specs))
+	for i, c := range specs {
+		if buffers[i], err = newColumnBuffer(c.Type); err != nil {
+			return nil, errors.Annotatef(err, "column %s", c.OrigName)
+		}
+	}
+	rowGroupRows := cfg.Parquet.ConvertRowGroupRows
+	if rowGroupRows <= 0 {
+		rowGroupRows = defaultRowGroupRows
+	}
+	return &ParquetRowWriter{w: w, specs: specs, buffers: buffers, rowGroupRows: rowGroupRows}, nil
+}
+
+// WriteRow appends a row, with spec.NullValue for NULL fields.
+func (w *ParquetRowWriter) WriteRow(fields []string) error {
+	for i, field := range fields {
+		if field == spec.NullValue {
+			w.buffers[i].appendNull()
+			continue
+		}
+		v, err := w.specs[i].ParseParquetValue(field)
+		if err != nil {
+			return errors.Annotatef(err, "column %s", w.specs[i].OrigName)
+		}
+		w.buffers[i].append(v)
+	}
+	return w.endRow()
+}
+
+// endRow counts a row appended to the buffers and writes a full row group.
+func (w *ParquetRowWriter) endRow() error {
+	w.pending++
+	if w.pending < w.rowGroupRows {
+		return nil
+	}
+	w.pending = 0
+	return writeRowGroup(w.w, w.buffers)
+}
+
+// closeWritten writes the footer of the row groups written so far, dropping
+// the rows of the current one.
+func (w *ParquetRowWriter) closeWritten() error {
+	w.pending = 0
+	return w.w.Close()
+}
+
+// Close writes the last row group and the footer.
+func (w *ParquetRowWriter) Close() error {
+	if w.pending > 0 {
+		w.pending = 0
+		if err := writeRowGroup(w.w, w.buffers); err != nil {
+			return err
+		}
+	}
+	return w.w.Close()
+}
+
+func newParquetFileWriter(out io.Writer, specs []*spec.ColumnSpec, cfg config.ParquetConfig) (*file.Writer, error) {
+	codec := compress.Codecs.Uncompressed
+	if cfg.Compression != "" {
+		var err error
+		if codec, err = util.ParquetCompressionCodec(cfg.Compression); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := make([]schema.Node, len(specs))
+	for i, c := range specs {
+		node, err := c.ParquetNode()
+		if err != nil {
+			return nil, errors.Annotatef(err, "column %s", c.OrigName)
+		}
+		fields[i] = node
+	}
+	node, err := schema.NewGroupNode("schema", parquet.Repetitions.Required, fields, -1)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	props := parquet.NewWriterProperties(
+		parquet.WithDataPageSize(cfg.PageSizeBytes),
+		parquet.WithDataPageVersion(parquet.DataPageV2),
+		parquet.WithVersion(parquet.V2_LATEST),
+		parquet.WithCompression(codec),
+	)
+	// Hide Close of out, the parquet writer would close it with the footer
+	// while the caller owns it.
+	return file.NewParquetWriter(struct{ io.Writer }{out}, node, file.WithWriterProps(props)), nil
+}
+
+// splitRecord splits a CSV record into its fields. Unquoted JSON values may
+// hold the separator, so with csv.quote = never the pieces of a JSON column
+// are joined back until its brackets balance.
+func splitRecord(quoter util.CSVQuoter, specs []*spec.ColumnSpec, record string) ([]string, error) {
+	fields, err := quoter.Split(record)
+	if err != nil || quoter.Enabled() || len(fields) <= len(specs) {
+		return fields, err
+	}
+	joined := fields[:0]
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if c := len(joined); c < len(specs) && specs[c].SQLType == "json" {
+			for jsonDepth(field) > 0 && i+1 < len(fields) {
+				i++
+				field += quoter.Separator() + fields[i]
+			}
+		}
+		joined = append(joined, field)
+	}
+	return joined, nil
+}
+
+// jsonDepth returns the brackets and braces of s left open, skipping the
+// ones inside JSON strings.
+func jsonDepth(s string) int {
+	depth, inString := 0, false
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case inString && ch == '\\':
+			i++
+		case ch == '"':
+			inString = !inString
+		case inString:
+		case ch == '[' || ch == '{':
+			depth++
+		case ch == ']' || ch == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// fitFields pads fields with NULLs or drops the extra ones to get n fields.
+func fitFields(fields []string, n int, nullString string) []string {
+	if len(fields) > n {
+		return fields[:n]
+	}
+	for len(fields) < n {
+		fields = append(fields, nullString)
+	}
+	return fields
+}
+
+// quoteRecord quotes a raw CSV record for an error message, truncating long
+// ones.
+func quoteRecord(record string) string {
+	if len(record) > maxQuotedRecord {
+		return strconv.Quote(record[:maxQuotedRecord]) + "..."
+	}
+	return strconv.Quote(record)
+}
+
+// newLineScanner returns a scanner of the CSV lines of r, which may hold
+// endlines inside quoted fields with csv.quote. If consumed is not nil, the
+// bytes of every scanned line, with its endline, are added to it.
+func newLineScanner(r io.Reader, cfg config.CSVConfig, consumed *int64) *bufio.Scanner {
+	_, endline := util.CSVSeparatorAndEndline(cfg)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, units.MiB), maxLineSize)
+	split := splitLines([]byte(endline), util.NewCSVQuoter(cfg).Enabled())
+	if consumed != nil {
+		split = countBytes(split, consumed)
+	}
+	scanner.Split(split)
+	return scanner
+}
+
+// countBytes wraps a bufio.SplitFunc to add the bytes it advances over to n.
+func countBytes(split bufio.SplitFunc, n *int64) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := split(data, atEOF)
+		*n += int64(advance)
+		return advance, token, err
+	}
+}
+
+// splitLines is a bufio.SplitFunc for lines ending with endline. A missing
+// endline after the last line is accepted.
+func splitLines(endline []byte, quoted bool) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if quoted {
+			// An endline inside a quoted field doesn't end the line.
+			inQuotes := false
+			for i := 0; i < len(data); i++ {
+				if data[i] == '"' {
+					inQuotes = !inQuotes
+				} else if !inQuotes && bytes.HasPrefix(data[i:], endline) {
+					return i + len(endline), data[:i], nil
+				}
+			}
+		} else if i := bytes.Index(data, endline); i >= 0 {
+			return i + len(endline), data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// convertValue decodes one CSV field and appends it to the column buffer.
+// A field equal to nullString is appended as NULL.
+func convertValue(c *spec.ColumnSpec, field string, withBase64 bool, nullString string, buf columnBuffer) error {
+	field, isNull, err := decodeField(c, field, withBase64, nullString)
+	if err != nil {
+		return err
+	}
+	if isNull {
+		buf.appendNull()
+		return nil
+	}
+	v, err := c.ParseParquetValue(field)
+	if err != nil {
+		return err
+	}
+	buf.append(v)
+	return nil
+}
+
+// decodeField undoes the base64 encoding of a CSV field and reports whether
+// it is NULL.
+func decodeField(c *spec.ColumnSpec, field string, withBase64 bool, nullString string) (string, bool, error) {
+	if withBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(field)
+		if err != nil {
+			return "", false, err
+		}
+		field = string(decoded)
+	}
+	if field == nullString {
+		if c.Required {
+			return "", false, errors.New("NULL in a required column")
+		}
+		return "", true, nil
+	}
+	if c.IsBinary() && !withBase64 {
+		// Binary values are base64 encoded even without csv.base64.
+		decoded, err := base64.StdEncoding.DecodeString(field)
+		if err != nil {
+			return "", false, err
+		}
+		field = string(decoded)
+	}
+	return field, false, nil
+}
+
+func writeRowGroup(w *file.Writer, buffers []columnBuffer) error {
+	rgw := w.AppendRowGroup()
+	for _, buf := range buffers {
+		cw, err := rgw.NextColumn()
+		if err != nil {
+			return err
+		}
+		if err := buf.flush(cw); err != nil {
+			cw.Close()
+			return err
+		}
+		if err := cw.Close(); err != nil {
+			return err
+		}
+	}
+	return rgw.Close()
+}
+
+// columnBuffer collects the values of one column for the current row group.
+type columnBuffer interface {
+	append(v any)
+	appendNull()
+	// flush writes the buffered values and resets the buffer.
+	flush(cw file.ColumnChunkWriter) error
+}
+
+func newColumnBuffer(typ parquet.Type) (columnBuffer, error) {
+	switch typ {
+	case parquet.Types.Int32:
+		return &typedBuffer[int32]{}, nil
+	case parquet.Types.Int64:
+		return &typedBuffer[int64]{}, nil
+	case parquet.Types.Int96:
+		return &typedBuffer[parquet.Int96]{}, nil
+	case parquet.Types.Float:
+		return &typedBuffer[float32]{}, nil
+	case parquet.Types.Double:
+		return &typedBuffer[float64]{}, nil
+	case parquet.Types.ByteArray:
+		return &typedBuffer[parquet.ByteArray]{}, nil
+	case parquet.Types.FixedLenByteArray:
+		return &typedBuffer[parquet.FixedLenByteArray]{}, nil
+	default:
+		return nil, errors.Errorf("unsupported parquet type: %s", typ)
+	}
+}
+
+// typedBuffer holds the non-NULL values and the definition levels of a
+// column, which is what the column chunk writers expect.
+type typedBuffer[T any] struct {
+	values    []T
+	defLevels []int16
+}
+
+func (b *typedBuffer[T]) append(v any) {
+	b.values = append(b.values, v.(T))
+	b.defLevels = append(b.defLevels, 1)
+}
+
+func (b *typedBuffer[T]) appendNull() {
+	b.defLevels = append(b.defLevels, 0)
+}
+
+func (b *typedBuffer[T]) flush(cw file.ColumnChunkWriter) error {
+	w, ok := cw.(interface {
+		WriteBatch(values []T, defLevels, repLevels []int16) (int64, error)
+	})
+	if !ok {
+		return errors.Errorf("unexpected column writer %T", cw)
+	}
+	defLevels := b.defLevels
+	if cw.Descr().MaxDefinitionLevel() == 0 {
+		defLevels = nil
+	}
+	if _, err := w.WriteBatch(b.values, defLevels, nil); err != nil {
+		return err
+	}
+	b.values, b.defLevels = b.values[:0], b.defLevels[:0]
+	return nil
+}
@@ -0,0 +1,156 @@
+package converter
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"dataWriter/src/config"
+	"dataWriter/src/spec"
+	"dataWriter/src/util"
+
+	"github.com/pingcap/errors"
+)
+
+// columnGuess tracks the types every sampled value of a column parses as.
+type columnGuess struct {
+	seen      bool
+	isInt     bool
+	isFloat   bool
+	isDate    bool
+	isTime    bool
+	isClock   bool
+	fsp       int
+	maxLength int
+}
+
+func newColumnGuess() *columnGuess {
+	return &columnGuess{isInt: true, isFloat: true, isDate: true, isTime: true, isClock: true}
+}
+
+func (g *columnGuess) add(v string) {
+	g.seen = true
+	g.maxLength = max(g.maxLength, len(v))
+	if g.isInt {
+		_, err := strconv.ParseInt(v, 10, 64)
+		g.isInt = err == nil
+	}
+	if g.isFloat {
+		// ParseFloat also takes words like "inf" and "nan".
+		_, err := strconv.ParseFloat(v, 64)
+		g.isFloat = err == nil && strings.ContainsAny(v, "0123456789")
+	}
+	if g.isDate {
+		_, err := time.Parse(time.DateOnly, v)
+		g.isDate = err == nil
+	}
+	if g.isTime {
+		_, err := time.Parse(time.DateTime, v)
+		g.isTime = err == nil
+	}
+	if g.isClock {
+		_, err := time.Parse(time.TimeOnly, v)
+		g.isClock = err == nil
+	}
+	if _, frac, ok := strings.Cut(v, "."); ok && (g.isTime || g.isClock) {
+		g.fsp = max(g.fsp, min(len(frac), 6))
+	}
+}
+
+// sqlType returns the narrowest type of the sampled values, varchar when
+// there were none or asString is set.
+func (g *columnGuess) sqlType(asString bool) string {
+	switch {
+	case !g.seen || asString:
+		return fmt.Sprintf("varchar(%d)", max(g.maxLength, 1))
+	case g.isInt:
+		return "bigint"
+	case g.isFloat:
+		return "double"
+	case g.isDate:
+		return "date"
+	case g.isTime:
+		return fmt.Sprintf("timestamp(%d)", g.fsp)
+	case g.isClock:
+		return fmt.Sprintf("time(%d)", g.fsp)
+	default:
+		return fmt.Sprintf("varchar(%d)", g.maxLength)
+	}
+}
+
+// InferCSVSpecs builds the column specs of a CSV file starting with a header
+// row, for csv.infer_schema. Columns are named after the header and typed
+// from the first csv.infer_sample_rows rows as the narrowest of bigint,
+// double, date, timestamp, time and varchar every non-NULL value parses as, or
+// as varchar with csv.infer_as_string.
+func InferCSVSpecs(csvPath string, cfg *config.Config) ([]*spec.ColumnSpec, error) {
+	in, err := os.Open(csvPath)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to open csv file: %s", csvPath)
+	}
+	defer in.Close()
+
+	nullString := util.CSVNullString(cfg.CSV)
+	quoter := util.NewCSVQuoter(cfg.CSV)
+	scanner := newLineScanner(in, cfg.CSV, nil)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, errors.Annotatef(err, "failed to read csv file: %s", csvPath)
+		}
+		return nil, errors.Errorf("csv file %s has no header row", csvPath)
+	}
+	header, err := quoter.Split(scanner.Text())
+	if err != nil {
+		return nil, errors.Annotatef(err, "header of %s", csvPath)
+	}
+
+	guesses := make([]*columnGuess, len(header))
+	for i := range guesses {
+		guesses[i] = newColumnGuess()
+	}
+	for rows := 0; rows < cfg.CSV.InferSampleRows && scanner.Scan(); rows++ {
+		record := scanner.Text()
+		fields, err := quoter.Split(record)
+		if err != nil {
+			return nil, errors.Annotatef(err, "line %d: %s", rows+2, quoteRecord(record))
+		}
+		// Ragged rows are reported by the conversion, extra fields have no
+		// column to guess.
+		for i, field := range fields[:min(len(fields), len(header))] {
+			if cfg.CSV.Base64 {
+				decoded, err := base64.StdEncoding.DecodeString(field)
+				if err != nil {
+					return nil, errors.Annotatef(err, "line %d, column %s: %s", rows+2, header[i], quoteRecord(record))
+				}
+				field = string(decoded)
+			}
+			if field != nullString {
+				guesses[i].add(field)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Annotatef(err, "failed to read csv file: %s", csvPath)
+	}
+
+	cols := make([]spec.NamedColumn, len(header))
+	desc := make([]string, len(header))
+	for i, name := range header {
+		cols[i] = spec.NamedColumn{Name: name, Type: guesses[i].sqlType(cfg.CSV.InferAsString)}
+		desc[i] = name + " " + cols[i].Type
+	}
+	specs, err := spec.NamedSpecs(cols, spec.ParseOptions{})
+	if err != nil {
+		return nil, errors.Annotatef(err, "header of %s", csvPath)
+	}
+	util.Infof("Inferred columns: %s", strings.Join(desc, ", "))
+
+	warnings, err := spec.ApplyParquetDialect(specs, cfg.Parquet.Dialect)
+	for _, w := range warnings {
+		util.Warnf("parquet.dialect=%s: %s", cfg.Parquet.Dialect, w)
+	}
+	return specs, errors.Trace(err)
+}
@@ -0,0 +1,272 @@
+package converter
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	"dataWriter/src/config"
+	"dataWriter/src/spec"
+	"dataWriter/src/util"
+
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/schema"
+	"github.com/docker/go-units"
+	"github.com/pingcap/errors"
+)
+
+// readBatchSize is the number of rows read from every column at a time.
+const readBatchSize = 1024
+
+// timestampLayout drops the fraction when it is zero, so second-precision
+// values look like the ones generated for datetime columns.
+const timestampLayout = "2006-01-02 15:04:05.999999999"
+
+// ConvertParquetToCSV converts a Parquet file into CSV written to out,
+// formatting every value the same way the CSV generator does. NULLs are
+// written as csv.null_string. specs, when not nil, are the columns of the
+// SQL schema: Parquet stores binary and string columns alike, so only they
+// tell which columns are binary and base64 encoded like the generator does.
+func ConvertParquetToCSV(parquetPath string, out io.Writer, cfg config.CSVConfig, specs []*spec.ColumnSpec) error {
+	reader, err := file.OpenParquetFile(parquetPath, false)
+	if err != nil {
+		return errors.Annotatef(err, "failed to open parquet file: %s", parquetPath)
+	}
+	defer reader.Close()
+
+	w := bufio.NewWriterSize(out, units.MiB)
+	separator, endline := util.CSVSeparatorAndEndline(cfg)
+	nullString := util.CSVNullString(cfg)
+	quoter := util.NewCSVQuoter(cfg)
+	var quoted []byte
+
+	sc := reader.MetaData().Schema
+	numCols := sc.NumColumns()
+	fields := make([][]string, numCols)
+	binary := make([]bool, numCols)
+	for i := range fields {
+		fields[i] = make([]string, readBatchSize)
+		for _, c := range specs {
+			if c.OrigName == sc.Column(i).Name() {
+				binary[i] = c.IsBinary()
+			}
+		}
+	}
+
+	for rg := range reader.NumRowGroups() {
+		rgr := reader.RowGroup(rg)
+		readers := make([]*columnReader, numCols)
+		for i := range numCols {
+			cr, err := rgr.Column(i)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			readers[i] = newColumnReader(cr, sc.Column(i), nullString)
+		}
+
+		numRows := rgr.NumRows()
+		for done := int64(0); done < numRows; done += readBatchSize {
+			n := min(readBatchSize, numRows-done)
+			for i, cr := range readers {
+				if err := cr.next(n, fields[i][:n]); err != nil {
+					return errors.Annotatef(err, "failed to read column %s", sc.Column(i).Name())
+				}
+			}
+
+			for row := range n {
+				for i := range numCols {
+					if i > 0 {
+						w.WriteString(separator)
+					}
+					s := fields[i][row]
+					isNull := s == nullString
+					if cfg.Base64 || (binary[i] && !isNull) {
+						s = base64.StdEncoding.EncodeToString([]byte(s))
+					}
+					if isNull {
+						w.WriteString(s)
+					} else {
+						quoted = quoter.Append(quoted[:0], s)
+						w.Write(quoted)
+					}
+				}
+				if _, err := w.WriteString(endline); err != nil {
+					return errors.Trace(err)
+				}
+			}
+		}
+	}
+
+	return errors.Trace(w.Flush())
+}
+
+// columnReader reads one column chunk and formats its values as strings.
+type columnReader struct {
+	reader     file.ColumnChunkReader
+	descr      *schema.Column
+	nullString string
+	defLevels  []int16
+	values     any
+}
+
+func newColumnReader(reader file.ColumnChunkReader, descr *schema.Column, nullString string) *columnReader {
+	return &columnReader{
+		reader:     reader,
+		descr:      descr,
+		nullString: nullString,
+		defLevels:  make([]int16, readBatchSize),
+	}
+}
+
+// next reads n rows into out.
+func (c *columnReader) next(n int64, out []string) error {
+	switch r := c.reader.(type) {
+	case *file.BooleanColumnChunkReader:
+		return readColumn(c, n, r.ReadBatch, formatBool, out)
+	case *file.Int32ColumnChunkReader:
+		return readColumn(c, n, r.ReadBatch, c.int32Formatter(), out)
+	case *file.Int64ColumnChunkReader:
+		return readColumn(c, n, r.ReadBatch, c.int64Formatter(), out)
+	case *file.Int96ColumnChunkReader:
+		return readColumn(c, n, r.ReadBatch, func(v parquet.Int96) string {
+			return spec.TimeFromInt96(v).Format(timestampLayout)
+		}, out)
+	case *file.Float32ColumnChunkReader:
+		return readColumn(c, n, r.ReadBatch, func(v float32) string {
+			return strconv.FormatFloat(float64(v), 'g', -1, 32)
+		}, out)
+	case *file.Float64ColumnChunkReader:
+		return readColumn(c, n, r.ReadBatch, func(v float64) string {
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}, out)
+	case *file.ByteArrayColumnChunkReader:
+		return readColumn(c, n, r.ReadBatch, c.bytesFormatter(), out)
+	case *file.FixedLenByteArrayColumnChunkReader:
+		format := c.bytesFormatter()
+		return readColumn(c, n, r.ReadBatch, func(v parquet.FixedLenByteArray) string {
+			return format(parquet.ByteArray(v))
+		}, out)
+	default:
+		return errors.Errorf("unsupported parquet type: %s", c.descr.PhysicalType())
+	}
+}
+
+func readColumn[T any](
+	c *columnReader,
+	n int64,
+	read func(int64, []T, []int16, []int16) (int64, int, error),
+	format func(T) string,
+	out []string,
+) error {
+	values, ok := c.values.([]T)
+	if !ok {
+		values = make([]T, readBatchSize)
+		c.values = values
+	}
+
+	total, _, err := read(n, values, c.defLevels, nil)
+	if err != nil {
+		return err
+	}
+	if total != n {
+		return errors.Errorf("expected %d rows, got %d", n, total)
+	}
+
+	required := c.descr.MaxDefinitionLevel() == 0
+	next := 0
+	for i := range n {
+		if !required && c.defLevels[i] < c.descr.MaxDefinitionLevel() {
+			out[i] = c.nullString
+			continue
+		}
+		out[i] = format(values[next])
+		next++
+	}
+	return nil
+}
+
+func formatBool(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
+func (c *columnReader) int32Formatter() func(int32) string {
+	switch lt := c.descr.LogicalType().(type) {
+	case schema.DateLogicalType:
+		return func(v int32) string {
+			return time.Unix(int64(v)*86400, 0).UTC().Format(time.DateOnly)
+		}
+	case schema.DecimalLogicalType:
+		return func(v int32) string {
+			return spec.FormatDecimal(strconv.FormatInt(int64(v), 10), int(lt.Scale()))
+		}
+	case schema.TimeLogicalType:
+		return func(v int32) string {
+			return formatTimeOfDay(int64(v), lt.TimeUnit())
+		}
+	}
+	return func(v int32) string {
+		return strconv.FormatInt(int64(v), 10)
+	}
+}
+
+func (c *columnReader) int64Formatter() func(int64) string {
+	switch lt := c.descr.LogicalType().(type) {
+	case schema.TimestampLogicalType:
+		return func(v int64) string {
+			return toTime(v, lt.TimeUnit()).Format(timestampLayout)
+		}
+	case schema.DecimalLogicalType:
+		return func(v int64) string {
+			return spec.FormatDecimal(strconv.FormatInt(v, 10), int(lt.Scale()))
+		}
+	case schema.TimeLogicalType:
+		return func(v int64) string {
+			return formatTimeOfDay(v, lt.TimeUnit())
+		}
+	}
+	return func(v int64) string {
+		return strconv.FormatInt(v, 10)
+	}
+}
+
+func (c *columnReader) bytesFormatter() func(parquet.ByteArray) string {
+	if lt, ok := c.descr.LogicalType().(schema.DecimalLogicalType); ok {
+		return func(v parquet.ByteArray) string {
+			return spec.FormatDecimal(decimalFromBytes(v).String(), int(lt.Scale()))
+		}
+	}
+	return func(v parquet.ByteArray) string {
+		return string(v)
+	}
+}
+
+// decimalFromBytes decodes a two's-complement big-endian unscaled value.
+func decimalFromBytes(b []byte) *big.Int {
+	v := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+	}
+	return v
+}
+
+func toTime(v int64, unit schema.TimeUnitType) time.Time {
+	switch unit {
+	case schema.TimeUnitMillis:
+		return time.UnixMilli(v).UTC()
+	case schema.TimeUnitNanos:
+		return time.Unix(0, v).UTC()
+	default:
+		return time.UnixMicro(v).UTC()
+	}
+}
+
+func formatTimeOfDay(v int64, unit schema.TimeUnitType) string {
+	return toTime(v, unit).Format("15:04:05.999999999")
+}
@@ -0,0 +1,44 @@
+package converter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dataWriter/src/config"
+	"dataWriter/src/spec"
+)
+
+func TestParquetToCSVBase64EncodesBinary(t *testing.T) {
+	dir := t.TempDir()
+	sqlPath := filepath.Join(dir, "t.sql")
+	if err := os.WriteFile(sqlPath, []byte("CREATE TABLE t (id bigint, b varbinary(16), s varchar(16));"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	specs, err := spec.GetSpecFromSQL(sqlPath, spec.ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Binary values are base64 encoded in CSV, like the generator writes
+	// them: 00 01 02 ff and "a,b".
+	input := "1,AAEC/w==,x\n2,YSxi,y\n3,\\N,z\n"
+	csvPath := filepath.Join(dir, "t.csv")
+	if err := os.WriteFile(csvPath, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Parquet: config.ParquetConfig{PageSizeBytes: 1 << 20}}
+	output := filepath.Join(dir, "t.parquet")
+	if _, err := convertToFile(t, csvPath, output, specs, cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := ConvertParquetToCSV(output, &buf, cfg.CSV, specs); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != input {
+		t.Errorf("round trip gives %q, want %q", buf.String(), input)
+	}
+}
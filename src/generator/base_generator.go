@@ -3,44 +3,183 @@ package generator
 import (
 	"context"
 	"fmt"
+	"hash/crc32"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"dataWriter/src/config"
 	"dataWriter/src/spec"
 	"dataWriter/src/util"
 
-	"github.com/docker/go-units"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/br/pkg/storage"
 	"golang.org/x/sync/errgroup"
 )
 
+// seededReferenceTime anchors generated time values for seeded runs, so they
+// don't depend on when the run happens.
+var seededReferenceTime = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
 // Orchestrator orchestrates file generation for a single format.
 type Orchestrator struct {
 	FileGenerator
 
-	cfg    *config.Config
-	store  storage.ExternalStorage
-	logger *util.ProgressLogger
+	cfg     *config.Config
+	specs   []*spec.ColumnSpec
+	store   storage.ExternalStorage
+	logger  *util.ProgressLogger
+	timings *columnTimings
+	index   *rowIndex
+	fileLog *fileLog
+	// budget bounds the chunks in flight in streaming mode, nil without
+	// common.max_memory.
+	budget *memoryBudget
+	// limiter caps the write throughput of all files, nil without
+	// common.rate_limit.
+	limiter *rateLimiter
+	// manifest collects the files written, nil without common.manifest.
+	manifest *manifest
+	// rolledFiles is the number of files written with common.max_file_bytes.
+	rolledFiles int
+	// writtenRows counts the rows of the files written in this run, files
+	// skipped by resume are left out.
+	writtenRows atomic.Int64
+	// partitioner generates the files instead of FileGenerator with
+	// common.partition_by, nil otherwise.
+	partitioner *partitioner
+
+	// localDir is common.path when it is local, files are then written
+	// directly instead of through store.
+	localDir string
+}
+
+// LoadSpecs parses the SQL schema, or builds the synthetic_schema of cfg,
+// into column specs using the options in cfg.
+func LoadSpecs(cfg *config.Config, sqlPath string) ([]*spec.ColumnSpec, error) {
+	opts := spec.ParseOptions{
+		SkipUnsupported: cfg.Common.SkipUnsupportedColumns,
+		Dialect:         cfg.Common.SQLDialect,
+	}
+	var (
+		specs []*spec.ColumnSpec
+		err   error
+	)
+	if len(cfg.SyntheticSchema) > 0 {
+		if sqlPath != "" {
+			return nil, errors.New("synthetic_schema cannot be used with -sql")
+		}
+		groups := make([]spec.SyntheticColumns, len(cfg.SyntheticSchema))
+		for i, g := range cfg.SyntheticSchema {
+			groups[i] = spec.SyntheticColumns{Count: g.Columns, Type: g.Type, Options: g.Options}
+		}
+		specs, err = spec.SyntheticSpecs(groups, opts)
+	} else {
+		specs, err = spec.GetSpecFromSQL(sqlPath, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return specs, applyParquetDialect(cfg, specs)
+}
+
+// LoadTables parses every table of the SQL schema by table name, using the
+// options in cfg.
+func LoadTables(cfg *config.Config, sqlPath string) (map[string]*spec.Table, error) {
+	tables, err := spec.GetTablesFromSQL(sqlPath, spec.ParseOptions{
+		SkipUnsupported: cfg.Common.SkipUnsupportedColumns,
+		Dialect:         cfg.Common.SQLDialect,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		if err := applyParquetDialect(cfg, table.Specs); err != nil {
+			return nil, err
+		}
+	}
+	return tables, nil
+}
+
+// ResolveForeignKeys sets the key space of the fk=table.col columns of
+// tables, from the rows the config gives each table.
+func ResolveForeignKeys(cfg *config.Config, tables map[string]*spec.Table) error {
+	files := cfg.Common.EndFileNo - cfg.Common.StartFileNo
+	lastRows := cfg.Common.RowsForFile(cfg.Common.EndFileNo - 1)
+	return spec.ResolveFKRefs(tables, spec.RowSpan{
+		First:   cfg.Common.StartFileNo * cfg.Common.Rows,
+		Total:   (files-1)*cfg.Common.Rows + lastRows,
+		PerFile: max(cfg.Common.Rows, lastRows),
+	})
+}
+
+// applyParquetDialect applies parquet.dialect to the specs and logs the
+// columns the target may not load as expected.
+func applyParquetDialect(cfg *config.Config, specs []*spec.ColumnSpec) error {
+	warnings, err := spec.ApplyParquetDialect(specs, cfg.Parquet.Dialect)
+	for _, w := range warnings {
+		util.Warnf("parquet.dialect=%s: %s", cfg.Parquet.Dialect, w)
+	}
+	return err
 }
 
 // NewOrchestrator creates a orchestrator using the config and SQL schema.
 func NewOrchestrator(cfg *config.Config, sqlPath string) (*Orchestrator, error) {
-	specs, err := spec.GetSpecFromSQL(sqlPath)
+	specs, err := LoadSpecs(cfg, sqlPath)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	return NewOrchestratorFromSpecs(cfg, specs)
+}
+
+// NewOrchestratorFromSpecs creates a orchestrator for already parsed specs.
+func NewOrchestratorFromSpecs(cfg *config.Config, specs []*spec.ColumnSpec) (*Orchestrator, error) {
+	if err := checkSpecs(cfg, specs); err != nil {
+		return nil, err
+	}
+	if err := spec.SetRunContext(specs, newRunContext(cfg)); err != nil {
+		return nil, errors.Trace(err)
+	}
 
-	gen, err := newGenerator(cfg, specs)
+	var timings *columnTimings
+	if cfg.Common.ColumnTiming {
+		timings = newColumnTimings(specs)
+	}
+
+	// target_compressed_size decides the rows of a file while writing it,
+	// they are taken from the index.
+	var index *rowIndex
+	if cfg.Parquet.EmitIndex || cfg.Parquet.TargetCompressedSizeBytes > 0 {
+		index = newRowIndex()
+	}
+
+	gen, err := newGenerator(cfg, specs, timings, index)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkFileNames(cfg, gen.FileSuffix()); err != nil {
+		return nil, err
+	}
+	var m *manifest
+	if cfg.Common.Manifest {
+		m = newManifest()
+	}
+	var part *partitioner
+	if cfg.Common.PartitionBy != "" {
+		if part, err = newPartitioner(cfg, specs, timings); err != nil {
+			return nil, err
+		}
+	}
 
 	store, err := config.GetStore(cfg)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	localDir, _ := config.LocalDir(cfg)
 
 	logger := util.InitializeProgressLogger(
 		cfg.Common.EndFileNo-cfg.Common.StartFileNo,
@@ -51,27 +190,146 @@ func NewOrchestrator(cfg *config.Config, sqlPath string) (*Orchestrator, error)
 		strings.ToLower(cfg.Common.FileFormat),
 		resolvePlatform(cfg),
 	)
+	if cfg.Common.SummaryJSON == "-" {
+		logger.SetSink(util.NewPlainProgressSink(logger, progressOutput(cfg)))
+	}
 
 	return &Orchestrator{
 		FileGenerator: gen,
 
-		cfg:    cfg,
-		store:  store,
-		logger: logger,
+		cfg:     cfg,
+		specs:   specs,
+		store:   store,
+		logger:  logger,
+		timings: timings,
+		index:   index,
+		budget:  newMemoryBudget(cfg.Common.MaxMemoryBytes, logger),
+
+		limiter:     newRateLimiter(cfg.Common.RateLimitBytes),
+		partitioner: part,
+		manifest:    m,
+
+		localDir: localDir,
 	}, nil
 }
 
-func newGenerator(cfg *config.Config, specs []*spec.ColumnSpec) (FileGenerator, error) {
+// progressOutput returns where progress is printed: stderr when the JSON
+// summary is written to stdout, which must stay parseable.
+func progressOutput(cfg *config.Config) *os.File {
+	if cfg.Common.SummaryJSON == "-" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// checkSpecs rejects the column options the files of cfg cannot hold.
+func checkSpecs(cfg *config.Config, specs []*spec.ColumnSpec) error {
+	if err := spec.ValidateOutput(specs, spec.OutputLayout{
+		Format:          strings.ToLower(cfg.Common.FileFormat),
+		RowsPerFile:     cfg.Common.Rows,
+		MaxRowsPerFile:  max(cfg.Common.Rows, cfg.Common.RowsForFile(cfg.Common.EndFileNo-1)),
+		MinRowGroupRows: minRowGroupRows(cfg),
+		TargetSize:      cfg.Parquet.TargetCompressedSizeBytes > 0,
+		MaxFileBytes:    cfg.Common.MaxFileBytes > 0,
+	}); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Parquet.MaxColumnChunkBytes > 0 && strings.ToLower(cfg.Common.FileFormat) == "parquet" &&
+		rowGroupRowsForColumnChunk(cfg, specs) == 0 {
+		return errors.Errorf("parquet.max_column_chunk_bytes %s cannot hold %d values of the widest column, %d bytes each",
+			cfg.Parquet.MaxColumnChunkSize, BatchSize, widestColumnBytes(specs))
+	}
+	if cfg.Common.Broadcast {
+		return checkBroadcast(cfg, specs)
+	}
+	return nil
+}
+
+func newGenerator(cfg *config.Config, specs []*spec.ColumnSpec, timings *columnTimings, index *rowIndex) (FileGenerator, error) {
 	switch strings.ToLower(cfg.Common.FileFormat) {
 	case "parquet":
-		return newParquetGenerator(cfg, specs)
+		return newParquetGenerator(cfg, specs, timings, index)
 	case "csv":
-		return newCSVGenerator(cfg, specs)
+		return newCSVGenerator(cfg, specs, timings)
+	case "ndjson":
+		return newNDJSONGenerator(cfg, specs, timings), nil
 	default:
 		return nil, errors.Errorf("unsupported file format: %s", cfg.Common.FileFormat)
 	}
 }
 
+// newRunContext returns the settings shared by the columns of a run.
+func newRunContext(cfg *config.Config) *spec.RunContext {
+	run := spec.NewRunContext()
+	if cfg.Common.Seed != 0 {
+		run.Seed = uint64(cfg.Common.Seed)
+		run.ReferenceTime = seededReferenceTime
+	}
+	if p := cfg.Common.RowWidthProfile; p != nil {
+		run.WidePercent = p.WidePercent
+	}
+	run.Layout = spec.SequenceLayout{
+		RowsPerFile:     cfg.Common.Rows,
+		LastFile:        cfg.Common.EndFileNo - 1,
+		LastFileRows:    cfg.Common.RowsForFile(cfg.Common.EndFileNo - 1),
+		PartitionOffset: partitionOffsets(cfg),
+	}
+	return run
+}
+
+// fileSeed returns the random seed for a file. With common.seed set every
+// file gets seed+fileNo, so the same config always produces the same data.
+// Broadcast files all get the seed of file 0.
+func fileSeed(cfg *config.Config, fileNo int) int64 {
+	if cfg.Common.Broadcast {
+		fileNo = 0
+	}
+	if cfg.Common.Seed != 0 {
+		return cfg.Common.Seed + int64(fileNo)
+	}
+	return time.Now().UnixNano() + int64(rand.Intn(65536))
+}
+
+func newFileRand(cfg *config.Config, fileNo int) *rand.Rand {
+	return rand.New(rand.NewSource(fileSeed(cfg, fileNo)))
+}
+
+// writtenFileRows returns the rows written to a file, which
+// target_compressed_size only knows once the file is written.
+func (o *Orchestrator) writtenFileRows(fileNo int) int {
+	if o.cfg.Parquet.TargetCompressedSizeBytes > 0 {
+		if rows, ok := o.index.fileRows(fileNo); ok {
+			return rows
+		}
+	}
+	return o.cfg.Common.RowsForFile(fileNo)
+}
+
+// fileStartRow returns the row ID of the first row of a file. Broadcast files
+// all hold rows 0 to rows-1.
+func fileStartRow(cfg *config.Config, fileNo int) int {
+	if cfg.Common.Broadcast {
+		return 0
+	}
+	return fileNo * cfg.Common.Rows
+}
+
+// checkBroadcast rejects the options that make broadcast files differ.
+func checkBroadcast(cfg *config.Config, specs []*spec.ColumnSpec) error {
+	if cfg.Common.Seed == 0 {
+		return errors.New("broadcast requires common.seed, so every file gets the same rows")
+	}
+	if cfg.Common.RowsForFile(cfg.Common.EndFileNo-1) != cfg.Common.Rows {
+		return errors.New("broadcast cannot be used with a common.total_rows that gives the last file more rows")
+	}
+	for _, s := range specs {
+		if s.Order == spec.SequenceOrder && s.SequenceScope == spec.SequenceGlobal {
+			return errors.Errorf("broadcast cannot be used with the global sequence of column %s, use sequence_scope=file", s.OrigName)
+		}
+	}
+	return nil
+}
+
 func resolvePlatform(cfg *config.Config) string {
 	path := strings.ToLower(cfg.Common.Path)
 	if cfg.S3Config != nil || strings.HasPrefix(path, "s3://") {
@@ -83,54 +341,170 @@ func resolvePlatform(cfg *config.Config) string {
 	return "local"
 }
 
-func (o *Orchestrator) openWriter(
-	ctx context.Context,
-	fileID int,
-) (storage.ExternalFileWriter, error) {
-	var fileName string
-	if o.cfg.Common.Folders <= 1 {
-		fileName = fmt.Sprintf("%s.%d.%s",
-			o.cfg.Common.Prefix, fileID, o.FileSuffix())
-	} else {
-		folderID := fileID % o.cfg.Common.Folders
-		fileName = fmt.Sprintf("part%05d/%s.%d.%s",
-			folderID, o.cfg.Common.Prefix, fileID, o.FileSuffix())
+// folderForFile returns the folder of a file: round-robin by default, or a
+// hash of folder_seed and the file number when folder_seed is set.
+func folderForFile(cfg *config.Config, fileID int) int {
+	folders := cfg.Common.Folders
+	if cfg.Common.FolderSeed == 0 {
+		return fileID % folders
 	}
 
-	writer, err := o.store.Create(ctx, fileName, &storage.WriterOption{
-		Concurrency: 8,
+	// splitmix64 finalizer
+	x := uint64(cfg.Common.FolderSeed) ^ (uint64(fileID) * 0x9e3779b97f4a7c15)
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+	return int(x % uint64(folders))
+}
+
+// fileOrder returns the file numbers in the order they are started. A random
+// order is shuffled by common.seed, or by the clock without a seed.
+func fileOrder(cfg *config.Config) []int {
+	fileNos := make([]int, 0, max(cfg.Common.EndFileNo-cfg.Common.StartFileNo, 0))
+	for fileNo := cfg.Common.StartFileNo; fileNo < cfg.Common.EndFileNo; fileNo++ {
+		fileNos = append(fileNos, fileNo)
+	}
+	switch cfg.Common.FileOrder {
+	case "descending":
+		slices.Reverse(fileNos)
+	case "random":
+		seed := cfg.Common.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		rng := rand.New(rand.NewSource(seed))
+		rng.Shuffle(len(fileNos), func(i, j int) {
+			fileNos[i], fileNos[j] = fileNos[j], fileNos[i]
+		})
+	}
+	return fileNos
+}
+
+// partitionOffsets returns, for each file number, the rows of the earlier
+// files in the same folder, counting from start_fileno.
+func partitionOffsets(cfg *config.Config) func(fileNo int) int {
+	start := cfg.Common.StartFileNo
+	offsets := make([]int, max(cfg.Common.EndFileNo-start, 0))
+	folderRows := make(map[int]int)
+	for i := range offsets {
+		fileNo := start + i
+		folder := 0
+		if cfg.Common.Folders > 1 {
+			folder = folderForFile(cfg, fileNo)
+		}
+		offsets[i] = folderRows[folder]
+		folderRows[folder] += cfg.Common.RowsForFile(fileNo)
+	}
+	return func(fileNo int) int {
+		return offsets[fileNo-start]
+	}
+}
+
+// fileName returns the object name of a file relative to common.path.
+func (o *Orchestrator) fileName(fileID int) (string, error) {
+	name, err := o.cfg.Common.FileName(fileNameData(o.cfg, fileID, o.FileSuffix()))
+	return name, errors.Trace(err)
+}
+
+func fileNameData(cfg *config.Config, fileID int, suffix string) config.FileNameData {
+	data := config.FileNameData{
+		FileNo: fileID,
+		Total:  cfg.Common.EndFileNo,
+		Prefix: cfg.Common.Prefix,
+		Suffix: suffix,
+	}
+	if cfg.Common.Folders > 1 {
+		data.Folder = folderForFile(cfg, fileID)
+	}
+	return data
+}
+
+// checkFileNames renders the name of every file and rejects a
+// filename_template that fails or gives two files the same name.
+func checkFileNames(cfg *config.Config, suffix string) error {
+	if cfg.Common.FileNameTmpl == nil {
+		return nil
+	}
+	seen := make(map[string]int)
+	for fileNo := cfg.Common.StartFileNo; fileNo < cfg.Common.EndFileNo; fileNo++ {
+		name, err := cfg.Common.FileName(fileNameData(cfg, fileNo, suffix))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if prev, ok := seen[name]; ok {
+			return errors.Errorf("filename_template gives files %d and %d the same name %s", prev, fileNo, name)
+		}
+		seen[name] = fileNo
+	}
+	return nil
+}
+
+// existingFiles lists the non-empty files under common.path. It runs before
+// any writer starts, so the result can be read concurrently without locking.
+func (o *Orchestrator) existingFiles(ctx context.Context) (map[string]struct{}, error) {
+	existing := make(map[string]struct{})
+	err := o.store.WalkDir(ctx, &storage.WalkOption{}, func(path string, size int64) error {
+		if size > 0 {
+			existing[strings.TrimPrefix(path, "/")] = struct{}{}
+		}
+		return nil
 	})
 	if err != nil {
-		return nil, errors.Trace(err)
+		return nil, errors.Annotate(err, "failed to list existing files for resume")
 	}
-
-	return &writerWithStats{writer: writer, logger: o.logger}, nil
+	return existing, nil
 }
 
-func (o *Orchestrator) Close() {
-	o.store.Close()
+func (o *Orchestrator) openWriter(
+	ctx context.Context,
+	fileID int,
+) (*writerWithStats, error) {
+	name, err := o.fileName(fileID)
+	if err != nil {
+		return nil, err
+	}
+	return o.openNamedWriter(ctx, fileID, name, o.cfg.Common.RowsForFile(fileID))
 }
 
-func (o *Orchestrator) printSummary(elapsed time.Duration) {
-	files, bytes := o.logger.Snapshot()
-	if files == 0 {
-		files = int64(o.cfg.Common.EndFileNo - o.cfg.Common.StartFileNo)
+// openNamedWriter opens the file fileName relative to common.path for the
+// file number fileID, which will hold rows rows.
+func (o *Orchestrator) openNamedWriter(
+	ctx context.Context,
+	fileID int,
+	fileName string,
+	rows int,
+) (*writerWithStats, error) {
+	var (
+		writer storage.ExternalFileWriter
+		err    error
+	)
+	if o.localDir != "" {
+		writer, err = createLocalFile(filepath.Join(o.localDir, fileName), o.cfg.Common.LocalBufferSizeBytes)
+	} else {
+		writer, err = o.store.Create(ctx, fileName, o.cfg.Common.WriterOption())
 	}
-	rowsPerFile := o.cfg.Common.Rows
-	totalRows := int64(rowsPerFile) * files
-	throughput := 0.0
-	if elapsed.Seconds() > 0 {
-		throughput = float64(bytes) / elapsed.Seconds()
+	if err != nil {
+		return nil, errors.Trace(asStorageError(err))
 	}
 
-	fmt.Println("Summary:")
-	fmt.Printf("  Format: %s\n", strings.ToLower(o.cfg.Common.FileFormat))
-	fmt.Printf("  Files: %d\n", files)
-	fmt.Printf("  Rows/File: %d\n", rowsPerFile)
-	fmt.Printf("  Total Rows: %d\n", totalRows)
-	fmt.Printf("  Bytes: %s\n", units.BytesSize(float64(bytes)))
-	fmt.Printf("  Throughput: %s/s\n", units.BytesSize(throughput))
-	fmt.Printf("  Path: %s\n", o.cfg.Common.Path)
+	w := &writerWithStats{
+		writer: writer, logger: o.logger, fileLog: o.fileLog, fileNo: fileID, limiter: o.limiter,
+		name: fileName, rows: rows,
+	}
+	if o.manifest != nil {
+		w.manifest, w.crc = o.manifest, crc32.New(crc32cTable)
+	}
+	switch format := strings.ToLower(o.cfg.Common.FileFormat); {
+	case format == "csv" && o.cfg.CSV.IsGzip():
+		w.gzip = newGzipWriter(o.cfg.CSV.GzipMemberPerChunk)
+	case format == "ndjson" && o.cfg.NDJSON.IsGzip():
+		w.gzip = newGzipWriter(false)
+	}
+	return w, nil
+}
+
+func (o *Orchestrator) Close() {
+	o.store.Close()
 }
 
 func (o *Orchestrator) generateDirect(ctx context.Context, fileNo int) error {
@@ -139,52 +513,93 @@ func (o *Orchestrator) generateDirect(ctx context.Context, fileNo int) error {
 		return errors.Trace(err)
 	}
 
-	defer writer.Close(ctx)
+	o.logger.SetFileState(fileNo, util.FileGenerating)
 	if err = o.GenerateFile(ctx, writer, fileNo); err != nil {
+		writer.Close(ctx)
+		writer.discard()
+		return errors.Trace(err)
+	}
+	o.logger.SetFileState(fileNo, util.FileWriting)
+	if err = writer.Close(ctx); err != nil {
+		writer.discard()
 		return errors.Trace(err)
 	}
+	o.logger.SetFileState(fileNo, util.FileDone)
 	o.logger.UpdateFiles(1)
 	return nil
 }
 
 func (o *Orchestrator) generateStreaming(ctx context.Context, fileNo int) error {
-	var eg errgroup.Group
+	// A failed writer cancels the generator, which would block on a full
+	// channel otherwise.
+	eg, ctx := errgroup.WithContext(ctx)
 
 	chunkChannel := make(chan *util.FileChunk, 4)
+	produced := chunkChannel
+	if o.budget != nil {
+		produced = make(chan *util.FileChunk)
+		eg.Go(func() error {
+			return o.budget.relay(ctx, produced, chunkChannel)
+		})
+	}
+	o.logger.SetFileState(fileNo, util.FileGenerating)
 	eg.Go(func() error {
-		defer close(chunkChannel)
-		return o.GenerateFileStreaming(ctx, fileNo, chunkChannel)
+		defer close(produced)
+		if err := o.GenerateFileStreaming(ctx, fileNo, produced); err != nil {
+			return err
+		}
+		o.logger.SetFileState(fileNo, util.FileWriting)
+		return nil
 	})
 
+	var writer *writerWithStats
 	eg.Go(func() error {
-		writer, err := o.openWriter(ctx, fileNo)
+		var err error
+		writer, err = o.openWriter(ctx, fileNo)
 		if err != nil {
 			return errors.Trace(err)
 		}
-		defer writer.Close(ctx)
-		for {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case chunk, ok := <-chunkChannel:
-				if !ok {
-					return nil
-				}
-				if _, err := writer.Write(ctx, chunk.Data); err != nil {
-					return errors.Trace(err)
-				}
-
-				if chunk.IsLast {
-					return nil
+		writeErr := func() error {
+			for {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case chunk, ok := <-chunkChannel:
+					if !ok {
+						return nil
+					}
+					_, err := writer.Write(ctx, chunk.Data)
+					o.budget.release(chunk)
+					if err != nil {
+						return errors.Trace(err)
+					}
+
+					if chunk.IsLast {
+						return nil
+					}
 				}
 			}
+		}()
+		// Close finishes the file, e.g. with the gzip trailer, so its error
+		// fails the file too.
+		if err := writer.Close(ctx); err != nil && writeErr == nil {
+			return errors.Trace(err)
 		}
+		return writeErr
 	})
 
 	if err := eg.Wait(); err != nil {
+		if writer != nil {
+			writer.discard()
+		}
+		// Chunks the writer didn't get to still hold budget.
+		for chunk := range chunkChannel {
+			o.budget.release(chunk)
+		}
 		return err
 	}
 
+	o.logger.SetFileState(fileNo, util.FileDone)
 	o.logger.UpdateFiles(1)
 	return nil
 }
@@ -197,27 +612,156 @@ func (o *Orchestrator) Run(streaming bool, threads int) error {
 	eg, _ := errgroup.WithContext(ctx)
 	eg.SetLimit(threads)
 
+	if o.cfg.Common.LogFile != "" {
+		var err error
+		if o.fileLog, err = openFileLog(o.cfg.Common.LogFile); err != nil {
+			o.logger.Stop()
+			return err
+		}
+		defer func() {
+			_ = o.fileLog.close()
+		}()
+	}
+
+	var existing map[string]struct{}
+	if o.cfg.Common.Resume {
+		var err error
+		if existing, err = o.existingFiles(ctx); err != nil {
+			o.logger.Stop()
+			return err
+		}
+	}
+
 	startNo := o.cfg.Common.StartFileNo
 	endNo := o.cfg.Common.EndFileNo
-	for fileNo := startNo; fileNo < endNo; fileNo++ {
-		fileID := fileNo
+	var skipped int
+	if o.cfg.Parquet.SingleFile {
+		name, err := o.fileName(startNo)
+		if err != nil {
+			o.logger.Stop()
+			return err
+		}
+		if _, ok := existing[name]; ok {
+			skipped = endNo - startNo
+			o.logger.UpdateFiles(int32(skipped))
+		} else {
+			eg.Go(func() error {
+				if err := o.waitForRunWindow(ctx); err != nil {
+					return err
+				}
+				fileStart := time.Now()
+				err := o.runSingleFile(ctx, threads)
+				rows := 0
+				for fileNo := startNo; fileNo < endNo; fileNo++ {
+					rows += o.cfg.Common.RowsForFile(fileNo)
+				}
+				if err == nil {
+					o.writtenRows.Add(int64(rows))
+				}
+				if logErr := o.fileLog.record(startNo, name, rows, fileStart, err); err == nil {
+					err = logErr
+				}
+				return err
+			})
+		}
+	} else if o.cfg.Common.MaxFileBytes > 0 {
 		eg.Go(func() error {
-			if streaming {
-				return o.generateStreaming(ctx, fileID)
+			if err := o.waitForRunWindow(ctx); err != nil {
+				return err
 			}
-			return o.generateDirect(ctx, fileID)
+			var err error
+			o.rolledFiles, err = o.runRolling(ctx)
+			return err
 		})
+	} else {
+		if o.cfg.Common.ProgressDetail {
+			names := make([]string, 0, endNo-startNo)
+			for fileNo := startNo; fileNo < endNo; fileNo++ {
+				name, err := o.fileName(fileNo)
+				if err != nil {
+					o.logger.Stop()
+					return err
+				}
+				names = append(names, name)
+			}
+			o.logger.EnableFileDetail(startNo, names)
+		}
+		for _, fileID := range fileOrder(o.cfg) {
+			name, err := o.fileName(fileID)
+			if err != nil {
+				// Files already started stop with the group.
+				eg.Go(func() error { return err })
+				break
+			}
+			if _, ok := existing[name]; ok {
+				skipped++
+				o.logger.SetFileState(fileID, util.FileDone)
+				o.logger.UpdateFiles(1)
+				continue
+			}
+			eg.Go(func() error {
+				// Files already running finish, new ones wait for the window.
+				if err := o.waitForRunWindow(ctx); err != nil {
+					return err
+				}
+				fileStart := time.Now()
+				err := o.withRetry(ctx, fileID, func() error {
+					switch {
+					case o.partitioner != nil:
+						return o.generatePartitioned(ctx, fileID)
+					case streaming:
+						return o.generateStreaming(ctx, fileID)
+					}
+					return o.generateDirect(ctx, fileID)
+				})
+				rows := o.writtenFileRows(fileID)
+				if err != nil {
+					o.logger.SetFileState(fileID, util.FileFailed)
+				} else {
+					o.writtenRows.Add(int64(rows))
+				}
+				if logErr := o.fileLog.record(fileID, name, rows, fileStart, err); err == nil {
+					err = logErr
+				}
+				return err
+			})
+		}
 	}
 
 	if err := eg.Wait(); err != nil {
-		fmt.Println()
-		fmt.Printf("Generate and upload failed after %s\n", time.Since(start))
+		o.logger.Stop()
+		util.Errorf("Generate and upload failed after %s", time.Since(start))
 		return errors.Trace(err)
 	}
 
+	if o.cfg.Common.EmitSchema {
+		if err := o.writeSchemaSidecar(ctx); err != nil {
+			o.logger.Stop()
+			return errors.Trace(err)
+		}
+	}
+	if o.cfg.Parquet.EmitIndex {
+		if err := o.writeIndexSidecar(ctx); err != nil {
+			o.logger.Stop()
+			return errors.Trace(err)
+		}
+	}
+	if o.manifest != nil {
+		if err := o.writeManifestSidecar(ctx); err != nil {
+			o.logger.Stop()
+			return errors.Trace(err)
+		}
+	}
+
 	elapsed := time.Since(start)
-	fmt.Println()
-	fmt.Printf("Generate and upload took %s\n", elapsed)
-	o.printSummary(elapsed)
-	return nil
+	o.logger.Stop()
+	fmt.Fprintln(progressOutput(o.cfg))
+	if skipped > 0 {
+		util.Infof("Resume skipped %d existing files", skipped)
+	}
+	util.Infof("Generate and upload took %s", elapsed)
+	if err := o.printSummary(elapsed); err != nil {
+		return err
+	}
+	return o.runPostHook(ctx, elapsed)
 }
@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+
+	"dataWriter/src/config"
+	"dataWriter/src/spec"
+
+	"github.com/BurntSushi/toml"
+	"github.com/apache/arrow-go/v18/parquet/file"
+)
+
+// testConfig decodes, normalizes and validates a TOML config.
+func testConfig(t *testing.T, text string) *config.Config {
+	t.Helper()
+	var cfg config.Config
+	if _, err := toml.Decode(text, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Normalize(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Validate(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	return &cfg
+}
+
+// testSpecs parses a CREATE TABLE statement.
+func testSpecs(t testing.TB, sql string) []*spec.ColumnSpec {
+	t.Helper()
+	sqlPath := filepath.Join(t.TempDir(), "schema.sql")
+	if err := os.WriteFile(sqlPath, []byte(sql), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	specs, err := spec.GetSpecFromSQL(sqlPath, spec.ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return specs
+}
+
+// countParquetRows returns the rows of a local Parquet file.
+func countParquetRows(path string) (int, error) {
+	r, err := file.OpenParquetFile(path, false)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	return int(r.NumRows()), nil
+}
+
+// testFileName returns the name of file fileNo of o.
+func testFileName(t *testing.T, o *Orchestrator, fileNo int) string {
+	t.Helper()
+	name, err := o.fileName(fileNo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+// runTest generates the files of cfg and returns the orchestrator.
+func runTest(t *testing.T, cfg *config.Config, specs []*spec.ColumnSpec) *Orchestrator {
+	t.Helper()
+	o, err := NewOrchestratorFromSpecs(cfg, specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+	if err := o.Run(cfg.Common.UseStreamingMode, 2); err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestRunReturnsFileNameError(t *testing.T) {
+	cfg := testConfig(t, fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 2
+rows = 10
+format = "csv"
+`, t.TempDir()))
+	o, err := NewOrchestratorFromSpecs(cfg, testSpecs(t, "CREATE TABLE t (id bigint);"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+	// Swap in a template that fails to render after the names were checked.
+	cfg.Common.FileNameTmpl = template.Must(template.New("name").Parse("{{.Missing}}"))
+	if err := o.Run(false, 2); err == nil {
+		t.Fatal("Run succeeded with a failing filename_template")
+	}
+}
@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"dataWriter/src/spec"
+)
+
+// columnTimings accumulates the time spent generating each column across all
+// files. A nil *columnTimings disables timing, so the only cost when it is
+// off is a nil check per batch or field.
+type columnTimings struct {
+	names []string
+	nanos []atomic.Int64
+}
+
+func newColumnTimings(specs []*spec.ColumnSpec) *columnTimings {
+	t := &columnTimings{
+		names: make([]string, len(specs)),
+		nanos: make([]atomic.Int64, len(specs)),
+	}
+	for i, c := range specs {
+		t.names[i] = c.OrigName
+	}
+	return t
+}
+
+func (t *columnTimings) start() time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+func (t *columnTimings) add(col int, start time.Time) {
+	if t == nil {
+		return
+	}
+	t.nanos[col].Add(int64(time.Since(start)))
+}
+
+// ColumnTiming is the total generation time of a column.
+type ColumnTiming struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+	Percent float64 `json:"percent"`
+}
+
+// sorted returns the timings from the slowest column to the fastest.
+func (t *columnTimings) sorted() []ColumnTiming {
+	if t == nil {
+		return nil
+	}
+
+	var total int64
+	for i := range t.nanos {
+		total += t.nanos[i].Load()
+	}
+	result := make([]ColumnTiming, len(t.names))
+	for i, name := range t.names {
+		ns := t.nanos[i].Load()
+		result[i] = ColumnTiming{Name: name, Seconds: time.Duration(ns).Seconds()}
+		if total > 0 {
+			result[i].Percent = float64(ns) * 100 / float64(total)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Seconds > result[j].Seconds
+	})
+	return result
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"math/rand"
-	"time"
+	"strings"
 	"unsafe"
 
 	"dataWriter/src/config"
@@ -12,6 +12,7 @@ import (
 	"dataWriter/src/util"
 
 	"github.com/docker/go-units"
+	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/br/pkg/storage"
 )
 
@@ -19,54 +20,104 @@ func string2Bytes(s string) []byte {
 	return unsafe.Slice(unsafe.StringData(s), len(s))
 }
 
+// csvRowFormat holds the settings used to write a CSV row.
+type csvRowFormat struct {
+	base64     bool
+	separator  []byte
+	endline    []byte
+	nullString string
+	// quoter quotes non-NULL fields as csv.quote asks.
+	quoter util.CSVQuoter
+}
+
 func generateCSVRow(
 	specs []*spec.ColumnSpec,
 	rowID int,
-	withBase64 bool,
 	rng *rand.Rand,
 	buf []byte,
-	separator []byte,
-	endline []byte,
+	format *csvRowFormat,
+	timings *columnTimings,
 ) []byte {
 	for i, columnSpec := range specs {
+		start := timings.start()
 		s := spec.GenerateSingleField(rowID, columnSpec, rng)
-		if withBase64 {
-			s = base64.StdEncoding.EncodeToString(string2Bytes(s))
-		}
+		timings.add(i, start)
 		if i > 0 {
-			buf = append(buf, separator...)
+			buf = append(buf, format.separator...)
 		}
-		buf = append(buf, s...)
+		buf = format.appendField(buf, columnSpec, rowID, s)
 	}
-	buf = append(buf, endline...)
+	buf = append(buf, format.endline...)
 	return buf
 }
 
+// appendField appends the generated value s of column c as a CSV field.
+func (format *csvRowFormat) appendField(buf []byte, c *spec.ColumnSpec, rowID int, s string) []byte {
+	isNull := s == spec.NullValue
+	if isNull {
+		s = format.nullString
+	} else {
+		s = c.TypeNoise(rowID, s)
+	}
+	// Raw bytes would break the CSV, so binary values are always base64.
+	if format.base64 || (c.IsBinary() && !isNull) {
+		s = base64.StdEncoding.EncodeToString(string2Bytes(s))
+	}
+	if isNull {
+		return append(buf, s...)
+	}
+	return format.quoter.Append(buf, s)
+}
+
 // CSVGenerator implements FormatGenerator for CSV files.
 type CSVGenerator struct {
 	cfg             *config.Config
 	specs           []*spec.ColumnSpec
 	chunkCalculator util.ChunkCalculator
-	separatorBytes  []byte
-	endlineBytes    []byte
+	format          *csvRowFormat
+	timings         *columnTimings
 }
 
 func newCSVGenerator(
 	cfg *config.Config,
 	specs []*spec.ColumnSpec,
+	timings *columnTimings,
 ) (*CSVGenerator, error) {
 	separator, endline := util.CSVSeparatorAndEndline(cfg.CSV)
+	if spec.MayAppearInValues(separator) && cfg.CSV.QuoteMode() == "never" {
+		return nil, errors.Errorf("csv separator %q may appear in generated values, use another separator or csv.quote", separator)
+	}
+	// Keep padded whitespace from being read as a separator or line break.
+	for _, s := range specs {
+		s.ExcludeWhitespace(separator + endline)
+		// Unquoted, comma-joined SET values or formatted numbers would be
+		// split into several fields.
+		hasComma := s.SQLType == "set" || s.NumberFormat != spec.NumberFormatNone
+		if hasComma && strings.Contains(separator, ",") && !cfg.CSV.Base64 && cfg.CSV.QuoteMode() == "never" {
+			return nil, errors.Errorf("column %s may contain ',' and needs a csv separator other than ',', csv.quote or csv.base64", s.OrigName)
+		}
+	}
 	return &CSVGenerator{
 		cfg:             cfg,
 		specs:           specs,
 		chunkCalculator: util.NewChunkSizeCalculator(cfg),
-		separatorBytes:  []byte(separator),
-		endlineBytes:    []byte(endline),
+		format: &csvRowFormat{
+			base64:     cfg.CSV.Base64,
+			separator:  []byte(separator),
+			endline:    []byte(endline),
+			nullString: util.CSVNullString(cfg.CSV),
+			quoter:     util.NewCSVQuoter(cfg.CSV),
+		},
+		timings: timings,
 	}, nil
 }
 
+func (g *CSVGenerator) generateRow(rowID int, rng *rand.Rand, buf []byte) []byte {
+	return generateCSVRow(g.specs, rowID, rng, buf, g.format, g.timings)
+}
+
 func (g *CSVGenerator) FileSuffix() string {
-	return "csv"
+	return g.cfg.CSV.FileSuffix()
 }
 
 func (g *CSVGenerator) GenerateFile(
@@ -74,23 +125,24 @@ func (g *CSVGenerator) GenerateFile(
 	writer storage.ExternalFileWriter,
 	fileNo int,
 ) error {
+	if g.cfg.CSV.IsGzip() {
+		// The writer compresses every write on its own with
+		// gzip_member_per_chunk, so write the chunks of the streaming mode.
+		return g.generateChunks(fileNo, func(data []byte, _ bool) error {
+			_, err := writer.Write(ctx, data)
+			return err
+		})
+	}
+
 	var (
-		rng        = rand.New(rand.NewSource(time.Now().UnixNano() + int64(rand.Intn(16))))
+		rng        = newFileRand(g.cfg, fileNo)
 		buffer     = make([]byte, 0, 64*units.KiB)
-		startRowID = fileNo * g.cfg.Common.Rows
+		startRowID = fileStartRow(g.cfg, fileNo)
 	)
 
-	for i := range g.cfg.Common.Rows {
+	for i := range g.cfg.Common.RowsForFile(fileNo) {
 		rowID := startRowID + i
-		buffer = generateCSVRow(
-			g.specs,
-			rowID,
-			g.cfg.CSV.Base64,
-			rng,
-			buffer[:0],
-			g.separatorBytes,
-			g.endlineBytes,
-		)
+		buffer = generateCSVRow(g.specs, rowID, rng, buffer[:0], g.format, g.timings)
 		if _, err := writer.Write(ctx, buffer); err != nil {
 			return err
 		}
@@ -103,12 +155,30 @@ func (g *CSVGenerator) GenerateFileStreaming(
 	ctx context.Context,
 	fileNo int,
 	chunkChannel chan<- *util.FileChunk,
+) error {
+	return g.generateChunks(fileNo, func(data []byte, isLast bool) error {
+		select {
+		case chunkChannel <- &util.FileChunk{
+			Data:   data,
+			IsLast: isLast,
+		}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// generateChunks generates a file in chunks of rows and passes them to emit.
+func (g *CSVGenerator) generateChunks(
+	fileNo int,
+	emit func(data []byte, isLast bool) error,
 ) error {
 	var (
-		rng = rand.New(rand.NewSource(time.Now().UnixNano() + int64(rand.Intn(16))))
+		rng = newFileRand(g.cfg, fileNo)
 
-		startRowID = fileNo * g.cfg.Common.Rows
-		totalRows  = g.cfg.Common.Rows
+		startRowID = fileStartRow(g.cfg, fileNo)
+		totalRows  = g.cfg.Common.RowsForFile(fileNo)
 
 		specs      = g.specs
 		rowSize    = g.chunkCalculator.EstimateRowSize(specs)
@@ -123,24 +193,11 @@ func (g *CSVGenerator) GenerateFileStreaming(
 
 		for i := range rowsInChunk {
 			rowID := startRowID + rowOffset + i
-			buffer = generateCSVRow(
-				specs,
-				rowID,
-				g.cfg.CSV.Base64,
-				rng,
-				buffer,
-				g.separatorBytes,
-				g.endlineBytes,
-			)
+			buffer = generateCSVRow(specs, rowID, rng, buffer, g.format, g.timings)
 		}
 
-		select {
-		case chunkChannel <- &util.FileChunk{
-			Data:   buffer,
-			IsLast: isLast,
-		}:
-		case <-ctx.Done():
-			return ctx.Err()
+		if err := emit(buffer, isLast); err != nil {
+			return err
 		}
 	}
 
@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCSVTabSeparator(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(t, fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 1
+rows = 100
+format = "csv"
+
+[csv]
+separator = '\t'
+endline = '\r\n'
+`, dir))
+	o := runTest(t, cfg, testSpecs(t, "CREATE TABLE t (id bigint, s varchar(20), d date);"))
+
+	name := testFileName(t, o, 0)
+	if !strings.HasSuffix(name, ".tsv") {
+		t.Errorf("file name %s, want a .tsv file", name)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(`\t`)) || bytes.Contains(data, []byte(`\r`)) {
+		t.Error("file holds escape sequences instead of tab and carriage return bytes")
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\r\n"), "\r\n")
+	if len(lines) != 100 {
+		t.Fatalf("file has %d CRLF lines, want 100", len(lines))
+	}
+	for i, line := range lines {
+		if n := strings.Count(line, "\t"); n != 2 {
+			t.Fatalf("line %d has %d tab bytes, want 2: %q", i+1, n, line)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+)
+
+// fileLogEntry is one line of common.log_file.
+type fileLogEntry struct {
+	Time            string  `json:"time"`
+	FileNo          int     `json:"file_no"`
+	File            string  `json:"file"`
+	Rows            int     `json:"rows"`
+	Bytes           int64   `json:"bytes"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Success         bool    `json:"success"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// fileLog appends a JSON line to common.log_file as each file finishes. A
+// nil fileLog records nothing.
+type fileLog struct {
+	mu    sync.Mutex
+	f     *os.File
+	bytes map[int]int64
+}
+
+func openFileLog(path string) (*fileLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to open log file %s", path)
+	}
+	return &fileLog{f: f, bytes: make(map[int]int64)}, nil
+}
+
+// addBytes counts bytes written to fileNo.
+func (l *fileLog) addBytes(fileNo int, n int64) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.bytes[fileNo] += n
+	l.mu.Unlock()
+}
+
+// record writes the entry of a finished file, err is nil on success.
+func (l *fileLog) record(fileNo int, name string, rows int, start time.Time, err error) error {
+	if l == nil {
+		return nil
+	}
+	entry := fileLogEntry{
+		Time:            time.Now().Format(time.RFC3339Nano),
+		FileNo:          fileNo,
+		File:            name,
+		Rows:            rows,
+		DurationSeconds: time.Since(start).Seconds(),
+		Success:         err == nil,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry.Bytes = l.bytes[fileNo]
+	delete(l.bytes, fileNo)
+	data, mErr := json.Marshal(entry)
+	if mErr != nil {
+		return errors.Trace(mErr)
+	}
+	_, wErr := l.f.Write(append(data, '\n'))
+	return errors.Annotatef(wErr, "failed to write log file %s", l.f.Name())
+}
+
+func (l *fileLog) close() error {
+	if l == nil {
+		return nil
+	}
+	return errors.Trace(l.f.Close())
+}
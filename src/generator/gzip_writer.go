@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"bytes"
+	"compress/gzip"
+)
+
+// gzipWriter compresses the bytes written to a data file, for
+// csv.compression and ndjson.compression. Everything goes into a single gzip
+// member, whose compressed bytes come out as the deflate window fills, unless
+// memberPerWrite is set: then every write is closed as a member of its own,
+// so a file written in chunks is a concatenation of members that can each be
+// decompressed on its own.
+type gzipWriter struct {
+	buf            bytes.Buffer
+	zw             *gzip.Writer
+	memberPerWrite bool
+	// closed is set after a member is closed, the next write starts a new
+	// one.
+	closed bool
+}
+
+func newGzipWriter(memberPerWrite bool) *gzipWriter {
+	g := &gzipWriter{memberPerWrite: memberPerWrite}
+	g.zw = gzip.NewWriter(&g.buf)
+	return g
+}
+
+// compress returns the compressed bytes of p that are ready, possibly none.
+func (g *gzipWriter) compress(p []byte) ([]byte, error) {
+	g.buf.Reset()
+	if g.closed {
+		g.zw.Reset(&g.buf)
+		g.closed = false
+	}
+	if _, err := g.zw.Write(p); err != nil {
+		return nil, err
+	}
+	if g.memberPerWrite {
+		if err := g.zw.Close(); err != nil {
+			return nil, err
+		}
+		g.closed = true
+	}
+	return bytes.Clone(g.buf.Bytes()), nil
+}
+
+// finish returns the rest of the compressed bytes and the gzip trailer of the
+// open member. A file without any write gets an empty member.
+func (g *gzipWriter) finish() ([]byte, error) {
+	g.buf.Reset()
+	if g.closed {
+		return nil, nil
+	}
+	if err := g.zw.Close(); err != nil {
+		return nil, err
+	}
+	g.closed = true
+	return bytes.Clone(g.buf.Bytes()), nil
+}
@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readGzip returns the decompressed content of a gzip file.
+func readGzip(t *testing.T, path string) []byte {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestGzipStreamedMatchesDirect(t *testing.T) {
+	specs := testSpecs(t, "CREATE TABLE t (id bigint, s varchar(40), d decimal(10,2));")
+	for _, format := range []string{"csv", "ndjson"} {
+		t.Run(format, func(t *testing.T) {
+			run := func(streaming bool, compression string) []byte {
+				dir := t.TempDir()
+				cfg := testConfig(t, fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 1
+rows = 5000
+format = %q
+seed = 1
+chunk_size = "16KiB"
+use_streaming_mode = %v
+
+[csv]
+compression = %q
+
+[ndjson]
+compression = %q
+`, dir, format, streaming, compression, compression))
+				o := runTest(t, cfg, specs)
+				path := filepath.Join(dir, testFileName(t, o, 0))
+				if compression == "gzip" {
+					return readGzip(t, path)
+				}
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return data
+			}
+
+			plain := run(false, "none")
+			if len(plain) == 0 {
+				t.Fatal("direct file is empty")
+			}
+			if got := run(true, "gzip"); !bytes.Equal(got, plain) {
+				t.Errorf("streamed gzip file decompresses to %d bytes, differing from the %d bytes of the direct file", len(got), len(plain))
+			}
+			if got := run(false, "gzip"); !bytes.Equal(got, plain) {
+				t.Errorf("direct gzip file decompresses to %d bytes, differing from the %d bytes of the direct file", len(got), len(plain))
+			}
+		})
+	}
+}
+
+func TestGzipMemberPerChunk(t *testing.T) {
+	specs := testSpecs(t, "CREATE TABLE t (id bigint, s varchar(40));")
+	run := func(streaming bool, compression string, memberPerChunk bool) string {
+		dir := t.TempDir()
+		cfg := testConfig(t, fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 1
+rows = 5000
+format = "csv"
+seed = 1
+chunk_size = "16KiB"
+use_streaming_mode = %v
+
+[csv]
+compression = %q
+gzip_member_per_chunk = %v
+`, dir, streaming, compression, memberPerChunk))
+		o := runTest(t, cfg, specs)
+		return filepath.Join(dir, testFileName(t, o, 0))
+	}
+	plain, err := os.ReadFile(run(false, "none", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, streaming := range []bool{false, true} {
+		path := run(streaming, "gzip", true)
+		if got := readGzip(t, path); !bytes.Equal(got, plain) {
+			t.Errorf("streaming=%v: members decompress to %d bytes, want the %d bytes of the plain file", streaming, len(got), len(plain))
+		}
+
+		// Decompress member by member, each with a reader of its own. A
+		// bytes.Reader is a ByteReader, so gzip stops right after a member.
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var joined []byte
+		members := 0
+		for r := bytes.NewReader(data); r.Len() > 0; members++ {
+			zr, err := gzip.NewReader(r)
+			if err != nil {
+				t.Fatalf("streaming=%v: member %d: %v", streaming, members, err)
+			}
+			zr.Multistream(false)
+			member, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatalf("streaming=%v: member %d: %v", streaming, members, err)
+			}
+			if len(member) == 0 || member[len(member)-1] != '\n' {
+				t.Errorf("streaming=%v: member %d doesn't end with a row", streaming, members)
+			}
+			joined = append(joined, member...)
+		}
+		if members < 2 {
+			t.Errorf("streaming=%v: file has %d gzip members, want one per chunk", streaming, members)
+		}
+		if !bytes.Equal(joined, plain) {
+			t.Errorf("streaming=%v: members hold %d bytes, want the %d bytes of the plain file", streaming, len(joined), len(plain))
+		}
+	}
+}
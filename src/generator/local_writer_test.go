@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalFileNamedOnClose(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "part00000", "t.0.csv")
+	w, err := createLocalFile(path, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(ctx, []byte("1,a\n2,b\n")); err != nil {
+		t.Fatal(err)
+	}
+	// A killed run leaves only the temporary file, which resume ignores.
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("%s exists before Close: %v", path, err)
+	}
+	if err := w.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "1,a\n2,b\n" {
+		t.Errorf("file holds %q", data)
+	}
+	if _, err := os.Stat(w.tmp); !os.IsNotExist(err) {
+		t.Errorf("temporary file %s is left: %v", w.tmp, err)
+	}
+
+	w.remove()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s exists after remove: %v", path, err)
+	}
+}
@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"sync"
+
+	"github.com/pingcap/errors"
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ManifestFile is a file in the manifest sidecar. CRC32C is the hex CRC-32C
+// of the file's bytes as written, after compression.
+type ManifestFile struct {
+	Size   int64  `json:"size"`
+	CRC32C string `json:"crc32c"`
+	Rows   int    `json:"rows"`
+}
+
+// ManifestSidecar is written as <prefix>_manifest.json when common.manifest
+// is set. Files are keyed by their name relative to common.path.
+type ManifestSidecar struct {
+	Files map[string]ManifestFile `json:"files"`
+}
+
+// manifest collects the files written in this run. A nil *manifest records
+// nothing.
+type manifest struct {
+	mu    sync.Mutex
+	files map[string]ManifestFile
+}
+
+func newManifest() *manifest {
+	return &manifest{files: make(map[string]ManifestFile)}
+}
+
+func (m *manifest) record(name string, file ManifestFile) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[name] = file
+}
+
+// remove forgets a file that failed after it was closed, before a retry.
+func (m *manifest) remove(name string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, name)
+}
+
+func (o *Orchestrator) manifestSidecarName() string {
+	return fmt.Sprintf("%s_manifest.json", o.cfg.Common.Prefix)
+}
+
+// writeManifestSidecar writes the size, checksum and rows of every file
+// written in this run through the same storage as the data files.
+func (o *Orchestrator) writeManifestSidecar(ctx context.Context) error {
+	data, err := json.MarshalIndent(ManifestSidecar{Files: o.manifest.files}, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	name := o.manifestSidecarName()
+	if err := o.store.WriteFile(ctx, name, append(data, '\n')); err != nil {
+		return errors.Annotatef(err, "failed to write manifest sidecar %s", name)
+	}
+	return nil
+}
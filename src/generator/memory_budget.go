@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"context"
+
+	"dataWriter/src/util"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// memoryBudget bounds the bytes of streamed chunks that are generated but not
+// yet written, across all files of a run, for common.max_memory.
+type memoryBudget struct {
+	sem    *semaphore.Weighted
+	limit  int64
+	logger *util.ProgressLogger
+}
+
+// newMemoryBudget returns a budget of limit bytes, or nil without a limit.
+func newMemoryBudget(limit int64, logger *util.ProgressLogger) *memoryBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &memoryBudget{sem: semaphore.NewWeighted(limit), limit: limit, logger: logger}
+}
+
+// weight returns the budget a chunk takes. A chunk larger than the whole
+// budget takes all of it, so it waits for the others instead of forever.
+func (b *memoryBudget) weight(chunk *util.FileChunk) int64 {
+	return min(int64(len(chunk.Data)), b.limit)
+}
+
+// acquire blocks until chunk fits the budget.
+func (b *memoryBudget) acquire(ctx context.Context, chunk *util.FileChunk) error {
+	if err := b.sem.Acquire(ctx, b.weight(chunk)); err != nil {
+		return err
+	}
+	b.logger.UpdateInFlight(int64(len(chunk.Data)))
+	return nil
+}
+
+// release returns the budget of chunk once it is written or dropped.
+func (b *memoryBudget) release(chunk *util.FileChunk) {
+	if b == nil {
+		return
+	}
+	b.sem.Release(b.weight(chunk))
+	b.logger.UpdateInFlight(-int64(len(chunk.Data)))
+}
+
+// relay passes the chunks of in to out, each once it fits the budget, so a
+// generator blocks before producing its next chunk while the budget is full.
+// out is closed when in is.
+func (b *memoryBudget) relay(ctx context.Context, in <-chan *util.FileChunk, out chan<- *util.FileChunk) error {
+	defer close(out)
+	for chunk := range in {
+		if err := b.acquire(ctx, chunk); err != nil {
+			return err
+		}
+		select {
+		case out <- chunk:
+		case <-ctx.Done():
+			b.release(chunk)
+			return ctx.Err()
+		}
+	}
+	return nil
+}
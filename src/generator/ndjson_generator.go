@@ -0,0 +1,206 @@
+package generator
+
+import (
+	"context"
+	"encoding/base64"
+	"math/rand"
+	"unicode/utf8"
+
+	"dataWriter/src/config"
+	"dataWriter/src/spec"
+	"dataWriter/src/util"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/tidb/br/pkg/storage"
+)
+
+// ndjsonKind decides how a column's values are written in JSON.
+type ndjsonKind int
+
+const (
+	ndjsonString ndjsonKind = iota
+	// ndjsonNumber values are written unquoted.
+	ndjsonNumber
+	// ndjsonRaw values are JSON documents and written as is.
+	ndjsonRaw
+	// ndjsonBase64 values are arbitrary bytes, written as base64 strings.
+	ndjsonBase64
+)
+
+func ndjsonKindOf(c *spec.ColumnSpec) ndjsonKind {
+	switch {
+	case c.SQLType == "json":
+		return ndjsonRaw
+	case c.IsBinary():
+		return ndjsonBase64
+	case c.IsNumeric() && c.NumberFormat == spec.NumberFormatNone:
+		return ndjsonNumber
+	default:
+		// Formatted numbers like 1,234.5 are not JSON numbers.
+		return ndjsonString
+	}
+}
+
+// NDJSONGenerator implements FileGenerator for newline-delimited JSON files,
+// one object per row keyed by column name.
+type NDJSONGenerator struct {
+	cfg             *config.Config
+	specs           []*spec.ColumnSpec
+	chunkCalculator util.ChunkCalculator
+	// keys are the quoted column names followed by ':'.
+	keys    [][]byte
+	kinds   []ndjsonKind
+	timings *columnTimings
+}
+
+func newNDJSONGenerator(
+	cfg *config.Config,
+	specs []*spec.ColumnSpec,
+	timings *columnTimings,
+) *NDJSONGenerator {
+	keys := make([][]byte, len(specs))
+	kinds := make([]ndjsonKind, len(specs))
+	for i, s := range specs {
+		keys[i] = append(appendJSONString(nil, s.OrigName), ':')
+		kinds[i] = ndjsonKindOf(s)
+	}
+	return &NDJSONGenerator{
+		cfg:             cfg,
+		specs:           specs,
+		chunkCalculator: util.NewChunkSizeCalculator(cfg),
+		keys:            keys,
+		kinds:           kinds,
+		timings:         timings,
+	}
+}
+
+func (g *NDJSONGenerator) FileSuffix() string {
+	return g.cfg.NDJSON.FileSuffix()
+}
+
+func (g *NDJSONGenerator) generateRow(rowID int, rng *rand.Rand, buf []byte) []byte {
+	buf = append(buf, '{')
+	for i, columnSpec := range g.specs {
+		start := g.timings.start()
+		s := spec.GenerateSingleField(rowID, columnSpec, rng)
+		g.timings.add(i, start)
+		buf = g.appendField(buf, i, s)
+	}
+	return append(buf, '}', '\n')
+}
+
+// appendField appends the generated value s of the i-th column as a key and
+// value of the row's object.
+func (g *NDJSONGenerator) appendField(buf []byte, i int, s string) []byte {
+	if i > 0 {
+		buf = append(buf, ',')
+	}
+	buf = append(buf, g.keys[i]...)
+	if s == spec.NullValue {
+		return append(buf, "null"...)
+	}
+	switch g.kinds[i] {
+	case ndjsonNumber, ndjsonRaw:
+		return append(buf, s...)
+	case ndjsonBase64:
+		buf = append(buf, '"')
+		buf = base64.StdEncoding.AppendEncode(buf, string2Bytes(s))
+		return append(buf, '"')
+	default:
+		return appendJSONString(buf, s)
+	}
+}
+
+func (g *NDJSONGenerator) GenerateFile(
+	ctx context.Context,
+	writer storage.ExternalFileWriter,
+	fileNo int,
+) error {
+	var (
+		rng        = newFileRand(g.cfg, fileNo)
+		buffer     = make([]byte, 0, 64*units.KiB)
+		startRowID = fileStartRow(g.cfg, fileNo)
+	)
+
+	for i := range g.cfg.Common.RowsForFile(fileNo) {
+		buffer = g.generateRow(startRowID+i, rng, buffer[:0])
+		if _, err := writer.Write(ctx, buffer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *NDJSONGenerator) GenerateFileStreaming(
+	ctx context.Context,
+	fileNo int,
+	chunkChannel chan<- *util.FileChunk,
+) error {
+	var (
+		rng = newFileRand(g.cfg, fileNo)
+
+		startRowID = fileStartRow(g.cfg, fileNo)
+		totalRows  = g.cfg.Common.RowsForFile(fileNo)
+
+		rowSize    = g.chunkCalculator.EstimateRowSize(g.specs)
+		chunkRows  = g.chunkCalculator.CalculateChunkSize(g.specs)
+		bufferSize = rowSize * chunkRows * 3 / 2
+	)
+
+	for rowOffset := 0; rowOffset < totalRows; rowOffset += chunkRows {
+		buffer := make([]byte, 0, bufferSize)
+		rowsInChunk := min(chunkRows, totalRows-rowOffset)
+		for i := range rowsInChunk {
+			buffer = g.generateRow(startRowID+rowOffset+i, rng, buffer)
+		}
+
+		select {
+		case chunkChannel <- &util.FileChunk{
+			Data:   buffer,
+			IsLast: rowOffset+chunkRows >= totalRows,
+		}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a quoted JSON string. Invalid UTF-8 is
+// replaced with U+FFFD.
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				buf = append(buf, '\\', c)
+			case c == '\n':
+				buf = append(buf, '\\', 'n')
+			case c == '\r':
+				buf = append(buf, '\\', 'r')
+			case c == '\t':
+				buf = append(buf, '\\', 't')
+			case c < 0x20:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			default:
+				buf = append(buf, c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, "\ufffd"...)
+		} else {
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(buf, '"')
+}
@@ -5,9 +5,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"math/rand"
 	"strings"
-	"time"
 
 	"dataWriter/src/config"
 	"dataWriter/src/spec"
@@ -16,6 +16,7 @@ import (
 	"github.com/apache/arrow-go/v18/parquet"
 	"github.com/apache/arrow-go/v18/parquet/compress"
 	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/metadata"
 	"github.com/apache/arrow-go/v18/parquet/schema"
 	"github.com/apache/arrow/go/arrow/memory"
 	"github.com/pingcap/errors"
@@ -26,6 +27,9 @@ const BatchSize = 50
 
 type writeWrapper struct {
 	Writer storage.ExternalFileWriter
+
+	// written is the number of bytes flushed by the parquet writer so far.
+	written int64
 }
 
 func (ww *writeWrapper) Seek(offset int64, pos int) (int64, error) {
@@ -37,7 +41,9 @@ func (ww *writeWrapper) Read(b []byte) (int, error) {
 }
 
 func (ww *writeWrapper) Write(b []byte) (int, error) {
-	return ww.Writer.Write(context.Background(), b)
+	n, err := ww.Writer.Write(context.Background(), b)
+	ww.written += int64(n)
+	return n, err
 }
 
 func (ww *writeWrapper) Close() error {
@@ -50,11 +56,20 @@ type ParquetWriter struct {
 	valueBufs []any
 	specs     []*spec.ColumnSpec
 
-	rng *rand.Rand
+	rng  *rand.Rand
+	seed int64
 
 	numCols         int
 	numRowGroups    int
 	rowsPerRowGroup int
+	// rowGroupRows overrides the even split with explicit row group sizes.
+	rowGroupRows []int
+	// writtenRows is the number of rows of each row group written so far.
+	writtenRows []int
+	// uniform lists the row groups written all NULL or constant.
+	uniform *uniformRowGroups
+
+	timings *columnTimings
 
 	buffer *memory.Buffer
 }
@@ -66,15 +81,16 @@ func (pw *ParquetWriter) getWriter(w io.Writer, dataPageSize int64, compression
 		parquet.WithDataPageVersion(parquet.DataPageV2),
 		parquet.WithVersion(parquet.V2_LATEST),
 	}
+	if limit := dictPageSizeLimit(pw.specs); limit > parquet.DefaultDictionaryPageSizeLimit {
+		opts = append(opts, parquet.WithDictionaryPageSizeLimit(limit))
+	}
 	for i, columnSpec := range pw.specs {
 		colName := columnSpec.OrigName
-		fields[i], _ = schema.NewPrimitiveNodeConverted(
-			colName,
-			parquet.Repetitions.Optional,
-			columnSpec.Type, columnSpec.Converted,
-			columnSpec.TypeLen, columnSpec.Precision, columnSpec.Scale,
-			-1,
-		)
+		node, err := columnSpec.ParquetNode()
+		if err != nil {
+			return nil, errors.Annotatef(err, "column %s", colName)
+		}
+		fields[i] = node
 		encoding, useDict := chooseParquetEncoding(columnSpec)
 		opts = append(opts, parquet.WithDictionaryFor(colName, useDict))
 		if !useDict {
@@ -88,10 +104,62 @@ func (pw *ParquetWriter) getWriter(w io.Writer, dataPageSize int64, compression
 		return nil, errors.Trace(err)
 	}
 
-	return file.NewParquetWriter(w, node, file.WithWriterProps(parquet.NewWriterProperties(opts...))), nil
+	writeOpts := []file.WriteOption{file.WithWriterProps(parquet.NewWriterProperties(opts...))}
+	if meta := fkRangeMetadata(pw.specs); meta.Len() > 0 {
+		writeOpts = append(writeOpts, file.WithWriteMetadata(meta))
+	}
+	return file.NewParquetWriter(w, node, writeOpts...), nil
+}
+
+// fkRangeMetadata records the fk_range of each column in the file metadata,
+// so readers can tell the column references a parent table's key space.
+func fkRangeMetadata(specs []*spec.ColumnSpec) metadata.KeyValueMetadata {
+	meta := metadata.NewKeyValueMetadata()
+	for _, s := range specs {
+		if s.IsForeignKey {
+			_ = meta.Append(spec.FKMetadataPrefix+s.OrigName, s.FKRangeString())
+		}
+	}
+	return meta
+}
+
+// minRowGroupRows returns the rows of the smallest row group of a Parquet
+// run whose row groups split the files evenly, or 0 if they are sized
+// otherwise or the format is not Parquet.
+func minRowGroupRows(cfg *config.Config) int {
+	if strings.ToLower(cfg.Common.FileFormat) != "parquet" || cfg.Parquet.RowGroupSizeBytes > 0 ||
+		cfg.Parquet.MaxColumnChunkBytes > 0 || len(cfg.Parquet.RowGroupRows) > 0 || cfg.Parquet.NumRowGroups <= 0 {
+		return 0
+	}
+	rows := min(cfg.Common.Rows, cfg.Common.RowsForFile(cfg.Common.EndFileNo-1))
+	if cfg.Parquet.SingleFile {
+		// Every file number is one row group.
+		return rows
+	}
+	return rows / cfg.Parquet.NumRowGroups
+}
+
+// dictPageSizeLimit returns the dictionary page size that holds the pools of
+// all dict_cardinality columns, so the writer never falls back to plain
+// encoding for them.
+func dictPageSizeLimit(specs []*spec.ColumnSpec) int64 {
+	var limit int64
+	for _, s := range specs {
+		if s.DictCardinality > 0 {
+			limit = max(limit, int64(s.DictCardinality)*int64(s.MaxPlainBytes()))
+		}
+	}
+	return limit
 }
 
 func chooseParquetEncoding(columnSpec *spec.ColumnSpec) (parquet.Encoding, bool) {
+	if encoding, useDict, ok := columnSpec.ParquetEncoding(); ok {
+		return encoding, useDict
+	}
+	if columnSpec.DictCardinality > 0 {
+		return parquet.Encodings.Plain, true
+	}
+
 	hasExplicitSet := len(columnSpec.ValueSet) > 0 || len(columnSpec.IntSet) > 0
 	if hasExplicitSet && !columnSpec.IsUnique {
 		return parquet.Encodings.Plain, true
@@ -99,7 +167,8 @@ func chooseParquetEncoding(columnSpec *spec.ColumnSpec) (parquet.Encoding, bool)
 
 	switch columnSpec.Type {
 	case parquet.Types.Int32, parquet.Types.Int64:
-		if columnSpec.Order == spec.NumericTotalOrder || columnSpec.Order == spec.NumericPartialOrder {
+		switch columnSpec.Order {
+		case spec.NumericTotalOrder, spec.NumericPartialOrder, spec.SequenceOrder:
 			return parquet.Encodings.DeltaBinaryPacked, false
 		}
 		return parquet.Encodings.Plain, false
@@ -117,37 +186,14 @@ func chooseParquetEncoding(columnSpec *spec.ColumnSpec) (parquet.Encoding, bool)
 	}
 }
 
-func getParquetCompressionCodec(name string) (compress.Compression, error) {
-	switch strings.ToLower(strings.TrimSpace(name)) {
-	case "snappy":
-		return compress.Codecs.Snappy, nil
-	case "zstd":
-		return compress.Codecs.Zstd, nil
-	case "gzip":
-		return compress.Codecs.Gzip, nil
-	case "brotli":
-		return compress.Codecs.Brotli, nil
-	case "lz4_raw", "lz4":
-		return compress.Codecs.Lz4Raw, nil
-	case "uncompressed", "none":
-		return compress.Codecs.Uncompressed, nil
-	default:
-		return compress.Codecs.Uncompressed, fmt.Errorf("unsupported parquet compression: %q", name)
-	}
-}
-
-func (pw *ParquetWriter) Init(w io.Writer, rows, rowGroups int, dataPageSize int64, specs []*spec.ColumnSpec, compression compress.Compression) error {
-	source := rand.NewSource(time.Now().UnixNano() + int64(rand.Intn(65536)))
-	pw.rng = rand.New(source)
+func (pw *ParquetWriter) Init(w io.Writer, rows, rowGroups int, dataPageSize int64, specs []*spec.ColumnSpec, compression compress.Compression, seed int64) error {
+	pw.rng = rand.New(rand.NewSource(seed))
+	pw.seed = seed
 
 	pw.numCols = len(specs)
 	pw.numRowGroups = rowGroups
 	pw.rowsPerRowGroup = rows / rowGroups
 
-	if pw.rowsPerRowGroup%BatchSize != 0 {
-		panic("rowsPerRowGroup must be divisible by BatchSize")
-	}
-
 	var err error
 
 	pw.specs = specs
@@ -161,32 +207,118 @@ func (pw *ParquetWriter) Init(w io.Writer, rows, rowGroups int, dataPageSize int
 
 	for i := range len(specs) {
 		pw.defLevels[i] = make([]int16, BatchSize)
-		switch specs[i].Type {
-		case parquet.Types.Int32:
-			pw.valueBufs[i] = make([]int32, BatchSize)
-		case parquet.Types.Int64:
-			pw.valueBufs[i] = make([]int64, BatchSize)
-		case parquet.Types.FixedLenByteArray:
-			pw.valueBufs[i] = make([]parquet.FixedLenByteArray, BatchSize)
-		case parquet.Types.Double:
-			pw.valueBufs[i] = make([]float64, BatchSize)
-		case parquet.Types.Float:
-			pw.valueBufs[i] = make([]float32, BatchSize)
-		case parquet.Types.ByteArray:
-			pw.valueBufs[i] = make([]parquet.ByteArray, BatchSize)
-		default:
-			panic("unimplemented")
-		}
+		pw.valueBufs[i] = newValueBuffer(specs[i].Type, BatchSize)
 	}
 
 	return nil
 }
 
-func (pw *ParquetWriter) Close() {
-	pw.w.Close()
+// newValueBuffer allocates a value buffer matching the parquet physical type.
+func newValueBuffer(typ parquet.Type, size int) any {
+	switch typ {
+	case parquet.Types.Int32:
+		return make([]int32, size)
+	case parquet.Types.Int64:
+		return make([]int64, size)
+	case parquet.Types.Int96:
+		return make([]parquet.Int96, size)
+	case parquet.Types.FixedLenByteArray:
+		return make([]parquet.FixedLenByteArray, size)
+	case parquet.Types.Double:
+		return make([]float64, size)
+	case parquet.Types.Float:
+		return make([]float32, size)
+	case parquet.Types.ByteArray:
+		return make([]parquet.ByteArray, size)
+	default:
+		panic("unimplemented")
+	}
+}
+
+// sliceValueBuffer returns the first n values of a buffer from newValueBuffer.
+func sliceValueBuffer(buf any, n int) any {
+	switch b := buf.(type) {
+	case []int32:
+		return b[:n]
+	case []int64:
+		return b[:n]
+	case []parquet.Int96:
+		return b[:n]
+	case []parquet.FixedLenByteArray:
+		return b[:n]
+	case []float64:
+		return b[:n]
+	case []float32:
+		return b[:n]
+	case []parquet.ByteArray:
+		return b[:n]
+	default:
+		panic("unimplemented")
+	}
+}
+
+// setValue stores v, a value from spec.ParseParquetValue, at index i of a
+// buffer from newValueBuffer.
+func setValue(buf any, i int, v any) {
+	switch b := buf.(type) {
+	case []int32:
+		b[i] = v.(int32)
+	case []int64:
+		b[i] = v.(int64)
+	case []parquet.Int96:
+		b[i] = v.(parquet.Int96)
+	case []parquet.FixedLenByteArray:
+		b[i] = v.(parquet.FixedLenByteArray)
+	case []float64:
+		b[i] = v.(float64)
+	case []float32:
+		b[i] = v.(float32)
+	case []parquet.ByteArray:
+		b[i] = v.(parquet.ByteArray)
+	default:
+		panic("unimplemented")
+	}
+}
+
+// writeColumnBatch writes one batch of values to the column chunk writer.
+func writeColumnBatch(cw file.ColumnChunkWriter, typ parquet.Type, valueBuffer any, defLevels []int16) (int64, error) {
+	if cw.Descr().MaxDefinitionLevel() == 0 {
+		// Required columns have no definition levels.
+		defLevels = nil
+	}
+	switch typ {
+	case parquet.Types.Int32:
+		w, _ := cw.(*file.Int32ColumnChunkWriter)
+		return w.WriteBatch(valueBuffer.([]int32), defLevels, nil)
+	case parquet.Types.Int64:
+		w, _ := cw.(*file.Int64ColumnChunkWriter)
+		return w.WriteBatch(valueBuffer.([]int64), defLevels, nil)
+	case parquet.Types.Int96:
+		w, _ := cw.(*file.Int96ColumnChunkWriter)
+		return w.WriteBatch(valueBuffer.([]parquet.Int96), defLevels, nil)
+	case parquet.Types.FixedLenByteArray:
+		w, _ := cw.(*file.FixedLenByteArrayColumnChunkWriter)
+		return w.WriteBatch(valueBuffer.([]parquet.FixedLenByteArray), defLevels, nil)
+	case parquet.Types.Double:
+		w, _ := cw.(*file.Float64ColumnChunkWriter)
+		return w.WriteBatch(valueBuffer.([]float64), defLevels, nil)
+	case parquet.Types.Float:
+		w, _ := cw.(*file.Float32ColumnChunkWriter)
+		return w.WriteBatch(valueBuffer.([]float32), defLevels, nil)
+	case parquet.Types.ByteArray:
+		w, _ := cw.(*file.ByteArrayColumnChunkWriter)
+		return w.WriteBatch(valueBuffer.([]parquet.ByteArray), defLevels, nil)
+	default:
+		return 0, errors.Errorf("unsupported parquet writer type: %v", typ)
+	}
+}
+
+// Close writes the footer of the file.
+func (pw *ParquetWriter) Close() error {
+	return pw.w.Close()
 }
 
-func (pw *ParquetWriter) writeNextColumn(rgw file.SerialRowGroupWriter, rowIDStart, currCol int) (int64, error) {
+func (pw *ParquetWriter) writeNextColumn(rgw file.SerialRowGroupWriter, rowIDStart, rows, currCol int, mode string) (int64, error) {
 	cw, err := rgw.NextColumn()
 	if err != nil {
 		return 0, err
@@ -196,49 +328,34 @@ func (pw *ParquetWriter) writeNextColumn(rgw file.SerialRowGroupWriter, rowIDSta
 	columnSpec := pw.specs[currCol]
 	defLevels := pw.defLevels[currCol]
 	valueBuffer := pw.valueBufs[currCol]
-	rounds := pw.rowsPerRowGroup / len(defLevels)
+	uniform := &uniformColumn{spec: columnSpec, mode: mode}
 
 	var (
 		written int64
 		num     int64
 	)
 
-	for range rounds {
-		if err = columnSpec.FillParquetBatch(rowIDStart, valueBuffer, defLevels, pw.rng); err != nil {
+	for rows > 0 {
+		// Only the last row group of row_group_bytes or a row group from a
+		// reference layout can end with a partial batch.
+		n := min(rows, len(defLevels))
+		batchValues, batchDefLevels := valueBuffer, defLevels
+		if n < len(defLevels) {
+			batchValues, batchDefLevels = sliceValueBuffer(valueBuffer, n), defLevels[:n]
+		}
+		start := pw.timings.start()
+		if err = columnSpec.FillParquetBatch(rowIDStart, batchValues, batchDefLevels, pw.rng); err != nil {
 			return written, err
 		}
-
-		switch columnSpec.Type {
-		case parquet.Types.Int32:
-			buf := valueBuffer.([]int32)
-			w, _ := cw.(*file.Int32ColumnChunkWriter)
-			num, err = w.WriteBatch(buf, defLevels, nil)
-		case parquet.Types.Int64:
-			buf := valueBuffer.([]int64)
-			w, _ := cw.(*file.Int64ColumnChunkWriter)
-			num, err = w.WriteBatch(buf, defLevels, nil)
-		case parquet.Types.FixedLenByteArray:
-			buf := valueBuffer.([]parquet.FixedLenByteArray)
-			w, _ := cw.(*file.FixedLenByteArrayColumnChunkWriter)
-			num, err = w.WriteBatch(buf, defLevels, nil)
-		case parquet.Types.Double:
-			buf := valueBuffer.([]float64)
-			w, _ := cw.(*file.Float64ColumnChunkWriter)
-			num, err = w.WriteBatch(buf, defLevels, nil)
-		case parquet.Types.Float:
-			buf := valueBuffer.([]float32)
-			w, _ := cw.(*file.Float32ColumnChunkWriter)
-			num, err = w.WriteBatch(buf, defLevels, nil)
-		case parquet.Types.ByteArray:
-			buf := valueBuffer.([]parquet.ByteArray)
-			w, _ := cw.(*file.ByteArrayColumnChunkWriter)
-			num, err = w.WriteBatch(buf, defLevels, nil)
-		default:
-			return 0, errors.Errorf("unsupported parquet writer type: %v", columnSpec.Type)
+		if err = uniform.apply(rowIDStart, batchValues, batchDefLevels, pw.rng); err != nil {
+			return written, err
 		}
+		pw.timings.add(currCol, start)
 
+		num, err = writeColumnBatch(cw, columnSpec.Type, batchValues, batchDefLevels)
 		written += num
-		rowIDStart += len(defLevels)
+		rowIDStart += n
+		rows -= n
 		if err != nil {
 			return written, err
 		}
@@ -247,33 +364,167 @@ func (pw *ParquetWriter) writeNextColumn(rgw file.SerialRowGroupWriter, rowIDSta
 	return written, err
 }
 
+func (pw *ParquetWriter) writeRowGroup(startRowID, rows int) error {
+	rgw := pw.w.AppendRowGroup()
+	mode := pw.uniform.modeOf(len(pw.writtenRows))
+	for col := range pw.numCols {
+		if _, err := pw.writeNextColumn(rgw, startRowID, rows, col, mode); err != nil {
+			return err
+		}
+	}
+	pw.writtenRows = append(pw.writtenRows, rows)
+	return rgw.Close()
+}
+
+// rowGroupSizes returns the number of rows of each row group.
+func (pw *ParquetWriter) rowGroupSizes() []int {
+	if len(pw.rowGroupRows) > 0 {
+		return pw.rowGroupRows
+	}
+	sizes := make([]int, pw.numRowGroups)
+	for i := range sizes {
+		sizes[i] = pw.rowsPerRowGroup
+	}
+	return sizes
+}
+
 func (pw *ParquetWriter) Write(startRowID int) error {
-	for range pw.numRowGroups {
-		rgw := pw.w.AppendRowGroup()
-		for col := range pw.numCols {
-			if _, err := pw.writeNextColumn(rgw, startRowID, col); err != nil {
+	for _, rows := range pw.rowGroupSizes() {
+		if err := pw.writeRowGroup(startRowID, rows); err != nil {
+			return err
+		}
+		startRowID += rows
+	}
+	return nil
+}
+
+// WriteParallel is like Write, but generates up to concurrency row groups at
+// once. Each row group uses its own random source derived from the seed, so
+// the data is reproducible but differs from Write.
+func (pw *ParquetWriter) WriteParallel(ctx context.Context, startRowID, concurrency int) error {
+	sizes := pw.rowGroupSizes()
+	starts := make([]int, len(sizes))
+	for i, rows := range sizes {
+		starts[i] = startRowID
+		startRowID += rows
+	}
+
+	return pipelineRowGroups(ctx, len(sizes), concurrency,
+		func(i int) (*rowGroupBuffer, error) {
+			return generateRowGroup(pw.specs, starts[i], sizes[i], pw.uniform.modeOf(i), rowGroupRand(pw.seed, i), pw.timings)
+		},
+		func(_ int, rg *rowGroupBuffer) error {
+			return pw.writeRowGroupBuffer(rg)
+		},
+	)
+}
+
+// rowGroupBuffer holds the generated batches of one row group, so data can be
+// generated in parallel and appended to a shared writer later.
+type rowGroupBuffer struct {
+	rows      int
+	values    [][]any
+	defLevels [][][]int16
+}
+
+// generateRowGroup fills a rowGroupBuffer with rows starting at startRowID.
+// The last batch is partial if rows is not a multiple of BatchSize. mode
+// makes the row group uniform, see uniformRowGroups.
+func generateRowGroup(specs []*spec.ColumnSpec, startRowID, rows int, mode string, rng *rand.Rand, timings *columnTimings) (*rowGroupBuffer, error) {
+	rounds := (rows + BatchSize - 1) / BatchSize
+	rg := &rowGroupBuffer{
+		rows:      rows,
+		values:    make([][]any, len(specs)),
+		defLevels: make([][][]int16, len(specs)),
+	}
+	for col, columnSpec := range specs {
+		rg.values[col] = make([]any, rounds)
+		rg.defLevels[col] = make([][]int16, rounds)
+		rowID := startRowID
+		uniform := &uniformColumn{spec: columnSpec, mode: mode}
+		for i := range rounds {
+			n := min(BatchSize, rows-i*BatchSize)
+			values := newValueBuffer(columnSpec.Type, n)
+			defLevels := make([]int16, n)
+			start := timings.start()
+			if err := columnSpec.FillParquetBatch(rowID, values, defLevels, rng); err != nil {
+				return nil, err
+			}
+			if err := uniform.apply(rowID, values, defLevels, rng); err != nil {
+				return nil, err
+			}
+			timings.add(col, start)
+			rg.values[col][i] = values
+			rg.defLevels[col][i] = defLevels
+			rowID += n
+		}
+	}
+	return rg, nil
+}
+
+// writeRowGroupBuffer appends a pre-generated row group to the file.
+func (pw *ParquetWriter) writeRowGroupBuffer(rg *rowGroupBuffer) error {
+	rgw := pw.w.AppendRowGroup()
+	for col, columnSpec := range pw.specs {
+		cw, err := rgw.NextColumn()
+		if err != nil {
+			return err
+		}
+		for i, values := range rg.values[col] {
+			if _, err := writeColumnBatch(cw, columnSpec.Type, values, rg.defLevels[col][i]); err != nil {
+				cw.Close()
 				return err
 			}
 		}
+		if err := cw.Close(); err != nil {
+			return err
+		}
+	}
+	pw.writtenRows = append(pw.writtenRows, rg.rows)
+	return rgw.Close()
+}
+
+// WriteUntilSize appends row groups until the bytes flushed to sink reach
+// targetBytes. The last row group and the footer, written on Close, go past
+// the target, so files end up slightly larger than it. Rows past the
+// file's common.rows take the row IDs of the next file, which ValidateOutput
+// keeps away from the columns that need unique IDs.
+func (pw *ParquetWriter) WriteUntilSize(startRowID int, targetBytes int64, sink *writeWrapper) error {
+	for sink.written < targetBytes {
+		before := sink.written
+		if err := pw.writeRowGroup(startRowID, pw.rowsPerRowGroup); err != nil {
+			return err
+		}
+		if sink.written == before {
+			return errors.New("row group flushed no data, cannot reach target_compressed_size")
+		}
 		startRowID += pw.rowsPerRowGroup
-		rgw.Close()
 	}
 	return nil
 }
 
 // ParquetGenerator implements FormatGenerator for Parquet files.
 type ParquetGenerator struct {
-	cfg   *config.Config
-	specs []*spec.ColumnSpec
+	cfg     *config.Config
+	specs   []*spec.ColumnSpec
+	timings *columnTimings
+	index   *rowIndex
 }
 
 func newParquetGenerator(
 	cfg *config.Config,
 	specs []*spec.ColumnSpec,
+	timings *columnTimings,
+	index *rowIndex,
 ) (*ParquetGenerator, error) {
+	if err := checkUniformRowGroups(cfg, specs); err != nil {
+		return nil, err
+	}
 	return &ParquetGenerator{
-		cfg:   cfg,
-		specs: specs,
+		cfg:     cfg,
+		specs:   specs,
+		timings: timings,
+		index:   index,
 	}, nil
 }
 
@@ -287,7 +538,7 @@ func (g *ParquetGenerator) GenerateFile(
 	fileNo int,
 ) error {
 	wrapper := &writeWrapper{Writer: writer}
-	return generateParquetCommon(wrapper, fileNo, g.specs, g.cfg)
+	return generateParquetCommon(ctx, wrapper, fileNo, g.specs, g.cfg, g.timings, g.index)
 }
 
 func (g *ParquetGenerator) GenerateFileStreaming(
@@ -309,37 +560,115 @@ func (g *ParquetGenerator) GenerateFileStreaming(
 		chunkSize:    targetChunkSize,
 		ctx:          ctx,
 	}}
-	return generateParquetCommon(wrapper, fileNo, g.specs, g.cfg)
+	return generateParquetCommon(ctx, wrapper, fileNo, g.specs, g.cfg, g.timings, g.index)
+}
+
+// rowGroupRowsForBytes returns the number of rows that make a row group of
+// about parquet.row_group_bytes, rounded to a multiple of BatchSize.
+func rowGroupRowsForBytes(cfg *config.Config, specs []*spec.ColumnSpec) int {
+	rowSize := max(util.NewChunkSizeCalculator(cfg).EstimateRowSize(specs), 1)
+	batches := math.Round(float64(cfg.Parquet.RowGroupSizeBytes) / float64(rowSize) / BatchSize)
+	return max(int(batches), 1) * BatchSize
+}
+
+// rowGroupRowsForColumnChunk returns the most rows, a multiple of BatchSize,
+// whose largest column chunk stays within max_column_chunk_bytes, or 0 if
+// even BatchSize rows don't fit.
+func rowGroupRowsForColumnChunk(cfg *config.Config, specs []*spec.ColumnSpec) int {
+	return int(cfg.Parquet.MaxColumnChunkBytes/int64(widestColumnBytes(specs))/BatchSize) * BatchSize
+}
+
+// widestColumnBytes returns the largest size of one value in any column, and
+// one byte for its definition level.
+func widestColumnBytes(specs []*spec.ColumnSpec) int {
+	widest := 1
+	for _, s := range specs {
+		widest = max(widest, s.MaxPlainBytes()+1)
+	}
+	return widest
+}
+
+// rowGroupRowsLimit returns the rows per row group set by row_group_bytes and
+// max_column_chunk_bytes, the smaller of both, or 0 if neither is set.
+// Without row_group_bytes, rows are split into row_groups groups unless those
+// exceed the column chunk limit.
+func rowGroupRowsLimit(cfg *config.Config, specs []*spec.ColumnSpec, numRows int) int {
+	rows := 0
+	if cfg.Parquet.RowGroupSizeBytes > 0 {
+		rows = rowGroupRowsForBytes(cfg, specs)
+	} else if cfg.Parquet.MaxColumnChunkBytes > 0 && cfg.Parquet.NumRowGroups > 0 {
+		rows = numRows / cfg.Parquet.NumRowGroups
+	}
+	if cfg.Parquet.MaxColumnChunkBytes > 0 {
+		rows = min(rows, rowGroupRowsForColumnChunk(cfg, specs))
+	}
+	return rows
+}
+
+// splitRows splits rows into row groups of rowsPerGroup rows, the last group
+// gets the remainder.
+func splitRows(rows, rowsPerGroup int) []int {
+	sizes := make([]int, 0, (rows+rowsPerGroup-1)/rowsPerGroup)
+	for rows > 0 {
+		n := min(rows, rowsPerGroup)
+		sizes = append(sizes, n)
+		rows -= n
+	}
+	return sizes
 }
 
 // Common parquet generation function that works with any writer
 func generateParquetCommon(
+	ctx context.Context,
 	wrapper *writeWrapper,
 	fileNo int,
 	specs []*spec.ColumnSpec,
 	cfg *config.Config,
+	timings *columnTimings,
+	index *rowIndex,
 ) error {
-	pw := ParquetWriter{}
+	pw := ParquetWriter{timings: timings}
 
-	numRows := cfg.Common.Rows
-	startRowID := numRows * fileNo
+	numRows := cfg.Common.RowsForFile(fileNo)
+	startRowID := fileStartRow(cfg, fileNo)
 	rowGroups := cfg.Parquet.NumRowGroups
-	if numRows%rowGroups != 0 {
+	rowGroupRows := cfg.Parquet.RowGroupRows
+	rowsPerGroupForBytes := rowGroupRowsLimit(cfg, specs, numRows)
+	if rowsPerGroupForBytes > 0 {
+		rowGroupRows = splitRows(numRows, rowsPerGroupForBytes)
+		rowGroups = len(rowGroupRows)
+	}
+	if len(rowGroupRows) == 0 && numRows%rowGroups != 0 {
 		return fmt.Errorf("numRows %d is not divisible by numRowGroups %d", numRows, rowGroups)
 	}
 
-	codec, err := getParquetCompressionCodec(cfg.Parquet.Compression)
+	codec, err := util.ParquetCompressionCodec(cfg.Parquet.Compression)
 	if err != nil {
 		return err
 	}
 
-	if err := pw.Init(wrapper, numRows, rowGroups, cfg.Parquet.PageSizeBytes, specs, codec); err != nil {
+	if err := pw.Init(wrapper, numRows, rowGroups, cfg.Parquet.PageSizeBytes, specs, codec, fileSeed(cfg, fileNo)); err != nil {
+		return errors.Trace(err)
+	}
+	pw.rowGroupRows = rowGroupRows
+	pw.uniform = newUniformRowGroups(cfg)
+	if rowsPerGroupForBytes > 0 {
+		pw.rowsPerRowGroup = rowsPerGroupForBytes
+	}
+	if target := cfg.Parquet.TargetCompressedSizeBytes; target > 0 {
+		err = pw.WriteUntilSize(startRowID, target, wrapper)
+	} else if concurrency := cfg.Parquet.RowGroupConcurrency; concurrency > 1 {
+		err = pw.WriteParallel(ctx, startRowID, concurrency)
+	} else {
+		err = pw.Write(startRowID)
+	}
+	if err != nil {
 		return errors.Trace(err)
 	}
-	if err := pw.Write(startRowID); err != nil {
+	if err := pw.Close(); err != nil {
 		return errors.Trace(err)
 	}
-	pw.Close()
+	index.record(fileNo, pw.writtenRows)
 	return nil
 }
 
@@ -0,0 +1,244 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/parquet/file"
+)
+
+// readInt64Column returns the non-NULL values of an INT64 column of a local
+// Parquet file.
+func readInt64Column(t *testing.T, path string, col int) []int64 {
+	t.Helper()
+	r, err := file.OpenParquetFile(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	var out []int64
+	for i := range r.NumRowGroups() {
+		cr, err := r.RowGroup(i).Column(col)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows := r.MetaData().RowGroup(i).NumRows()
+		values := make([]int64, rows)
+		defLevels := make([]int16, rows)
+		_, n, err := cr.(*file.Int64ColumnChunkReader).ReadBatch(rows, values, defLevels, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, values[:n]...)
+	}
+	return out
+}
+
+func TestParquetTimestampsTruncatedToFSP(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(t, fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 1
+rows = 5000
+format = "parquet"
+
+[parquet]
+row_groups = 1
+compression = "snappy"
+`, dir))
+	o := runTest(t, cfg, testSpecs(t, `CREATE TABLE t (
+		a datetime(3) COMMENT 'date_start=2020-01-01, date_end=2021-01-01',
+		b timestamp COMMENT 'date_start=1999-06-01T12:00:00, date_end=1999-06-02T12:00:00'
+	);`))
+	path := filepath.Join(dir, testFileName(t, o, 0))
+
+	cases := []struct {
+		col        int
+		start, end time.Time
+		unit       int64
+	}{
+		{0, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), 1000},
+		{1, time.Date(1999, 6, 1, 12, 0, 0, 0, time.UTC), time.Date(1999, 6, 2, 12, 0, 0, 0, time.UTC), 1_000_000},
+	}
+	for _, tc := range cases {
+		values := readInt64Column(t, path, tc.col)
+		if len(values) == 0 {
+			t.Fatalf("column %d has no values", tc.col)
+		}
+		for _, v := range values {
+			// The window is in UTC, so the micros read back are the wall
+			// clock of the window.
+			ts := time.UnixMicro(v).UTC()
+			if ts.Before(tc.start) || !ts.Before(tc.end) {
+				t.Fatalf("column %d: %s is outside [%s, %s)", tc.col, ts, tc.start, tc.end)
+			}
+			if v%tc.unit != 0 {
+				t.Fatalf("column %d: %d micros keeps digits beyond the fsp", tc.col, v)
+			}
+		}
+	}
+}
+
+func TestMaxColumnChunkBytes(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(t, fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 1
+rows = 20000
+format = "parquet"
+
+[parquet]
+row_groups = 1
+compression = "snappy"
+max_column_chunk_bytes = "64KiB"
+`, dir))
+	o := runTest(t, cfg, testSpecs(t, "CREATE TABLE t (id bigint, s varchar(100), c char(10));"))
+
+	r, err := file.OpenParquetFile(filepath.Join(dir, testFileName(t, o, 0)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.NumRows() != 20000 {
+		t.Errorf("file has %d rows, want 20000", r.NumRows())
+	}
+	if r.NumRowGroups() < 2 {
+		t.Fatalf("file has %d row groups, the 2MB string column needs more", r.NumRowGroups())
+	}
+	for i := range r.NumRowGroups() {
+		rg := r.MetaData().RowGroup(i)
+		for col := range rg.NumColumns() {
+			chunk, err := rg.ColumnChunk(col)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size := chunk.TotalUncompressedSize(); size > cfg.Parquet.MaxColumnChunkBytes {
+				t.Errorf("row group %d column %d has %d bytes, limit is %d", i, col, size, cfg.Parquet.MaxColumnChunkBytes)
+			}
+		}
+	}
+}
+
+func TestDictCardinalityPages(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(t, fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 1
+rows = 5000
+format = "parquet"
+
+[parquet]
+row_groups = 2
+compression = "snappy"
+`, dir))
+	o := runTest(t, cfg, testSpecs(t, `CREATE TABLE t (
+		a int NOT NULL COMMENT 'dict_cardinality=300',
+		b varchar(30) NOT NULL COMMENT 'dict_cardinality=1000',
+		c double NOT NULL COMMENT 'dict_cardinality=7'
+	);`))
+	want := []int64{300, 1000, 7}
+
+	r, err := file.OpenParquetFile(filepath.Join(dir, testFileName(t, o, 0)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for i := range r.NumRowGroups() {
+		for col, k := range want {
+			pages, err := r.RowGroup(i).GetColumnPageReader(col)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !pages.Next() {
+				t.Fatalf("row group %d column %d has no pages: %v", i, col, pages.Err())
+			}
+			dict, ok := pages.Page().(*file.DictionaryPage)
+			if !ok {
+				t.Fatalf("row group %d column %d starts with a %T, want a dictionary page", i, col, pages.Page())
+			}
+			if n := int64(dict.NumValues()); n != k {
+				t.Errorf("row group %d column %d has %d dictionary entries, want %d", i, col, n, k)
+			}
+		}
+	}
+}
+
+func TestTargetCompressedSizeIDsDoNotOverlap(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(t, fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 2
+rows = 100
+format = "parquet"
+
+[parquet]
+row_groups = 1
+compression = "snappy"
+target_compressed_size = "64KiB"
+`, dir))
+	o := runTest(t, cfg, testSpecs(t, "CREATE TABLE t (id bigint COMMENT 'order=sequence', s varchar(100));"))
+
+	seen := make(map[int64]int)
+	for fileNo := range 2 {
+		ids := readInt64Column(t, filepath.Join(dir, testFileName(t, o, fileNo)), 0)
+		if len(ids) <= 100 {
+			t.Fatalf("file %d has %d rows, want more than common.rows", fileNo, len(ids))
+		}
+		for _, id := range ids {
+			if other, ok := seen[id]; ok {
+				t.Fatalf("id %d is in files %d and %d", id, other, fileNo)
+			}
+			seen[id] = fileNo
+		}
+	}
+
+	specs := testSpecs(t, "CREATE TABLE t (id bigint COMMENT 'unique_scope=global');")
+	if _, err := NewOrchestratorFromSpecs(cfg, specs); err == nil {
+		t.Fatal("unique_scope=global was accepted with target_compressed_size")
+	}
+}
+
+func TestParquetRowGroupsOfPartialBatches(t *testing.T) {
+	for _, singleFile := range []bool{false, true} {
+		dir := t.TempDir()
+		cfg := testConfig(t, fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 2
+rows = 130
+format = "parquet"
+
+[parquet]
+row_groups = 2
+compression = "snappy"
+single_file = %v
+`, dir, singleFile))
+		o := runTest(t, cfg, testSpecs(t, "CREATE TABLE t (id bigint, s varchar(20));"))
+
+		want, files := 130, 2
+		if singleFile {
+			want, files = 260, 1
+		}
+		for fileNo := range files {
+			rows, err := countParquetRows(filepath.Join(dir, testFileName(t, o, fileNo)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rows != want {
+				t.Errorf("single_file=%v: file %d has %d rows, want %d", singleFile, fileNo, rows, want)
+			}
+		}
+	}
+}
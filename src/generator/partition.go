@@ -0,0 +1,390 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+
+	"dataWriter/src/config"
+	"dataWriter/src/spec"
+	"dataWriter/src/util"
+
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/pingcap/errors"
+)
+
+const (
+	// maxPartitionValues bounds the partitions of a file, each one is
+	// buffered in memory until the file is generated.
+	maxPartitionValues = 1024
+	// hiveDefaultPartition is the directory of NULL and empty values, as Hive
+	// names it.
+	hiveDefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+)
+
+// partitioner splits the rows of every file by the value of
+// common.partition_by and writes each part to <column>=<value>/<file name>.
+type partitioner struct {
+	cfg     *config.Config
+	specs   []*spec.ColumnSpec
+	timings *columnTimings
+	// column is the index of the partition column in specs.
+	column int
+	// rest are the specs without the partition column, the columns of the
+	// written files.
+	rest []*spec.ColumnSpec
+
+	csv    *csvRowFormat
+	ndjson *NDJSONGenerator
+}
+
+func newPartitioner(cfg *config.Config, specs []*spec.ColumnSpec, timings *columnTimings) (*partitioner, error) {
+	column := slices.IndexFunc(specs, func(s *spec.ColumnSpec) bool {
+		return strings.EqualFold(s.OrigName, cfg.Common.PartitionBy)
+	})
+	if column < 0 {
+		return nil, errors.Errorf("common.partition_by column %s is not in the schema", cfg.Common.PartitionBy)
+	}
+	c := specs[column]
+	switch n := c.DistinctValues(); {
+	case n == 0:
+		return nil, errors.Errorf("common.partition_by column %s has unbounded values, it needs a set, enum values or dict_cardinality", c.OrigName)
+	case n > maxPartitionValues:
+		return nil, errors.Errorf("common.partition_by column %s has %d distinct values, at most %d partitions are supported", c.OrigName, n, maxPartitionValues)
+	}
+	if len(specs) == 1 {
+		return nil, errors.Errorf("common.partition_by column %s is the only column, files would have no columns left", c.OrigName)
+	}
+
+	p := &partitioner{
+		cfg:     cfg,
+		specs:   specs,
+		timings: timings,
+		column:  column,
+		rest:    slices.Delete(slices.Clone(specs), column, column+1),
+	}
+	switch strings.ToLower(cfg.Common.FileFormat) {
+	case "csv":
+		gen, err := newCSVGenerator(cfg, p.rest, nil)
+		if err != nil {
+			return nil, err
+		}
+		p.csv = gen.format
+	case "ndjson":
+		p.ndjson = newNDJSONGenerator(cfg, p.rest, nil)
+	}
+	return p, nil
+}
+
+// filePartition is the encoded part of a file holding one partition value.
+type filePartition struct {
+	dir  string
+	data []byte
+	rows int
+}
+
+// generate generates the rows of a file and returns its parts ordered by
+// directory.
+func (p *partitioner) generate(fileNo int) ([]filePartition, error) {
+	var (
+		rng        = newFileRand(p.cfg, fileNo)
+		startRowID = fileStartRow(p.cfg, fileNo)
+		encoders   = make(map[string]partitionEncoder)
+		rows       = make(map[string]int)
+		fields     = make([]string, 0, len(p.rest))
+	)
+	for i := range p.cfg.Common.RowsForFile(fileNo) {
+		rowID := startRowID + i
+		var value string
+		fields = fields[:0]
+		for j, c := range p.specs {
+			start := p.timings.start()
+			if j == p.column {
+				value = spec.GenerateRawField(rowID, c, rng)
+			} else {
+				fields = append(fields, p.field(rowID, c, rng))
+			}
+			p.timings.add(j, start)
+		}
+
+		enc, ok := encoders[value]
+		if !ok {
+			if len(encoders) == maxPartitionValues {
+				return nil, errors.Errorf("common.partition_by column %s has more than %d distinct values in file %d",
+					p.specs[p.column].OrigName, maxPartitionValues, fileNo)
+			}
+			var err error
+			if enc, err = p.newEncoder(); err != nil {
+				return nil, errors.Trace(err)
+			}
+			encoders[value] = enc
+		}
+		if err := enc.writeRow(rowID, fields); err != nil {
+			return nil, errors.Annotatef(err, "row %d", rowID)
+		}
+		rows[value]++
+	}
+
+	parts := make([]filePartition, 0, len(encoders))
+	for value, enc := range encoders {
+		data, err := enc.finish()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		parts = append(parts, filePartition{dir: p.dir(value), data: data, rows: rows[value]})
+	}
+	slices.SortFunc(parts, func(a, b filePartition) int { return strings.Compare(a.dir, b.dir) })
+	return parts, nil
+}
+
+// field generates a value of a written column. Parquet takes the values
+// without number_format, as its own generator does.
+func (p *partitioner) field(rowID int, c *spec.ColumnSpec, rng *rand.Rand) string {
+	if p.csv == nil && p.ndjson == nil {
+		return spec.GenerateRawField(rowID, c, rng)
+	}
+	return spec.GenerateSingleField(rowID, c, rng)
+}
+
+// dir returns the directory of a partition value.
+func (p *partitioner) dir(value string) string {
+	if value == spec.NullValue || value == "" {
+		value = hiveDefaultPartition
+	} else {
+		value = escapePartitionValue(value)
+	}
+	return escapePartitionValue(p.specs[p.column].OrigName) + "=" + value
+}
+
+// escapePartitionValue escapes the characters Hive escapes in partition
+// directory names as %XX.
+func escapePartitionValue(s string) string {
+	var b strings.Builder
+	for i := range len(s) {
+		ch := s[i]
+		if ch < 0x20 || ch == 0x7f || strings.IndexByte(`"#%'*/:=?\{[]^`, ch) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		} else {
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// partitionEncoder encodes the rows of one partition of a file.
+type partitionEncoder interface {
+	writeRow(rowID int, fields []string) error
+	// finish returns the encoded file.
+	finish() ([]byte, error)
+}
+
+func (p *partitioner) newEncoder() (partitionEncoder, error) {
+	switch {
+	case p.csv != nil:
+		return &csvPartition{format: p.csv, specs: p.rest}, nil
+	case p.ndjson != nil:
+		return &ndjsonPartition{gen: p.ndjson}, nil
+	default:
+		codec, err := util.ParquetCompressionCodec(p.cfg.Parquet.Compression)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return &parquetPartition{
+			cfg:       p.cfg,
+			specs:     p.rest,
+			codec:     codec,
+			values:    make([][]any, len(p.rest)),
+			defLevels: make([][]int16, len(p.rest)),
+		}, nil
+	}
+}
+
+// csvPartition leaves csv.compression to the file writer, which writes the
+// partition as one gzip member.
+type csvPartition struct {
+	format *csvRowFormat
+	specs  []*spec.ColumnSpec
+	buf    []byte
+}
+
+func (e *csvPartition) writeRow(rowID int, fields []string) error {
+	for i, s := range fields {
+		if i > 0 {
+			e.buf = append(e.buf, e.format.separator...)
+		}
+		e.buf = e.format.appendField(e.buf, e.specs[i], rowID, s)
+	}
+	e.buf = append(e.buf, e.format.endline...)
+	return nil
+}
+
+func (e *csvPartition) finish() ([]byte, error) {
+	return e.buf, nil
+}
+
+// ndjsonPartition leaves ndjson.compression to the file writer, as the
+// NDJSON generator does.
+type ndjsonPartition struct {
+	gen *NDJSONGenerator
+	buf []byte
+}
+
+func (e *ndjsonPartition) writeRow(_ int, fields []string) error {
+	e.buf = append(e.buf, '{')
+	for i, s := range fields {
+		e.buf = e.gen.appendField(e.buf, i, s)
+	}
+	e.buf = append(e.buf, '}', '\n')
+	return nil
+}
+
+func (e *ndjsonPartition) finish() ([]byte, error) {
+	return e.buf, nil
+}
+
+// parquetPartition buffers the parsed values of a partition and writes them
+// with a ParquetWriter, so the file gets the encodings, page size and
+// compression of the other Parquet files. Its rows are split into row groups
+// like a file of that many rows, see partitionRowGroups.
+type parquetPartition struct {
+	cfg   *config.Config
+	specs []*spec.ColumnSpec
+	codec compress.Compression
+	// values are the non-NULL values of each column, defLevels mark the
+	// NULL rows with 0.
+	values    [][]any
+	defLevels [][]int16
+}
+
+func (e *parquetPartition) writeRow(_ int, fields []string) error {
+	for i, field := range fields {
+		if field == spec.NullValue {
+			e.defLevels[i] = append(e.defLevels[i], 0)
+			continue
+		}
+		v, err := e.specs[i].ParseParquetValue(field)
+		if err != nil {
+			return errors.Annotatef(err, "column %s", e.specs[i].OrigName)
+		}
+		e.values[i] = append(e.values[i], v)
+		e.defLevels[i] = append(e.defLevels[i], 1)
+	}
+	return nil
+}
+
+func (e *parquetPartition) finish() ([]byte, error) {
+	var (
+		buf  bytes.Buffer
+		pw   ParquetWriter
+		rows = len(e.defLevels[0])
+	)
+	if err := pw.Init(&buf, rows, 1, e.cfg.Parquet.PageSizeBytes, e.specs, e.codec, 0); err != nil {
+		return nil, errors.Trace(err)
+	}
+	// next is the first unwritten value of each column.
+	next := make([]int, len(e.specs))
+	start := 0
+	for _, n := range partitionRowGroups(e.cfg, e.specs, rows) {
+		if err := pw.writeRowGroupBuffer(e.rowGroup(start, n, next)); err != nil {
+			return nil, errors.Trace(err)
+		}
+		start += n
+	}
+	if err := pw.Close(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// rowGroup copies rows from start into batches of BatchSize rows, taking
+// the values of each column from next on.
+func (e *parquetPartition) rowGroup(start, rows int, next []int) *rowGroupBuffer {
+	rounds := (rows + BatchSize - 1) / BatchSize
+	rg := &rowGroupBuffer{
+		rows:      rows,
+		values:    make([][]any, len(e.specs)),
+		defLevels: make([][][]int16, len(e.specs)),
+	}
+	for col, c := range e.specs {
+		rg.values[col] = make([]any, rounds)
+		rg.defLevels[col] = make([][]int16, rounds)
+		for i := range rounds {
+			from := start + i*BatchSize
+			defLevels := e.defLevels[col][from : from+min(BatchSize, rows-i*BatchSize)]
+			values := newValueBuffer(c.Type, len(defLevels))
+			n := 0
+			for _, level := range defLevels {
+				if level > 0 {
+					setValue(values, n, e.values[col][next[col]])
+					n++
+					next[col]++
+				}
+			}
+			rg.values[col][i] = sliceValueBuffer(values, n)
+			rg.defLevels[col][i] = defLevels
+		}
+	}
+	return rg
+}
+
+// partitionRowGroups returns the row group sizes of a partition file of
+// rows rows: rows per row group from row_group_bytes and
+// max_column_chunk_bytes, or otherwise row_groups groups as even as possible,
+// as the rows of a partition are not a multiple of row_groups.
+func partitionRowGroups(cfg *config.Config, specs []*spec.ColumnSpec, rows int) []int {
+	perGroup := rows
+	if cfg.Parquet.NumRowGroups > 0 {
+		perGroup = (rows + cfg.Parquet.NumRowGroups - 1) / cfg.Parquet.NumRowGroups
+	}
+	if cfg.Parquet.RowGroupSizeBytes > 0 {
+		perGroup = rowGroupRowsForBytes(cfg, specs)
+	}
+	if cfg.Parquet.MaxColumnChunkBytes > 0 {
+		perGroup = min(perGroup, max(rowGroupRowsForColumnChunk(cfg, specs), BatchSize))
+	}
+	return splitRows(rows, max(perGroup, 1))
+}
+
+// generatePartitioned generates a file and writes each of its partitions.
+func (o *Orchestrator) generatePartitioned(ctx context.Context, fileNo int) error {
+	o.logger.SetFileState(fileNo, util.FileGenerating)
+	parts, err := o.partitioner.generate(fileNo)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	o.logger.SetFileState(fileNo, util.FileWriting)
+	writers := make([]*writerWithStats, 0, len(parts))
+	discard := func() {
+		for _, w := range writers {
+			w.discard()
+		}
+	}
+	name, err := o.fileName(fileNo)
+	if err != nil {
+		return err
+	}
+	for _, part := range parts {
+		writer, err := o.openNamedWriter(ctx, fileNo, part.dir+"/"+name, part.rows)
+		if err != nil {
+			discard()
+			return errors.Trace(err)
+		}
+		writers = append(writers, writer)
+		if _, err := writer.Write(ctx, part.data); err != nil {
+			writer.Close(ctx)
+			discard()
+			return errors.Trace(err)
+		}
+		if err := writer.Close(ctx); err != nil {
+			discard()
+			return errors.Trace(err)
+		}
+	}
+	o.logger.SetFileState(fileNo, util.FileDone)
+	o.logger.UpdateFiles(1)
+	return nil
+}
@@ -0,0 +1,139 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"dataWriter/src/config"
+
+	"github.com/BurntSushi/toml"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/file"
+)
+
+func TestPartitionedParquetUsesParquetOptions(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(t, fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 1
+rows = 4096
+format = "parquet"
+seed = 1
+partition_by = "region"
+
+[parquet]
+row_groups = 4
+compression = "zstd"
+page_size = "64KiB"
+`, dir))
+	specs := testSpecs(t, `CREATE TABLE t (
+		id bigint COMMENT 'encoding=delta_binary_packed',
+		region enum('us','eu','ap') NOT NULL,
+		d date,
+		amount decimal(10,2),
+		s varchar(20) COMMENT 'encoding=plain'
+	);`)
+	o := runTest(t, cfg, specs)
+
+	paths, err := filepath.Glob(filepath.Join(dir, "region=*", testFileName(t, o, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 3 {
+		t.Fatalf("found %d partition files, want 3", len(paths))
+	}
+	total := 0
+	for _, path := range paths {
+		r, err := file.OpenParquetFile(path, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += int(r.NumRows())
+		if got := r.NumRowGroups(); got != 4 {
+			t.Errorf("%s has %d row groups, want row_groups = 4", path, got)
+		}
+		for i := range r.NumRowGroups() {
+			rg := r.MetaData().RowGroup(i)
+			id, err := rg.ColumnChunk(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id.Compression() != compress.Codecs.Zstd {
+				t.Errorf("%s row group %d is compressed with %s, want zstd", path, i, id.Compression())
+			}
+			if !slices.Contains(id.Encodings(), parquet.Encodings.DeltaBinaryPacked) {
+				t.Errorf("%s row group %d encodes id with %v, want DELTA_BINARY_PACKED", path, i, id.Encodings())
+			}
+			s, err := rg.ColumnChunk(3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if slices.Contains(s.Encodings(), parquet.Encodings.RLEDict) || slices.Contains(s.Encodings(), parquet.Encodings.PlainDict) {
+				t.Errorf("%s row group %d encodes s with %v, want plain without a dictionary", path, i, s.Encodings())
+			}
+		}
+		r.Close()
+	}
+	if total != 4096 {
+		t.Errorf("partitions hold %d rows, want 4096", total)
+	}
+}
+
+func TestPartitionRowGroups(t *testing.T) {
+	cfg := testConfig(t, fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 1
+rows = 1024
+format = "parquet"
+
+[parquet]
+row_groups = 4
+`, t.TempDir()))
+	specs := testSpecs(t, "CREATE TABLE t (id bigint);")
+	cases := []struct {
+		rows int
+		want []int
+	}{
+		{400, []int{100, 100, 100, 100}},
+		{401, []int{101, 101, 101, 98}},
+		{3, []int{1, 1, 1}},
+	}
+	for _, tc := range cases {
+		if got := partitionRowGroups(cfg, specs, tc.rows); !slices.Equal(got, tc.want) {
+			t.Errorf("partitionRowGroups(%d) = %v, want %v", tc.rows, got, tc.want)
+		}
+	}
+}
+
+func TestPartitionRejectsPerFileParquetOptions(t *testing.T) {
+	var cfg config.Config
+	if _, err := toml.Decode(fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 1
+rows = 1024
+format = "parquet"
+partition_by = "region"
+
+[parquet]
+row_groups = 1
+row_group_concurrency = 4
+`, t.TempDir()), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Normalize(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Validate(&cfg); err == nil || !strings.Contains(err.Error(), "parquet.row_group_concurrency") {
+		t.Errorf("partition_by with row_group_concurrency: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/errors"
+)
+
+// runPostHook runs common.post_hook with the run summary in DATA_WRITER_*
+// environment variables. A failing or timed out hook fails the run.
+func (o *Orchestrator) runPostHook(ctx context.Context, elapsed time.Duration) error {
+	hook := o.cfg.Common.PostHook
+	if hook == nil {
+		return nil
+	}
+	summary := o.buildSummary(elapsed)
+
+	ctx, cancel := context.WithTimeout(ctx, hook.TimeoutDuration)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, hook.Command, hook.Args...)
+	cmd.Env = append(os.Environ(),
+		"DATA_WRITER_PATH="+summary.Path,
+		"DATA_WRITER_PREFIX="+o.cfg.Common.Prefix,
+		"DATA_WRITER_FORMAT="+summary.Format,
+		"DATA_WRITER_FILES="+strconv.FormatInt(summary.Files, 10),
+		"DATA_WRITER_ROWS="+strconv.FormatInt(summary.TotalRows, 10),
+		"DATA_WRITER_BYTES="+strconv.FormatInt(summary.Bytes, 10),
+		"DATA_WRITER_ELAPSED_SECONDS="+strconv.FormatFloat(summary.ElapsedSeconds, 'f', 3, 64),
+	)
+	// Keep stdout parseable when the JSON summary is written there.
+	cmd.Stdout = os.Stdout
+	if o.cfg.Common.SummaryJSON == "-" {
+		cmd.Stdout = os.Stderr
+	}
+	cmd.Stderr = os.Stderr
+
+	fmt.Fprintf(os.Stderr, "Running post_hook %s\n", hook.Command)
+	start := time.Now()
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.Errorf("post_hook %s timed out after %s", hook.Command, hook.TimeoutDuration)
+	}
+	if err != nil {
+		return errors.Annotatef(err, "post_hook %s failed", hook.Command)
+	}
+	fmt.Fprintf(os.Stderr, "post_hook took %s\n", time.Since(start).Round(time.Millisecond))
+	return nil
+}
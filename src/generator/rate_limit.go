@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket of bytes shared by the writers of all files,
+// capping the total write throughput to common.rate_limit. The bucket starts
+// empty and holds at most one second of bytes.
+type rateLimiter struct {
+	mu sync.Mutex
+	// rate is in bytes per second.
+	rate   float64
+	tokens float64
+	last   time.Time
+
+	// now and sleep are the clock, replaced in tests.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// newRateLimiter returns a limiter of bytesPerSec, or nil without a limit.
+func newRateLimiter(bytesPerSec int64) *rateLimiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	return &rateLimiter{rate: float64(bytesPerSec), last: time.Now(), now: time.Now, sleep: sleepContext}
+}
+
+// wait takes n bytes from the bucket and sleeps until they are covered. A
+// write larger than the bucket leaves it in debt, which later writes wait
+// out.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := l.now()
+	l.tokens = min(l.rate, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	return l.sleep(ctx, delay)
+}
+
+// sleepContext sleeps for d, or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock whose time only moves when a limiter sleeps.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(_ context.Context, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func newFakeLimiter(bytesPerSec int64) (*rateLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := newRateLimiter(bytesPerSec)
+	l.now, l.sleep, l.last = clock.Now, clock.Sleep, clock.now
+	return l, clock
+}
+
+func TestRateLimiterThroughput(t *testing.T) {
+	const rate = 1_000_000
+	l, clock := newFakeLimiter(rate)
+	start := clock.Now()
+	ctx := context.Background()
+
+	// 10 MB in writes of 64 KiB take 10 seconds, as the bucket starts empty.
+	written := 0
+	for written < 10*rate {
+		if err := l.wait(ctx, 64<<10); err != nil {
+			t.Fatal(err)
+		}
+		written += 64 << 10
+	}
+	elapsed := clock.Now().Sub(start)
+	if want := time.Duration(float64(written) / rate * float64(time.Second)); elapsed < want-time.Millisecond || elapsed > want+time.Millisecond {
+		t.Errorf("%d bytes took %s, want %s", written, elapsed, want)
+	}
+
+	// An idle second refills at most one second of bytes.
+	clock.Sleep(ctx, 5*time.Second)
+	before := clock.Now()
+	if err := l.wait(ctx, rate); err != nil {
+		t.Fatal(err)
+	}
+	if d := clock.Now().Sub(before); d != 0 {
+		t.Errorf("one second of bytes after an idle time waited %s", d)
+	}
+	if err := l.wait(ctx, rate/2); err != nil {
+		t.Fatal(err)
+	}
+	if d := clock.Now().Sub(before); d < 499*time.Millisecond || d > 501*time.Millisecond {
+		t.Errorf("half a second of bytes past the full bucket waited %s, want 500ms", d)
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	if l := newRateLimiter(0); l != nil {
+		t.Fatal("a zero rate_limit gives a limiter")
+	}
+	var l *rateLimiter
+	if err := l.wait(context.Background(), 1<<30); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"context"
+	goerrors "errors"
+	"math/rand"
+	"time"
+
+	"dataWriter/src/util"
+)
+
+// maxRetryBackoffFactor caps the exponential backoff at this many times
+// common.retry_backoff.
+const maxRetryBackoffFactor = 32
+
+// storageError marks an error returned by the storage layer, which may be
+// transient and worth retrying.
+type storageError struct {
+	err error
+}
+
+func (e *storageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *storageError) Unwrap() error {
+	return e.err
+}
+
+func asStorageError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &storageError{err: err}
+}
+
+func isStorageError(err error) bool {
+	var se *storageError
+	return goerrors.As(err, &se)
+}
+
+// retryBackoff returns the wait before retry attempt (from 1): base doubled
+// per attempt up to maxRetryBackoffFactor times base, with its upper half
+// jittered so failed files don't retry in lockstep.
+func retryBackoff(base time.Duration, attempt int) time.Duration {
+	d := base << min(attempt-1, 5)
+	d = min(d, base*maxRetryBackoffFactor)
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// withRetry runs generate for fileNo and, after a storage error, runs it
+// again from scratch with a new writer, up to common.max_retries times.
+func (o *Orchestrator) withRetry(ctx context.Context, fileNo int, generate func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := generate()
+		if err == nil || attempt > o.cfg.Common.MaxRetries || !isStorageError(err) {
+			return err
+		}
+		name, nameErr := o.fileName(fileNo)
+		if nameErr != nil {
+			return nameErr
+		}
+		wait := retryBackoff(o.cfg.Common.RetryBackoffDuration, attempt)
+		util.Warnf("Retrying %s in %s after attempt %d failed: %v", name, wait.Round(time.Millisecond), attempt, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/errors"
+)
+
+// rowGenerator is implemented by the generators of row-oriented text
+// formats, whose files can end after any row.
+type rowGenerator interface {
+	generateRow(rowID int, rng *rand.Rand, buf []byte) []byte
+}
+
+// runRolling writes the rows of all files as one sequence for
+// common.max_file_bytes, starting the next file number before a row that
+// would take the current file past the limit. Progress counts the rows in
+// files of common.rows. It returns the number of files written.
+func (o *Orchestrator) runRolling(ctx context.Context) (int, error) {
+	gen, ok := o.FileGenerator.(rowGenerator)
+	if !ok {
+		return 0, errors.Errorf("common.max_file_bytes is not supported for %s", o.cfg.Common.FileFormat)
+	}
+	var (
+		startNo    = o.cfg.Common.StartFileNo
+		rng        = newFileRand(o.cfg, startNo)
+		startRowID = fileStartRow(o.cfg, startNo)
+		maxBytes   = o.cfg.Common.MaxFileBytes
+		flushBytes = 64 * units.KiB
+
+		fileNo    = startNo
+		writer    *writerWithStats
+		fileStart time.Time
+		fileRows  int
+		fileBytes int64
+		buffer    []byte
+		row       []byte
+	)
+	totalRows := 0
+	for n := startNo; n < o.cfg.Common.EndFileNo; n++ {
+		totalRows += o.cfg.Common.RowsForFile(n)
+	}
+
+	flush := func() error {
+		_, err := writer.Write(ctx, buffer)
+		buffer = buffer[:0]
+		return errors.Trace(err)
+	}
+	// finish closes the current file and records it in the file log. A
+	// file that failed, with err or while flushing, is discarded as in
+	// generateStreaming.
+	finish := func(err error) error {
+		if err == nil {
+			err = flush()
+		}
+		writer.rows = fileRows
+		if closeErr := writer.Close(ctx); err == nil {
+			err = errors.Trace(closeErr)
+		}
+		if err != nil {
+			writer.discard()
+		} else {
+			o.writtenRows.Add(int64(fileRows))
+		}
+		if logErr := o.fileLog.record(fileNo, writer.name, fileRows, fileStart, err); err == nil {
+			err = logErr
+		}
+		writer = nil
+		return err
+	}
+
+	// Progress counts a file of common.rows as done once doneRows rows are
+	// written.
+	files := o.cfg.Common.EndFileNo - startNo
+	filesDone, doneRows := 0, o.cfg.Common.RowsForFile(startNo)
+	for i := range totalRows {
+		row = gen.generateRow(startRowID+i, rng, row[:0])
+		if writer != nil && fileBytes+int64(len(row)) > maxBytes {
+			if err := finish(nil); err != nil {
+				return 0, err
+			}
+			fileNo++
+		}
+		if writer == nil {
+			var err error
+			if writer, err = o.openWriter(ctx, fileNo); err != nil {
+				return 0, errors.Trace(err)
+			}
+			fileStart, fileRows, fileBytes = time.Now(), 0, 0
+		}
+
+		buffer = append(buffer, row...)
+		fileRows++
+		fileBytes += int64(len(row))
+		if len(buffer) >= flushBytes {
+			if err := flush(); err != nil {
+				return 0, finish(err)
+			}
+		}
+
+		if filesDone < files && i+1 == doneRows {
+			filesDone++
+			doneRows += o.cfg.Common.RowsForFile(startNo + filesDone)
+			o.logger.UpdateFiles(1)
+		}
+	}
+	if writer != nil {
+		if err := finish(nil); err != nil {
+			return 0, err
+		}
+	}
+	return fileNo - startNo + 1, nil
+}
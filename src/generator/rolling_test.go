@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRollingCutsAtRowBoundaries(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(t, fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 2
+rows = 500
+format = "csv"
+seed = 1
+max_file_bytes = "2KiB"
+`, dir))
+	o := runTest(t, cfg, testSpecs(t, "CREATE TABLE t (id bigint, s varchar(40));"))
+
+	maxBytes := int(cfg.Common.MaxFileBytes)
+	var files [][]byte
+	for fileNo := range o.rolledFiles {
+		data, err := os.ReadFile(filepath.Join(dir, testFileName(t, o, fileNo)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, data)
+	}
+	if len(files) < 2 {
+		t.Fatalf("wrote %d files, want the 1000 rows cut into several", len(files))
+	}
+
+	rows := 0
+	for i, data := range files {
+		if len(data) > maxBytes {
+			t.Errorf("file %d has %d bytes, limit is %d", i, len(data), maxBytes)
+		}
+		if len(data) == 0 || data[len(data)-1] != '\n' {
+			t.Fatalf("file %d does not end at a row boundary", i)
+		}
+		rows += bytes.Count(data, []byte("\n"))
+		if i+1 < len(files) {
+			// The first row of the next file didn't fit into this one.
+			next := files[i+1]
+			firstRow := next[:bytes.IndexByte(next, '\n')+1]
+			if len(data)+len(firstRow) <= maxBytes {
+				t.Errorf("file %d has %d bytes and ends before a %d byte row that fits", i, len(data), len(firstRow))
+			}
+		}
+	}
+	if rows != 1000 {
+		t.Errorf("files hold %d rows, want 1000", rows)
+	}
+	if _, err := os.Stat(filepath.Join(dir, testFileName(t, o, len(files)))); !os.IsNotExist(err) {
+		t.Errorf("file %d exists past the %d rolled files", len(files), len(files))
+	}
+}
@@ -0,0 +1,70 @@
+package generator
+
+import (
+	"context"
+	"math/rand"
+)
+
+// pipelineRowGroups generates n row groups with up to concurrency of them in
+// flight, and passes them to write in index order. Only the generation runs in
+// parallel, because a parquet file writer can only append one row group at a
+// time.
+func pipelineRowGroups(
+	ctx context.Context,
+	n, concurrency int,
+	generate func(i int) (*rowGroupBuffer, error),
+	write func(i int, rg *rowGroupBuffer) error,
+) error {
+	type result struct {
+		rg  *rowGroupBuffer
+		err error
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// sem bounds the number of generated row groups held in memory. A slot is
+	// released only after its row group has been written.
+	sem := make(chan struct{}, concurrency)
+	results := make([]chan result, n)
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+
+	go func() {
+		for i := range n {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(i int) {
+				rg, err := generate(i)
+				results[i] <- result{rg: rg, err: err}
+			}(i)
+		}
+	}()
+
+	for i := range n {
+		res := <-results[i]
+		if res.err != nil {
+			return res.err
+		}
+		if err := write(i, res.rg); err != nil {
+			return err
+		}
+		<-sem
+	}
+	return nil
+}
+
+// rowGroupRand returns the random source of the i-th row group of a file when
+// row groups are generated in parallel, derived from the file's seed.
+func rowGroupRand(seed int64, i int) *rand.Rand {
+	// splitmix64 finalizer
+	x := uint64(seed) ^ (uint64(i+1) * 0x9e3779b97f4a7c15)
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+	return rand.New(rand.NewSource(int64(x)))
+}
@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/parquet/compress"
+)
+
+// BenchmarkRowGroups writes an 8-column file of 16 row groups one row group
+// after another and with parquet.row_group_concurrency, e.g.
+//
+//	go test ./src/generator -run '^$' -bench RowGroups
+func BenchmarkRowGroups(b *testing.B) {
+	specs := testSpecs(b, `CREATE TABLE t (
+		a bigint, b int, c double, d decimal(18,4),
+		e varchar(64), f varchar(16), g datetime(6), h date
+	);`)
+	const (
+		rowGroups = 16
+		rows      = rowGroups * 8 * BatchSize
+	)
+	write := func(b *testing.B, concurrency int) {
+		for range b.N {
+			var pw ParquetWriter
+			if err := pw.Init(io.Discard, rows, rowGroups, 1<<20, specs, compress.Codecs.Snappy, 1); err != nil {
+				b.Fatal(err)
+			}
+			var err error
+			if concurrency > 1 {
+				err = pw.WriteParallel(context.Background(), 0, concurrency)
+			} else {
+				err = pw.Write(0)
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := pw.Close(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.Run("serial", func(b *testing.B) { write(b, 1) })
+	b.Run("parallel", func(b *testing.B) { write(b, runtime.GOMAXPROCS(0)) })
+}
@@ -0,0 +1,151 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"dataWriter/src/util"
+
+	"github.com/pingcap/errors"
+)
+
+// IndexRowGroup is a row group in the index sidecar. Rows of the group have
+// global row IDs [StartRow, StartRow+Rows).
+type IndexRowGroup struct {
+	StartRow int `json:"start_row"`
+	Rows     int `json:"rows"`
+}
+
+// IndexFile lists the row groups of one file in the index sidecar.
+type IndexFile struct {
+	File      string          `json:"file"`
+	StartRow  int             `json:"start_row"`
+	Rows      int             `json:"rows"`
+	RowGroups []IndexRowGroup `json:"row_groups"`
+}
+
+// IndexSidecar is written as <prefix>_index.json when parquet.emit_index is
+// set. Files are sorted by StartRow.
+type IndexSidecar struct {
+	TotalRows int         `json:"total_rows"`
+	Files     []IndexFile `json:"files"`
+}
+
+// rowIndex collects the row group sizes of the files written in this run.
+// A nil *rowIndex records nothing.
+type rowIndex struct {
+	mu        sync.Mutex
+	rowGroups map[int][]int
+}
+
+func newRowIndex() *rowIndex {
+	return &rowIndex{rowGroups: make(map[int][]int)}
+}
+
+func (idx *rowIndex) record(fileNo int, rowGroups []int) {
+	if idx == nil {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.rowGroups[fileNo] = rowGroups
+}
+
+// fileRows returns the rows recorded for a file.
+func (idx *rowIndex) fileRows(fileNo int) (int, bool) {
+	if idx == nil {
+		return 0, false
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	sizes, ok := idx.rowGroups[fileNo]
+	rows := 0
+	for _, n := range sizes {
+		rows += n
+	}
+	return rows, ok
+}
+
+func (o *Orchestrator) indexSidecarName() string {
+	return fmt.Sprintf("%s_index.json", o.cfg.Common.Prefix)
+}
+
+// plannedRowGroups returns the row group sizes a file is written with, for
+// files skipped by resume. It is unknown with target_compressed_size.
+func (o *Orchestrator) plannedRowGroups(fileNo int) ([]int, bool) {
+	if o.cfg.Parquet.TargetCompressedSizeBytes > 0 {
+		return nil, false
+	}
+	if rows := rowGroupRowsLimit(o.cfg, o.specs, o.cfg.Common.RowsForFile(fileNo)); rows > 0 {
+		return splitRows(o.cfg.Common.RowsForFile(fileNo), rows), true
+	}
+	if len(o.cfg.Parquet.RowGroupRows) > 0 {
+		return o.cfg.Parquet.RowGroupRows, true
+	}
+	n := o.cfg.Parquet.NumRowGroups
+	sizes := make([]int, n)
+	for i := range sizes {
+		sizes[i] = o.cfg.Common.RowsForFile(fileNo) / n
+	}
+	return sizes, true
+}
+
+// writeIndexSidecar writes the row group boundaries of every file through the
+// same storage as the data files.
+func (o *Orchestrator) writeIndexSidecar(ctx context.Context) error {
+	startNo := o.cfg.Common.StartFileNo
+	endNo := o.cfg.Common.EndFileNo
+
+	if o.cfg.Parquet.SingleFile {
+		if _, ok := o.index.rowGroups[startNo]; !ok {
+			// Skipped by resume, there is one row group per file number.
+			sizes := make([]int, 0, endNo-startNo)
+			for fileNo := startNo; fileNo < endNo; fileNo++ {
+				sizes = append(sizes, o.cfg.Common.RowsForFile(fileNo))
+			}
+			o.index.record(startNo, sizes)
+		}
+		endNo = startNo + 1
+	}
+
+	var sidecar IndexSidecar
+	for fileNo := startNo; fileNo < endNo; fileNo++ {
+		name, err := o.fileName(fileNo)
+		if err != nil {
+			return err
+		}
+		sizes, ok := o.index.rowGroups[fileNo]
+		if !ok {
+			if sizes, ok = o.plannedRowGroups(fileNo); !ok {
+				util.Warnf("Index sidecar skips %s, its row groups are unknown", name)
+				continue
+			}
+		}
+
+		file := IndexFile{
+			File:      name,
+			StartRow:  fileStartRow(o.cfg, fileNo),
+			RowGroups: make([]IndexRowGroup, 0, len(sizes)),
+		}
+		row := file.StartRow
+		for _, rows := range sizes {
+			file.RowGroups = append(file.RowGroups, IndexRowGroup{StartRow: row, Rows: rows})
+			row += rows
+		}
+		file.Rows = row - file.StartRow
+		sidecar.TotalRows += file.Rows
+		sidecar.Files = append(sidecar.Files, file)
+	}
+
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	name := o.indexSidecarName()
+	if err := o.store.WriteFile(ctx, name, append(data, '\n')); err != nil {
+		return errors.Annotatef(err, "failed to write index sidecar %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package generator
+
+import (
+	"context"
+	"time"
+)
+
+// waitForRunWindow blocks until common.run_window is open, showing the pause
+// in the progress box.
+func (o *Orchestrator) waitForRunWindow(ctx context.Context) error {
+	window := o.cfg.Common.RunWindowRange
+	if window == nil {
+		return nil
+	}
+	wait := window.Until(time.Now())
+	if wait <= 0 {
+		return nil
+	}
+
+	o.logger.SetStatus("paused until " + time.Now().Add(wait).Format("15:04"))
+	defer o.logger.SetStatus("")
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/errors"
+)
+
+// SchemaColumn describes a generated column in the schema sidecar.
+type SchemaColumn struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	SQLType     string `json:"sql_type"`
+	ParquetType string `json:"parquet_type"`
+	Nullable    bool   `json:"nullable"`
+	Precision   int    `json:"precision,omitempty"`
+	Scale       int    `json:"scale,omitempty"`
+}
+
+// SchemaSidecar is written as <prefix>.schema.json when common.emit_schema is set.
+type SchemaSidecar struct {
+	Format  string         `json:"format"`
+	Columns []SchemaColumn `json:"columns"`
+}
+
+func (o *Orchestrator) schemaSidecarName() string {
+	return fmt.Sprintf("%s.schema.json", o.cfg.Common.Prefix)
+}
+
+// writeSchemaSidecar writes the column specs as JSON through the same storage
+// as the data files.
+func (o *Orchestrator) writeSchemaSidecar(ctx context.Context) error {
+	sidecar := SchemaSidecar{
+		Format:  strings.ToLower(o.cfg.Common.FileFormat),
+		Columns: make([]SchemaColumn, 0, len(o.specs)),
+	}
+	for _, c := range o.specs {
+		col := SchemaColumn{
+			Name:        c.OrigName,
+			Type:        c.DisplaySQLType(),
+			SQLType:     c.SQLType,
+			ParquetType: c.DisplayParquetType(),
+			Nullable:    c.NullPercent > 0 || c.NullCount > 0,
+		}
+		if c.SQLType == "decimal" {
+			col.Precision = c.Precision
+			col.Scale = c.Scale
+		}
+		sidecar.Columns = append(sidecar.Columns, col)
+	}
+
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	name := o.schemaSidecarName()
+	if err := o.store.WriteFile(ctx, name, append(data, '\n')); err != nil {
+		return errors.Annotatef(err, "failed to write schema sidecar %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"context"
+
+	"dataWriter/src/util"
+
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/pingcap/errors"
+)
+
+// runSingleFile writes every file number into one Parquet file, one row group
+// per logical file. Row groups are generated in parallel, but the writer is
+// not concurrent, so they are appended one at a time in file number order.
+func (o *Orchestrator) runSingleFile(ctx context.Context, threads int) error {
+	startNo := o.cfg.Common.StartFileNo
+	endNo := o.cfg.Common.EndFileNo
+
+	codec, err := util.ParquetCompressionCodec(o.cfg.Parquet.Compression)
+	if err != nil {
+		return err
+	}
+
+	writer, err := o.openWriter(ctx, startNo)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for fileNo := startNo; fileNo < endNo; fileNo++ {
+		writer.rows += o.cfg.Common.RowsForFile(fileNo)
+	}
+
+	pw := ParquetWriter{timings: o.timings, uniform: newUniformRowGroups(o.cfg)}
+	if err := o.writeSingleFile(ctx, &pw, writer, codec, threads); err != nil {
+		_ = writer.Close(ctx)
+		writer.discard()
+		return err
+	}
+	// Closing finishes the upload of the file, a failure leaves no complete
+	// file behind.
+	if err := writer.Close(ctx); err != nil {
+		writer.discard()
+		return errors.Trace(err)
+	}
+	o.index.record(startNo, pw.writtenRows)
+	return nil
+}
+
+// writeSingleFile writes the row groups of every file number with pw and
+// closes pw, which writes the footer.
+func (o *Orchestrator) writeSingleFile(
+	ctx context.Context,
+	pw *ParquetWriter,
+	writer *writerWithStats,
+	codec compress.Compression,
+	threads int,
+) error {
+	startNo := o.cfg.Common.StartFileNo
+	endNo := o.cfg.Common.EndFileNo
+	wrapper := &writeWrapper{Writer: writer}
+	if err := pw.Init(wrapper, o.cfg.Common.Rows, 1, o.cfg.Parquet.PageSizeBytes, o.specs, codec, fileSeed(o.cfg, startNo)); err != nil {
+		return errors.Trace(err)
+	}
+
+	err := pipelineRowGroups(ctx, endNo-startNo, threads,
+		func(i int) (*rowGroupBuffer, error) {
+			fileNo := startNo + i
+			rg, err := generateRowGroup(o.specs, fileStartRow(o.cfg, fileNo), o.cfg.Common.RowsForFile(fileNo), pw.uniform.modeOf(i), newFileRand(o.cfg, fileNo), o.timings)
+			return rg, errors.Annotatef(err, "failed to generate row group for file %d", fileNo)
+		},
+		func(i int, rg *rowGroupBuffer) error {
+			if err := pw.writeRowGroupBuffer(rg); err != nil {
+				return errors.Annotatef(err, "failed to write row group for file %d", startNo+i)
+			}
+			o.logger.UpdateFiles(1)
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return errors.Annotate(pw.Close(), "failed to write parquet footer")
+}
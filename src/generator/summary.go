@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/errors"
+)
+
+// ColumnSummary describes a generated column in the run summary.
+type ColumnSummary struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	NullPercent int    `json:"null_percent"`
+	NullCount   int    `json:"null_count,omitempty"`
+	Unique      bool   `json:"unique"`
+}
+
+// RunSummary is the result of a generation run.
+type RunSummary struct {
+	Format         string          `json:"format"`
+	Files          int64           `json:"files"`
+	RowsPerFile    int             `json:"rows_per_file"`
+	TotalRows      int64           `json:"total_rows"`
+	Bytes          int64           `json:"bytes"`
+	ElapsedSeconds float64         `json:"elapsed_seconds"`
+	Throughput     float64         `json:"throughput_bytes_per_sec"`
+	Path           string          `json:"path"`
+	Seed           int64           `json:"seed,omitempty"`
+	Columns        []ColumnSummary `json:"columns"`
+	ColumnTimings  []ColumnTiming  `json:"column_timings,omitempty"`
+}
+
+func (o *Orchestrator) buildSummary(elapsed time.Duration) *RunSummary {
+	files, bytes := o.logger.Snapshot()
+	if files == 0 {
+		files = int64(o.cfg.Common.EndFileNo - o.cfg.Common.StartFileNo)
+	}
+	rowsPerFile := o.cfg.Common.Rows
+	throughput := 0.0
+	if elapsed.Seconds() > 0 {
+		throughput = float64(bytes) / elapsed.Seconds()
+	}
+
+	columns := make([]ColumnSummary, 0, len(o.specs))
+	for _, c := range o.specs {
+		columns = append(columns, ColumnSummary{
+			Name:        c.OrigName,
+			Type:        c.DisplaySQLType(),
+			NullPercent: c.NullPercent,
+			NullCount:   c.NullCount,
+			Unique:      c.IsUnique,
+		})
+	}
+
+	totalRows := o.writtenRows.Load()
+	if o.rolledFiles > 0 {
+		// Progress counted files of common.rows, but the files were cut by
+		// size and rows_per_file is their average.
+		files = int64(o.rolledFiles)
+		rowsPerFile = int(totalRows / files)
+	}
+
+	return &RunSummary{
+		Format:         strings.ToLower(o.cfg.Common.FileFormat),
+		Files:          files,
+		RowsPerFile:    rowsPerFile,
+		TotalRows:      totalRows,
+		Bytes:          bytes,
+		ElapsedSeconds: elapsed.Seconds(),
+		Throughput:     throughput,
+		Path:           o.cfg.Common.Path,
+		Seed:           o.cfg.Common.Seed,
+		Columns:        columns,
+		ColumnTimings:  o.timings.sorted(),
+	}
+}
+
+// printSummary prints the human-readable summary, and writes the JSON summary
+// if common.summary_json is set. When the JSON goes to stdout it replaces the
+// text summary so the output stays parseable.
+func (o *Orchestrator) printSummary(elapsed time.Duration) error {
+	summary := o.buildSummary(elapsed)
+
+	target := o.cfg.Common.SummaryJSON
+	if target != "-" {
+		fmt.Println("Summary:")
+		fmt.Printf("  Format: %s\n", summary.Format)
+		fmt.Printf("  Files: %d\n", summary.Files)
+		fmt.Printf("  Rows/File: %d\n", summary.RowsPerFile)
+		fmt.Printf("  Total Rows: %d\n", summary.TotalRows)
+		fmt.Printf("  Bytes: %s\n", units.BytesSize(float64(summary.Bytes)))
+		fmt.Printf("  Throughput: %s/s\n", units.BytesSize(summary.Throughput))
+		fmt.Printf("  Path: %s\n", summary.Path)
+		if summary.Seed != 0 {
+			fmt.Printf("  Seed: %d\n", summary.Seed)
+		}
+		if len(summary.ColumnTimings) > 0 {
+			fmt.Println("Column timing:")
+			for _, t := range summary.ColumnTimings {
+				fmt.Printf("  %-30s %12s %6.2f%%\n", t.Name,
+					time.Duration(t.Seconds*float64(time.Second)).Round(time.Microsecond), t.Percent)
+			}
+		}
+	}
+	if target == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	data = append(data, '\n')
+	if target == "-" {
+		_, err = os.Stdout.Write(data)
+		return errors.Trace(err)
+	}
+	return errors.Annotatef(os.WriteFile(target, data, 0o644), "failed to write summary to %s", target)
+}
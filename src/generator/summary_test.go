@@ -0,0 +1,109 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSummaryCountsWrittenRows(t *testing.T) {
+	dir := t.TempDir()
+	specs := testSpecs(t, "CREATE TABLE t (id bigint, s varchar(20));")
+	text := func(files int, resume bool) string {
+		return fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = %d
+rows = 100
+format = "csv"
+resume = %v
+`, dir, files, resume)
+	}
+
+	o := runTest(t, testConfig(t, text(2, false)), specs)
+	if got := o.buildSummary(time.Second).TotalRows; got != 200 {
+		t.Errorf("TotalRows = %d, want 200", got)
+	}
+	// Resume skips the two files written above.
+	o = runTest(t, testConfig(t, text(3, true)), specs)
+	if got := o.buildSummary(time.Second).TotalRows; got != 100 {
+		t.Errorf("TotalRows after resume = %d, want 100", got)
+	}
+}
+
+func TestSummaryCountsTargetSizeRows(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(t, fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 2
+rows = 100
+format = "parquet"
+
+[parquet]
+row_groups = 1
+compression = "snappy"
+target_compressed_size = "64KiB"
+`, dir))
+	o := runTest(t, cfg, testSpecs(t, "CREATE TABLE t (id bigint, s varchar(200));"))
+
+	want := 0
+	for fileNo := range 2 {
+		rows, err := countParquetRows(filepath.Join(dir, testFileName(t, o, fileNo)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want += rows
+	}
+	if want <= 200 {
+		t.Fatalf("files hold %d rows, the target should take more than rows per file", want)
+	}
+	if got := o.buildSummary(time.Second).TotalRows; got != int64(want) {
+		t.Errorf("TotalRows = %d, files hold %d rows", got, want)
+	}
+}
+
+func TestSummaryJSONKeepsStdoutParseable(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(t, fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+end_fileno = 2
+rows = 100
+format = "csv"
+summary_json = "-"
+`, dir))
+	specs := testSpecs(t, "CREATE TABLE t (id bigint, s varchar(20));")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	out := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- data
+	}()
+	runTest(t, cfg, specs)
+	os.Stdout = stdout
+	w.Close()
+	data := <-out
+
+	var summary RunSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatalf("stdout is not the JSON summary: %v\n%s", err, data)
+	}
+	if summary.TotalRows != 200 {
+		t.Errorf("summary has %d rows, want 200", summary.TotalRows)
+	}
+}
@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"bytes"
+	"math/rand"
+	"slices"
+
+	"dataWriter/src/config"
+	"dataWriter/src/spec"
+
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/pingcap/errors"
+)
+
+const (
+	uniformAllNull  = "all_null"
+	uniformConstant = "constant"
+
+	// maxConstantAttempts bounds the batches generated while looking for a
+	// non-NULL value for a constant row group.
+	maxConstantAttempts = 100
+)
+
+// uniformRowGroups lists the row groups of each file that are written
+// entirely NULL or as one repeated value, so their statistics let readers
+// skip them.
+type uniformRowGroups struct {
+	mode   string
+	groups map[int]bool
+}
+
+// newUniformRowGroups returns nil if no uniform row groups are configured.
+func newUniformRowGroups(cfg *config.Config) *uniformRowGroups {
+	if len(cfg.Parquet.UniformRowGroups) == 0 {
+		return nil
+	}
+	u := &uniformRowGroups{
+		mode:   cfg.Parquet.UniformMode,
+		groups: make(map[int]bool, len(cfg.Parquet.UniformRowGroups)),
+	}
+	for _, i := range cfg.Parquet.UniformRowGroups {
+		u.groups[i] = true
+	}
+	return u
+}
+
+// checkUniformRowGroups rejects all_null row groups for columns that cannot
+// hold NULL.
+func checkUniformRowGroups(cfg *config.Config, specs []*spec.ColumnSpec) error {
+	if len(cfg.Parquet.UniformRowGroups) == 0 || cfg.Parquet.UniformMode != uniformAllNull {
+		return nil
+	}
+	for _, s := range specs {
+		if s.Required {
+			return errors.Errorf("column %s is required and cannot be NULL with parquet.uniform_mode = %s", s.OrigName, uniformAllNull)
+		}
+	}
+	return nil
+}
+
+// modeOf returns the mode of row group i, or "" for a normal row group.
+func (u *uniformRowGroups) modeOf(i int) string {
+	if u == nil || !u.groups[i] {
+		return ""
+	}
+	return u.mode
+}
+
+// uniformColumn rewrites the generated batches of one column chunk.
+type uniformColumn struct {
+	spec *spec.ColumnSpec
+	mode string
+	// value is the constant of the chunk, taken from the first non-NULL row.
+	value any
+}
+
+// apply makes a filled batch all NULL or all the constant value.
+func (u *uniformColumn) apply(rowID int, values any, defLevels []int16, rng *rand.Rand) error {
+	switch u.mode {
+	case "":
+		return nil
+	case uniformAllNull:
+		clear(defLevels)
+		return nil
+	}
+
+	for attempt := 0; u.value == nil; attempt++ {
+		if i := slices.Index(defLevels, 1); i >= 0 {
+			u.value = cloneValue(values, i)
+			break
+		}
+		if attempt == maxConstantAttempts {
+			return errors.Errorf("column %s generated no non-NULL value for parquet.uniform_mode = %s", u.spec.OrigName, uniformConstant)
+		}
+		if err := u.spec.FillParquetBatch(rowID, values, defLevels, rng); err != nil {
+			return err
+		}
+	}
+	fillValue(values, u.value)
+	for i := range defLevels {
+		defLevels[i] = 1
+	}
+	return nil
+}
+
+// cloneValue returns a copy of the i-th value of a buffer from newValueBuffer.
+func cloneValue(buf any, i int) any {
+	switch b := buf.(type) {
+	case []int32:
+		return b[i]
+	case []int64:
+		return b[i]
+	case []parquet.Int96:
+		return b[i]
+	case []float64:
+		return b[i]
+	case []float32:
+		return b[i]
+	case []parquet.FixedLenByteArray:
+		return parquet.FixedLenByteArray(bytes.Clone(b[i]))
+	case []parquet.ByteArray:
+		return parquet.ByteArray(bytes.Clone(b[i]))
+	default:
+		panic("unimplemented")
+	}
+}
+
+// fillValue sets every value of a buffer from newValueBuffer to v.
+func fillValue(buf any, v any) {
+	switch b := buf.(type) {
+	case []int32:
+		fillSlice(b, v.(int32))
+	case []int64:
+		fillSlice(b, v.(int64))
+	case []parquet.Int96:
+		fillSlice(b, v.(parquet.Int96))
+	case []float64:
+		fillSlice(b, v.(float64))
+	case []float32:
+		fillSlice(b, v.(float32))
+	case []parquet.FixedLenByteArray:
+		fillSlice(b, v.(parquet.FixedLenByteArray))
+	case []parquet.ByteArray:
+		fillSlice(b, v.(parquet.ByteArray))
+	default:
+		panic("unimplemented")
+	}
+}
+
+func fillSlice[T any](s []T, v T) {
+	for i := range s {
+		s[i] = v
+	}
+}
@@ -2,25 +2,103 @@ package generator
 
 import (
 	"context"
+	"fmt"
+	"hash"
+
 	"dataWriter/src/util"
 
 	"github.com/pingcap/tidb/br/pkg/storage"
 )
 
-// writerWithStats wraps a writer and updates progress for bytes written.
+// writerWithStats wraps a writer and updates progress for bytes written. Its
+// errors are marked as storage errors, which common.max_retries retries.
 type writerWithStats struct {
-	writer storage.ExternalFileWriter
-	logger *util.ProgressLogger
+	writer  storage.ExternalFileWriter
+	logger  *util.ProgressLogger
+	fileLog *fileLog
+	fileNo  int
+	written int64
+	// gzip, when set, compresses what is written before it reaches writer.
+	// The progress counts the compressed bytes.
+	gzip *gzipWriter
+	// limiter caps the bytes written per second, nil without
+	// common.rate_limit.
+	limiter *rateLimiter
+
+	// name and rows describe the file, in manifest too when it is closed;
+	// crc is nil without common.manifest.
+	manifest *manifest
+	name     string
+	rows     int
+	crc      hash.Hash32
 }
 
 func (cw *writerWithStats) Write(ctx context.Context, p []byte) (int, error) {
+	if cw.gzip == nil {
+		return cw.write(ctx, p)
+	}
+	data, err := cw.gzip.compress(p)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := cw.write(ctx, data); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (cw *writerWithStats) write(ctx context.Context, p []byte) (int, error) {
+	if err := cw.limiter.wait(ctx, len(p)); err != nil {
+		return 0, err
+	}
 	n, err := cw.writer.Write(ctx, p)
+	cw.written += int64(n)
+	if cw.crc != nil {
+		cw.crc.Write(p[:n])
+	}
 	if cw.logger != nil {
-		cw.logger.UpdateBytes(int64(n))
+		cw.logger.UpdateFileBytes(cw.fileNo, int64(n))
 	}
-	return n, err
+	cw.fileLog.addBytes(cw.fileNo, int64(n))
+	return n, asStorageError(err)
 }
 
+// Close writes the gzip trailer, if compressing, before closing the file.
 func (cw *writerWithStats) Close(ctx context.Context) error {
-	return cw.writer.Close(ctx)
+	if cw.gzip != nil {
+		tail, err := cw.gzip.finish()
+		if err == nil {
+			_, err = cw.write(ctx, tail)
+		}
+		if err != nil {
+			_ = cw.writer.Close(ctx)
+			return err
+		}
+	}
+	if err := cw.writer.Close(ctx); err != nil {
+		return asStorageError(err)
+	}
+	if cw.crc != nil {
+		cw.manifest.record(cw.name, ManifestFile{
+			Size:   cw.written,
+			CRC32C: fmt.Sprintf("%08x", cw.crc.Sum32()),
+			Rows:   cw.rows,
+		})
+	}
+	return nil
+}
+
+// discard takes the bytes of a failed file back out of the progress, so a
+// retried file is counted once, and removes a failed local file, so resume
+// doesn't take it for a complete one.
+func (cw *writerWithStats) discard() {
+	if lw, ok := cw.writer.(*localFileWriter); ok {
+		lw.remove()
+	}
+	if cw.logger != nil {
+		cw.logger.UpdateFileBytes(cw.fileNo, -cw.written)
+	}
+	cw.fileLog.addBytes(cw.fileNo, -cw.written)
+	cw.manifest.remove(cw.name)
+	cw.written = 0
 }
@@ -3,38 +3,98 @@ package main
 import (
 	"flag"
 	"fmt"
-	"log"
 	"os"
 	"runtime/pprof"
 	"strings"
 
 	"dataWriter/src/config"
+	"dataWriter/src/generator"
 	"dataWriter/src/spec"
+	"dataWriter/src/util"
+	"dataWriter/src/util/logger"
 
 	"github.com/BurntSushi/toml"
+	"github.com/pingcap/errors"
 )
 
 func main() {
-	operation := flag.String("op", "create", "create/delete/show/ls/upload/download, default is create")
+	if err := run(); err != nil {
+		util.Errorf("%v", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the operation selected by the flags. Deferred cleanups like
+// stopping the CPU profile run before main exits on an error.
+func run() error {
+	operation := flag.String("op", "create", "create/delete/show/ls/upload/download/show-spec/convert/check-storage/validate, default is create")
 	sqlPath := flag.String("sql", "", "sql path")
 	cfgPath := flag.String("cfg", "", "config path")
 	threads := flag.Int("threads", 16, "threads")
 	localDir := flag.String("dir", "", "local directory for upload/download operation")
 	cpuProfile := flag.String("cpuprofile", "", "write cpu profile to file (or use CPUPROFILE env var)")
 	showSpec := flag.Bool("show-spec", false, "print parsed schema spec and exit")
+	input := flag.String("input", "", "input file for convert operation")
+	output := flag.String("output", "", "output file for convert operation")
+	summaryJSON := flag.String("summary-json", "", "write run summary as JSON to file, or - for stdout")
+	columnTiming := flag.Bool("column-timing", false, "print time spent generating each column")
+	appendFiles := flag.Bool("append", false, "continue after the largest file number already written, keeping the number of files")
+	sampleRate := flag.Float64("sample-rate", 1, "share of each CSV file checked by the validate operation, between 0 and 1")
+	logLevel := flag.String("log-level", "info", "lowest level logged: debug, info, warn or error")
+	logFormat := flag.String("log-format", "console", "log format: console or json")
 
 	flag.Parse()
 
-	if *showSpec {
-		if *sqlPath == "" {
-			log.Fatalf("SQL file (-sql) is required for -show-spec")
+	level, err := logger.ParseLevel(*logLevel)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(*logFormat) {
+	case "console":
+		logger.SetLogger(logger.NewConsoleLogger(os.Stderr, level))
+	case "json":
+		logger.SetLogger(logger.NewJSONLogger(os.Stderr, level))
+	default:
+		return errors.Errorf("unknown log format %q, must be console or json", *logFormat)
+	}
+	logger.RedirectStdLog()
+
+	// show-spec only parses the schema, so it needs neither config nor storage.
+	if *showSpec || strings.ToLower(*operation) == "show-spec" {
+		// The config is optional here, it only provides parse options.
+		var cfg config.Config
+		if *cfgPath != "" {
+			if _, err := toml.DecodeFile(*cfgPath, &cfg); err != nil {
+				return errors.Annotate(err, "failed to load config")
+			}
+		}
+		if *sqlPath == "" && len(cfg.SyntheticSchema) == 0 {
+			return errors.New("SQL file (-sql) or synthetic_schema is required for show-spec")
 		}
-		specs, err := spec.GetSpecFromSQL(*sqlPath)
+		specs, err := generator.LoadSpecs(&cfg, *sqlPath)
 		if err != nil {
-			log.Fatalf("Failed to parse SQL: %v", err)
+			return errors.Annotate(err, "failed to parse SQL")
 		}
 		fmt.Print(spec.FormatSpecsTable(specs))
-		return
+		return nil
+	}
+
+	// convert reads a local file, the config provides format options and the
+	// credentials of a remote -output.
+	if strings.ToLower(*operation) == "convert" {
+		if *input == "" {
+			return errors.New("input file (-input) is required for convert operation")
+		}
+		var cfg config.Config
+		if *cfgPath != "" {
+			if _, err := toml.DecodeFile(*cfgPath, &cfg); err != nil {
+				return errors.Annotate(err, "failed to load config")
+			}
+		}
+		if err := config.Normalize(&cfg); err != nil {
+			return errors.Annotate(err, "invalid config")
+		}
+		return errors.Annotate(ConvertFile(&cfg, *sqlPath, *input, *output), "failed to convert file")
 	}
 
 	profilePath := *cpuProfile
@@ -44,57 +104,77 @@ func main() {
 	if profilePath != "" {
 		f, err := os.Create(profilePath)
 		if err != nil {
-			log.Fatalf("Failed to create cpu profile file: %v", err)
+			return errors.Annotate(err, "failed to create cpu profile file")
 		}
 		if err := pprof.StartCPUProfile(f); err != nil {
-			log.Fatalf("Failed to start cpu profile: %v", err)
+			return errors.Annotate(err, "failed to start cpu profile")
 		}
 		defer func() {
 			pprof.StopCPUProfile()
 			if err := f.Close(); err != nil {
-				log.Printf("Failed to close cpu profile file: %v", err)
+				util.Warnf("Failed to close cpu profile file: %v", err)
 			}
 		}()
-		log.Printf("CPU profiling enabled: %s", profilePath)
+		util.Infof("CPU profiling enabled: %s", profilePath)
 	}
 
 	var cfg config.Config
-	toml.DecodeFile(*cfgPath, &cfg)
+	if _, err := toml.DecodeFile(*cfgPath, &cfg); err != nil {
+		return errors.Annotate(err, "failed to load config")
+	}
+	if *summaryJSON != "" {
+		cfg.Common.SummaryJSON = *summaryJSON
+	}
+	if *columnTiming {
+		cfg.Common.ColumnTiming = true
+	}
+	if *appendFiles {
+		cfg.Common.Append = true
+	}
 	if err := config.Normalize(&cfg); err != nil {
-		log.Fatalf("Invalid config: %v", err)
+		return errors.Annotate(err, "invalid config")
 	}
 	if err := config.Validate(&cfg); err != nil {
-		log.Fatalf("%v", err)
+		return err
 	}
 
 	switch strings.ToLower(*operation) {
 	case "delete":
 		if err := DeleteAllFiles(&cfg); err != nil {
-			log.Fatalf("Failed to delete files: %v", err)
+			return errors.Annotate(err, "failed to delete files")
 		}
 	case "show", "ls":
 		if err := ShowFiles(&cfg); err != nil {
-			log.Fatalf("Failed to show files: %v", err)
+			return errors.Annotate(err, "failed to show files")
+		}
+	case "check-storage":
+		if err := CheckStorage(&cfg); err != nil {
+			return errors.Annotate(err, "storage check failed")
+		}
+	case "validate":
+		if err := ValidateFiles(&cfg, *sqlPath, *threads, *sampleRate); err != nil {
+			return errors.Annotate(err, "validation failed")
 		}
 	case "create":
 		if err := GenerateFiles(&cfg, *sqlPath, *threads); err != nil {
-			log.Fatalf("Failed to generate files: %v", err)
+			return errors.Annotate(err, "failed to generate files")
 		}
 	case "upload":
 		if *localDir == "" {
-			log.Fatalf("Local directory (-dir) must be specified for upload operation")
+			return errors.New("local directory (-dir) must be specified for upload operation")
 		}
 		if err := UploadLocalFiles(&cfg, *localDir, *threads); err != nil {
-			log.Fatalf("Failed to upload files: %v", err)
+			return errors.Annotate(err, "failed to upload files")
 		}
 	case "download":
 		if *localDir == "" {
-			log.Fatalf("Local directory (-dir) must be specified for download operation")
+			return errors.New("local directory (-dir) must be specified for download operation")
 		}
 		if err := DownloadFiles(&cfg, *localDir, *threads); err != nil {
-			log.Fatalf("Failed to download files: %v", err)
+			return errors.Annotate(err, "failed to download files")
 		}
 	default:
-		log.Fatalf("Unknown operation: %s", *operation)
+		return errors.Errorf("unknown operation: %s", *operation)
 	}
+	return nil
 }
@@ -1,19 +1,25 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	goerrors "errors"
 	"fmt"
 	"io"
-	"log"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"time"
 
 	"dataWriter/src/config"
+	"dataWriter/src/converter"
 	"dataWriter/src/generator"
+	"dataWriter/src/spec"
 	"dataWriter/src/util"
 
 	"github.com/pingcap/errors"
@@ -58,14 +64,356 @@ func ShowFiles(cfg *config.Config) error {
 	defer store.Close()
 
 	store.WalkDir(context.Background(), &storage.WalkOption{SkipSubDir: true}, func(path string, size int64) error {
-		log.Printf("Name: %s, Size: %d, Size (MiB): %f", path, size, float64(size)/1024/1024)
+		util.Infof("Name: %s, Size: %d, Size (MiB): %f", path, size, float64(size)/1024/1024)
 		return nil
 	})
 
 	return nil
 }
 
+// appendConfig applies common.append: the returned config keeps the number
+// of files of cfg but starts after the largest file number already under the
+// path of any of dirs, and no earlier than start_fileno.
+func appendConfig(cfg *config.Config, dirs ...*config.Config) (*config.Config, error) {
+	if !cfg.Common.Append {
+		return cfg, nil
+	}
+	next := cfg.Common.StartFileNo
+	for _, dir := range dirs {
+		n, err := nextFileNo(dir)
+		if err != nil {
+			return nil, err
+		}
+		next = max(next, n)
+	}
+	appendCfg := *cfg
+	appendCfg.Common.StartFileNo = next
+	appendCfg.Common.EndFileNo = next + cfg.Common.EndFileNo - cfg.Common.StartFileNo
+	util.Infof("Appending files %d to %d", appendCfg.Common.StartFileNo, appendCfg.Common.EndFileNo-1)
+	return &appendCfg, nil
+}
+
+// nextFileNo returns one past the largest N of the prefix.N.suffix files
+// under common.path, in any folder, or 0 if there are none. Other files are
+// ignored.
+func nextFileNo(cfg *config.Config) (int, error) {
+	store, err := config.GetStore(cfg)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+
+	//nolint: errcheck
+	defer store.Close()
+
+	next, suffix := 0, cfg.FileSuffix()
+	err = store.WalkDir(context.Background(), &storage.WalkOption{}, func(path string, size int64) error {
+		if n, ok := config.ParseFileNo(filepath.Base(path), cfg.Common.Prefix, suffix); ok {
+			next = max(next, n+1)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Annotate(err, "failed to list existing files for append")
+	}
+	return next, nil
+}
+
+// checkStorageHint guesses the cause of a storage error from its message.
+func checkStorageHint(err error) string {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "invalidaccesskeyid"),
+		strings.Contains(msg, "signaturedoesnotmatch"),
+		strings.Contains(msg, "nocredentialproviders"),
+		strings.Contains(msg, "expiredtoken"),
+		strings.Contains(msg, "could not find default credentials"),
+		strings.Contains(msg, "invalid_grant"):
+		return "check the credentials in [s3]/[gcs] or the environment"
+	case strings.Contains(msg, "accessdenied"),
+		strings.Contains(msg, "access denied"),
+		strings.Contains(msg, "forbidden"),
+		strings.Contains(msg, "permission denied"):
+		return "the credentials lack permission on this path"
+	case strings.Contains(msg, "nosuchbucket"),
+		strings.Contains(msg, "bucket doesn't exist"),
+		strings.Contains(msg, "bucket does not exist"),
+		strings.Contains(msg, "no such file or directory"):
+		return "the bucket or directory does not exist"
+	default:
+		return "check common.path and the storage settings"
+	}
+}
+
+// CheckStorage verifies the configured storage is writable by writing a small
+// object, reading it back, listing it and deleting it.
+func CheckStorage(cfg *config.Config) error {
+	start := time.Now()
+	ctx := context.Background()
+	name := fmt.Sprintf(".%s.check-storage.%d", cfg.Common.Prefix, time.Now().UnixNano())
+	payload := []byte("data-writer storage check\n")
+
+	fail := func(step string, err error) error {
+		return errors.Errorf("%s failed on %s: %v (%s)", step, cfg.Common.Path, err, checkStorageHint(err))
+	}
+
+	store, err := config.GetStore(cfg)
+	if err != nil {
+		return fail("open storage", err)
+	}
+	defer store.Close()
+
+	writer, err := store.Create(ctx, name, cfg.Common.WriterOption())
+	if err != nil {
+		return fail("create", err)
+	}
+	if _, err := writer.Write(ctx, payload); err != nil {
+		_ = writer.Close(ctx)
+		return fail("write", err)
+	}
+	if err := writer.Close(ctx); err != nil {
+		return fail("write", err)
+	}
+
+	data, err := store.ReadFile(ctx, name)
+	if err != nil {
+		return fail("read", err)
+	}
+	if !bytes.Equal(data, payload) {
+		return errors.Errorf("read failed on %s: content mismatch, wrote %d bytes, read %d bytes",
+			cfg.Common.Path, len(payload), len(data))
+	}
+
+	var found bool
+	if err := store.WalkDir(ctx, &storage.WalkOption{SkipSubDir: true}, func(path string, size int64) error {
+		if path == name {
+			found = true
+		}
+		return nil
+	}); err != nil {
+		return fail("list", err)
+	}
+
+	if err := store.DeleteFile(ctx, name); err != nil {
+		return fail("delete", err)
+	}
+	if !found {
+		return errors.Errorf("list failed on %s: test object %s not found", cfg.Common.Path, name)
+	}
+
+	util.Infof("Storage %s is writable (write/read/list/delete ok) in %s", cfg.Common.Path, time.Since(start))
+	return nil
+}
+
+// ConvertFile converts a local file into the other format, the output format
+// is decided by the input extension. Converting CSV needs the SQL schema. The
+// output is a local path, or a remote URL such as s3://bucket/dir/t.parquet
+// written through the storage of cfg.
+func ConvertFile(cfg *config.Config, sqlPath, input, output string) error {
+	start := time.Now()
+	ext := strings.ToLower(filepath.Ext(input))
+	switch ext {
+	case ".parquet":
+		if output == "" {
+			ext := ".csv"
+			if cfg.CSV.IsTSV() {
+				ext = ".tsv"
+			}
+			output = strings.TrimSuffix(input, filepath.Ext(input)) + ext
+		}
+		var specs []*spec.ColumnSpec
+		if sqlPath != "" || len(cfg.SyntheticSchema) > 0 {
+			var err error
+			if specs, err = generator.LoadSpecs(cfg, sqlPath); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		out, err := createConvertOutput(cfg, output)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		err = converter.ConvertParquetToCSV(input, out, cfg.CSV, specs)
+		if err := closeConvertOutput(out, output, err); err != nil {
+			return errors.Trace(err)
+		}
+	case ".csv", ".tsv":
+		inferSchema := sqlPath == "" && len(cfg.SyntheticSchema) == 0
+		if inferSchema && !cfg.CSV.InferSchema {
+			return errors.New("SQL file (-sql), synthetic_schema or csv.infer_schema is required to convert csv to parquet")
+		}
+		if output == "" {
+			output = strings.TrimSuffix(input, filepath.Ext(input)) + ".parquet"
+		}
+		var specs []*spec.ColumnSpec
+		var err error
+		if inferSchema {
+			specs, err = converter.InferCSVSpecs(input, cfg)
+		} else {
+			specs, err = generator.LoadSpecs(cfg, sqlPath)
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+		var resume *converter.Checkpoint
+		if cfg.Parquet.ConvertCheckpoint != "" {
+			if resume, err = converter.LoadCheckpoint(cfg.Parquet.ConvertCheckpoint); err != nil {
+				return errors.Trace(err)
+			}
+			if resume != nil {
+				util.Infof("Resuming after %d rows at byte %d of %s, from %s", resume.Rows, resume.Offset, input,
+					converter.ContinuationName(output, resume.Part+1))
+			}
+		}
+		rows, err := converter.ConvertCSVToParquet(input, func(part int) (io.WriteCloser, error) {
+			return createConvertOutput(cfg, converter.ContinuationName(output, part))
+		}, specs, cfg, resume)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		util.Infof("Converted %d rows", rows)
+	default:
+		return errors.Errorf("unsupported input file for convert: %s", input)
+	}
+
+	util.Infof("Converted %s -> %s in %s", input, output, time.Since(start))
+	return nil
+}
+
+// createConvertOutput creates the output file of convert. A remote URL is
+// split into the directory, opened as the storage with the credentials of
+// cfg, and the object name created in it; a local path is created with
+// os.Create as before.
+func createConvertOutput(cfg *config.Config, output string) (io.WriteCloser, error) {
+	dir, name := path.Split(output)
+	storeCfg := *cfg
+	storeCfg.Common.Path = dir
+	if _, local := config.LocalDir(&storeCfg); local || dir == "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to create output file: %s", output)
+		}
+		return f, nil
+	}
+	if name == "" {
+		return nil, errors.Errorf("output %s has no file name", output)
+	}
+	store, err := config.GetStore(&storeCfg)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to open storage: %s", dir)
+	}
+	ctx := context.Background()
+	writer, err := store.Create(ctx, name, cfg.Common.WriterOption())
+	if err != nil {
+		store.Close()
+		return nil, errors.Annotatef(err, "failed to create remote file: %s", output)
+	}
+	return &storeFileWriter{ctx: ctx, store: store, w: writer}, nil
+}
+
+// closeConvertOutput closes the output of convert and returns err, or the
+// close error if the conversion succeeded. Closing a remote file finishes its
+// upload.
+func closeConvertOutput(out io.Closer, output string, err error) error {
+	if cerr := out.Close(); cerr != nil && err == nil {
+		return errors.Annotatef(cerr, "failed to close output file: %s", output)
+	}
+	return err
+}
+
+// storeFileWriter adapts a storage file writer to io.WriteCloser, closing
+// the storage it was created in with it.
+type storeFileWriter struct {
+	ctx   context.Context
+	store storage.ExternalStorage
+	w     storage.ExternalFileWriter
+}
+
+func (w *storeFileWriter) Write(p []byte) (int, error) {
+	return w.w.Write(w.ctx, p)
+}
+
+func (w *storeFileWriter) Close() error {
+	err := w.w.Close(w.ctx)
+	w.store.Close()
+	return err
+}
+
+// GenerateFiles generates the dataset of every prefix common.prefix expands
+// to, one after another, each with the full file range.
 func GenerateFiles(cfg *config.Config, sqlPath string, threads int) error {
+	prefixes, err := config.ExpandPrefix(cfg.Common.Prefix)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(prefixes) == 1 {
+		return generateDataset(cfg, sqlPath, threads)
+	}
+	if len(cfg.SyntheticSchema) == 0 {
+		// Tables of a multi-table schema use their names as prefixes.
+		tables, err := generator.LoadTables(cfg, sqlPath)
+		if err != nil && !goerrors.Is(err, spec.ErrNotMultiTable) {
+			return errors.Trace(err)
+		}
+		if len(tables) > 1 {
+			return errors.Errorf("common.prefix %q cannot be brace expanded with a multi-table schema", cfg.Common.Prefix)
+		}
+	}
+	for _, prefix := range prefixes {
+		util.Infof("Generating prefix %s", prefix)
+		prefixCfg := *cfg
+		prefixCfg.Common.Prefix = prefix
+		if err := generateDataset(&prefixCfg, sqlPath, threads); err != nil {
+			return errors.Annotatef(err, "prefix %s", prefix)
+		}
+	}
+	return nil
+}
+
+func generateDataset(cfg *config.Config, sqlPath string, threads int) error {
+	if len(cfg.SyntheticSchema) > 0 {
+		return generateSingleTable(cfg, sqlPath, threads)
+	}
+	// The single table parser is more lenient with trailing content, so a
+	// single table schema the multi-table parser rejects goes through it.
+	tables, err := generator.LoadTables(cfg, sqlPath)
+	if goerrors.Is(err, spec.ErrNotMultiTable) {
+		return generateSingleTable(cfg, sqlPath, threads)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	dirs := []*config.Config{cfg}
+	if len(tables) > 1 {
+		dirs = dirs[:0]
+		for name := range tables {
+			dirs = append(dirs, tableConfig(cfg, name))
+		}
+	}
+	if cfg, err = appendConfig(cfg, dirs...); err != nil {
+		return errors.Trace(err)
+	}
+	if err := generator.ResolveForeignKeys(cfg, tables); err != nil {
+		return errors.Trace(err)
+	}
+	if len(tables) == 1 {
+		for _, table := range tables {
+			return generateTable(cfg, table, threads)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(tables)) {
+		util.Infof("Generating table %s", name)
+		if err := generateTable(tableConfig(cfg, name), tables[name], threads); err != nil {
+			return errors.Annotatef(err, "table %s", name)
+		}
+	}
+	return nil
+}
+
+func generateSingleTable(cfg *config.Config, sqlPath string, threads int) error {
+	cfg, err := appendConfig(cfg, cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	gen, err := generator.NewOrchestrator(cfg, sqlPath)
 	if err != nil {
 		return errors.Trace(err)
@@ -75,11 +423,35 @@ func GenerateFiles(cfg *config.Config, sqlPath string, threads int) error {
 	return gen.Run(cfg.Common.UseStreamingMode, threads)
 }
 
+// tableConfig returns the config of one table of a multi-table schema, which
+// writes to a subfolder named after the table with the table name as prefix.
+func tableConfig(cfg *config.Config, table string) *config.Config {
+	tableCfg := *cfg
+	tableCfg.Common.Path = strings.TrimSuffix(cfg.Common.Path, "/") + "/" + table
+	tableCfg.Common.Prefix = table
+	return &tableCfg
+}
+
+func generateTable(cfg *config.Config, table *spec.Table, threads int) error {
+	if table.Broadcast && !cfg.Common.Broadcast {
+		broadcastCfg := *cfg
+		broadcastCfg.Common.Broadcast = true
+		cfg = &broadcastCfg
+	}
+	gen, err := generator.NewOrchestratorFromSpecs(cfg, table.Specs)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer gen.Close()
+
+	return gen.Run(cfg.Common.UseStreamingMode, threads)
+}
+
 // UploadLocalFiles uploads all files from a local directory to the configured remote path
 func UploadLocalFiles(cfg *config.Config, localDir string, threads int) error {
 	start := time.Now()
 	defer func() {
-		fmt.Printf("Upload took %s\n", time.Since(start))
+		util.Infof("Upload took %s", time.Since(start))
 	}()
 
 	// Validate local directory exists
@@ -111,11 +483,11 @@ func UploadLocalFiles(cfg *config.Config, localDir string, threads int) error {
 	}
 
 	if len(filesToUpload) == 0 {
-		fmt.Println("No files to upload")
+		util.Infof("No files to upload")
 		return nil
 	}
 
-	fmt.Printf("Found %d files to upload\n", len(filesToUpload))
+	util.Infof("Found %d files to upload", len(filesToUpload))
 
 	ctx := context.Background()
 	eg, _ := errgroup.WithContext(ctx)
@@ -161,9 +533,7 @@ func UploadLocalFiles(cfg *config.Config, localDir string, threads int) error {
 			}
 
 			// Create remote file writer
-			writer, err := store.Create(ctx, remotePath, &storage.WriterOption{
-				Concurrency: 8,
-			})
+			writer, err := store.Create(ctx, remotePath, cfg.Common.WriterOption())
 			if err != nil {
 				return errors.Annotatef(err, "failed to create remote file: %s", remotePath)
 			}
@@ -176,7 +546,7 @@ func UploadLocalFiles(cfg *config.Config, localDir string, threads int) error {
 			}
 
 			uploadedFiles.Add(1)
-			log.Printf("Uploaded: %s -> %s", filePath, remotePath)
+			util.Debugf("Uploaded: %s -> %s", filePath, remotePath)
 			return nil
 		})
 	}
@@ -185,7 +555,7 @@ func UploadLocalFiles(cfg *config.Config, localDir string, threads int) error {
 		return errors.Trace(err)
 	}
 
-	fmt.Printf("\nSuccessfully uploaded %d files\n", len(filesToUpload))
+	util.Infof("Successfully uploaded %d files", len(filesToUpload))
 	return nil
 }
 
@@ -193,7 +563,7 @@ func UploadLocalFiles(cfg *config.Config, localDir string, threads int) error {
 func DownloadFiles(cfg *config.Config, localDir string, threads int) error {
 	start := time.Now()
 	defer func() {
-		fmt.Printf("Download took %s\n", time.Since(start))
+		util.Infof("Download took %s", time.Since(start))
 	}()
 
 	if err := os.MkdirAll(localDir, 0o755); err != nil {
@@ -215,11 +585,11 @@ func DownloadFiles(cfg *config.Config, localDir string, threads int) error {
 	}
 
 	if len(filesToDownload) == 0 {
-		fmt.Println("No files to download")
+		util.Infof("No files to download")
 		return nil
 	}
 
-	fmt.Printf("Found %d files to download\n", len(filesToDownload))
+	util.Infof("Found %d files to download", len(filesToDownload))
 
 	ctx := context.Background()
 	eg, egCtx := errgroup.WithContext(ctx)
@@ -280,7 +650,7 @@ func DownloadFiles(cfg *config.Config, localDir string, threads int) error {
 			}
 
 			downloadedFiles.Add(1)
-			log.Printf("Downloaded: %s -> %s", remotePath, localPath)
+			util.Debugf("Downloaded: %s -> %s", remotePath, localPath)
 			return nil
 		})
 	}
@@ -289,6 +659,6 @@ func DownloadFiles(cfg *config.Config, localDir string, threads int) error {
 		return errors.Trace(err)
 	}
 
-	fmt.Printf("\nSuccessfully downloaded %d files\n", len(filesToDownload))
+	util.Infof("Successfully downloaded %d files", len(filesToDownload))
 	return nil
 }
@@ -0,0 +1,79 @@
+package spec
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// caseVariantSalt separates the case variant rows from the duplicate key rows
+// of a column.
+const caseVariantSalt = 0x2545f4914f6cdd1d
+
+// caseVariantSource returns the row whose value rowID repeats with different
+// letter case, for case_variants_percent of the rows. The source is never a
+// case variant itself, so its value was written as is.
+func (c *ColumnSpec) caseVariantSource(rowID int) (int, bool) {
+	src, ok := c.runContext().earlierRow(rowID, c.CaseVariantsPercent, c.runSalt^caseVariantSalt)
+	if !ok {
+		return rowID, false
+	}
+	for {
+		prev, ok := c.runContext().earlierRow(src, c.CaseVariantsPercent, c.runSalt^caseVariantSalt)
+		if !ok {
+			return src, true
+		}
+		src = prev
+	}
+}
+
+// stringValue returns the string value of rowID before whitespace padding.
+func (c *ColumnSpec) stringValue(rowID int, rng *rand.Rand) string {
+	if c.CaseVariantsPercent > 0 {
+		if src, ok := c.caseVariantSource(rowID); ok {
+			s := c.generateRawString(c.valueSource(src, rng))
+			return changeCase(s, rand.New(&splitmixSource{state: c.runContext().Seed ^ c.runSalt ^ uint64(rowID)}))
+		}
+	}
+	return c.generateRawString(c.valueSource(rowID, rng))
+}
+
+// changeCase flips the case of a random subset of the ASCII letters of s, and
+// of at least one letter, so the result differs from s if it has letters.
+func changeCase(s string, rng *rand.Rand) string {
+	b := []byte(s)
+	first, changed := -1, false
+	for i, ch := range b {
+		if !isASCIILetter(ch) {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		if rng.Intn(2) == 0 {
+			b[i] ^= 0x20
+			changed = true
+		}
+	}
+	if !changed && first >= 0 {
+		b[first] ^= 0x20
+	}
+	return string(b)
+}
+
+func isASCIILetter(ch byte) bool {
+	return ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z')
+}
+
+// checkCaseVariants validates case_variants_percent.
+func (c *ColumnSpec) checkCaseVariants() error {
+	if c.CaseVariantsPercent == 0 {
+		return nil
+	}
+	if !isStringType(c.SQLType) || c.IsBinary() || c.SQLType == "enum" || c.SQLType == "set" {
+		return fmt.Errorf("case_variants_percent is only supported for text columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if c.RunLength > 1 {
+		return fmt.Errorf("case_variants_percent cannot be combined with run_length for column %s", c.OrigName)
+	}
+	return nil
+}
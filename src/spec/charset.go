@@ -0,0 +1,99 @@
+package spec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	digitChars = "0123456789"
+	lowerChars = "abcdefghijklmnopqrstuvwxyz"
+	upperChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+var charsetNames = map[string]Charset{
+	"ascii":   CharsetASCII,
+	"unicode": CharsetUnicode,
+	"hex":     CharsetHex,
+	"alnum":   CharsetAlnum,
+	"lower":   CharsetLower,
+	"upper":   CharsetUpper,
+}
+
+var charsetAlphabets = map[Charset]string{
+	CharsetASCII: validChar,
+	CharsetHex:   digitChars + "abcdef",
+	CharsetAlnum: digitChars + lowerChars + upperChars,
+	CharsetLower: lowerChars,
+	CharsetUpper: upperChars,
+}
+
+func (cs Charset) String() string {
+	for name, v := range charsetNames {
+		if v == cs {
+			return name
+		}
+	}
+	return "custom"
+}
+
+// setCharset parses the charset option: a named alphabet, or a quoted
+// literal set of characters like "xyz01".
+func (c *ColumnSpec) setCharset(v string) error {
+	if cs, ok := charsetNames[v]; ok {
+		c.Charset = cs
+		c.chars, c.charRunes = charsetAlphabets[cs], nil
+		return nil
+	}
+	if !strings.HasPrefix(v, `"`) {
+		return fmt.Errorf("invalid charset for column %s: %q, must be ascii, unicode, hex, alnum, lower, upper or a quoted set of characters", c.OrigName, v)
+	}
+	chars, err := strconv.Unquote(v)
+	if err != nil || !utf8.ValidString(chars) {
+		return fmt.Errorf("invalid charset for column %s: %s", c.OrigName, v)
+	}
+	if chars == "" {
+		return fmt.Errorf("charset for column %s must not be empty", c.OrigName)
+	}
+	c.Charset = CharsetCustom
+	c.chars, c.charRunes = chars, nil
+	if len(chars) != utf8.RuneCountInString(chars) {
+		// Multibyte characters are drawn by rune, the ASCII ones fill the
+		// bytes left over when the next rune doesn't fit.
+		c.charRunes = []rune(chars)
+		c.chars = strings.Map(func(r rune) rune {
+			if r < utf8.RuneSelf {
+				return r
+			}
+			return -1
+		}, chars)
+		if c.chars == "" {
+			c.chars = validChar
+		}
+	}
+	return nil
+}
+
+// alphabet returns the single-byte characters strings of the column are
+// drawn from.
+func (c *ColumnSpec) alphabet() string {
+	if c.chars == "" {
+		return validChar
+	}
+	return c.chars
+}
+
+// MayAppearInValues reports whether sep, e.g. a CSV separator, can show up
+// in unquoted generated values: random strings of the default charset and
+// formatted numbers, dates and times use only validChar and spaces. Custom
+// charsets, regex and set values are not considered.
+func MayAppearInValues(sep string) bool {
+	for _, r := range sep {
+		if r != ' ' && !strings.ContainsRune(validChar, r) {
+			return false
+		}
+	}
+	return true
+}
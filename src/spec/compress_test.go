@@ -0,0 +1,43 @@
+package spec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"math/rand"
+	"testing"
+)
+
+// gzipSize returns the gzip compressed size of the CSV lines of n values.
+func gzipSize(t *testing.T, c *ColumnSpec, n int) (raw, compressed int) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	rng := rand.New(rand.NewSource(1))
+	for i := range n {
+		line := GenerateSingleField(i, c, rng) + "\n"
+		raw += len(line)
+		if _, err := zw.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return raw, buf.Len()
+}
+
+func TestCompressRatio(t *testing.T) {
+	rawPlain, plain := gzipSize(t, testSpec(t, "s varchar(64) NOT NULL COMMENT 'min_length=64'"), 2000)
+	raw10, compressed10 := gzipSize(t, testSpec(t, "s varchar(64) NOT NULL COMMENT 'min_length=64, compress=10'"), 2000)
+	if rawPlain != raw10 {
+		t.Fatalf("compress changed the raw size from %d to %d bytes", rawPlain, raw10)
+	}
+	// 10% of every value stays random, so gzip gets it about ten times
+	// smaller than the fully random values.
+	if ratio := float64(compressed10) / float64(plain); ratio > 0.2 {
+		t.Errorf("compress=10 gzips to %d bytes, %.2f of the %d bytes without it", compressed10, ratio, plain)
+	}
+	if ratio := float64(compressed10) / float64(raw10); ratio > 0.15 {
+		t.Errorf("compress=10 gzips %d bytes to %d, ratio %.2f", raw10, compressed10, ratio)
+	}
+}
@@ -1,13 +1,16 @@
 package spec
 
 import (
+	"encoding/binary"
 	"fmt"
-	"log"
 	"math"
-	"math/big"
 	"math/rand"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
+
+	"dataWriter/src/util/logger"
 
 	"github.com/apache/arrow-go/v18/parquet"
 	"github.com/cznic/mathutil"
@@ -23,11 +26,11 @@ var fillA = func() []byte {
 	return buf
 }()
 
-func generateStringWithCompress(b []byte, length int, compress int, rng *rand.Rand) {
+func generateStringWithCompress(b []byte, length int, compress int, chars string, rng *rand.Rand) {
 	nonduplicateLength := length * compress / 100
 	rng.Read(b[:nonduplicateLength])
 	for i := range b[:nonduplicateLength] {
-		b[i] = validChar[int(b[i])%len(validChar)]
+		b[i] = chars[int(b[i])%len(chars)]
 	}
 
 	// The rest part is filled with duplicate 'a' to simulate compression
@@ -36,11 +39,64 @@ func generateStringWithCompress(b []byte, length int, compress int, rng *rand.Ra
 	}
 }
 
-func (c *ColumnSpec) generateNULL(rng *rand.Rand) bool {
+// generateUnicodeWithCompress is like generateStringWithCompress, but the
+// random part is valid UTF-8 drawn from runes. length is a byte budget; the
+// bytes left when the next rune doesn't fit are filled from the ASCII chars.
+func generateUnicodeWithCompress(b []byte, length int, compress int, runes []rune, chars string, rng *rand.Rand) {
+	nonduplicateLength := length * compress / 100
+	i := 0
+	for i < nonduplicateLength {
+		r := runes[rng.Intn(len(runes))]
+		if i+utf8.RuneLen(r) > nonduplicateLength {
+			break
+		}
+		i += utf8.EncodeRune(b[i:], r)
+	}
+	for ; i < nonduplicateLength; i++ {
+		b[i] = chars[rng.Intn(len(chars))]
+	}
+
+	for i := nonduplicateLength; i < length; {
+		i += copy(b[i:length], fillA)
+	}
+}
+
+// fillString fills b with length random characters of the column's charset,
+// or arbitrary bytes for binary columns.
+func (c *ColumnSpec) fillString(b []byte, length int, rng *rand.Rand) {
+	if c.IsBinary() {
+		rng.Read(b[:length])
+		return
+	}
+	switch {
+	case c.Charset == CharsetUnicode:
+		generateUnicodeWithCompress(b, length, c.Compress, unicodeChars, validChar, rng)
+	case c.charRunes != nil:
+		generateUnicodeWithCompress(b, length, c.Compress, c.charRunes, c.chars, rng)
+	default:
+		generateStringWithCompress(b, length, c.Compress, c.alphabet(), rng)
+	}
+}
+
+// NullValue is how NULL is written in CSV.
+const NullValue = "\\N"
+
+func (c *ColumnSpec) generateNULL(rowID int, rng *rand.Rand) bool {
+	if c.NullCount > 0 {
+		return c.isNullRow(rowID)
+	}
 	return rng.Intn(100) < c.NullPercent
 }
 
-func (c *ColumnSpec) generateBatchNull(length int, rng *rand.Rand) []bool {
+func (c *ColumnSpec) generateBatchNull(rowID, length int, rng *rand.Rand) []bool {
+	if c.NullCount > 0 {
+		null := make([]bool, length)
+		for i := range length {
+			null[i] = c.isNullRow(rowID + i)
+		}
+		return null
+	}
+
 	randomIndices := make([]byte, length)
 	rng.Read(randomIndices)
 
@@ -58,6 +114,41 @@ func mixRowID(rowID int) uint64 {
 	return x ^ (x >> 31)
 }
 
+// permuteBits maps x to another value of the same bit width. Multiplying by
+// an odd constant and xor-shifting are both bijections modulo 2^bits, so
+// distinct inputs within the width never collide.
+func permuteBits(x uint64, bits int) uint64 {
+	mask := uint64(1)<<bits - 1
+	x = (x * 0x9e3779b97f4a7c15) & mask
+	x ^= x >> (bits / 2)
+	return (x * 0xbf58476d1ce4e5b9) & mask
+}
+
+// generateGlobalUniqueInt returns a value that is unique for every global row ID.
+func (c *ColumnSpec) generateGlobalUniqueInt(rowID int) int {
+	if c.Order != NumericRandomOrder {
+		return rowID
+	}
+	if c.TypeLen >= 64 {
+		return int(mixRowID(rowID))
+	}
+
+	v := int(permuteBits(uint64(rowID), c.TypeLen))
+	if c.Signed {
+		v -= 1 << (c.TypeLen - 1)
+	}
+	return v
+}
+
+// uniqueStringFromRowID formats a UUID-shaped string whose first half is a
+// bijection of rowID, so distinct row IDs never collide.
+func uniqueStringFromRowID(rowID int) string {
+	var b uuid.UUID
+	binary.BigEndian.PutUint64(b[:8], mixRowID(rowID))
+	binary.BigEndian.PutUint64(b[8:], mixRowID(^rowID))
+	return b.String()
+}
+
 func (c *ColumnSpec) generatePartialOrderInt(rowID int) int {
 	const randPrefixMask = 31
 	randPrefix := int(mixRowID(rowID) & randPrefixMask)
@@ -72,6 +163,9 @@ func (c *ColumnSpec) generateGaussianInt(rng *rand.Rand) int {
 	randomFloat := (rng.Float64()-0.5)*2*float64(c.StdDev) + float64(c.Mean)
 	randomInt := int(math.Round(randomFloat))
 
+	if c.HasRange {
+		return int(mathutil.ClampInt64(int64(randomInt), c.MinValue, c.MaxValue))
+	}
 	if c.TypeLen == 64 {
 		return randomInt
 	}
@@ -87,7 +181,22 @@ func (c *ColumnSpec) generateGaussianInt(rng *rand.Rand) int {
 	return mathutil.Clamp(randomInt, lower, upper)
 }
 
+// generateRangeInt draws uniformly from [MinValue, MaxValue].
+func (c *ColumnSpec) generateRangeInt(rng *rand.Rand) int {
+	span := uint64(c.MaxValue) - uint64(c.MinValue) + 1
+	if span == 0 {
+		return int(rng.Uint64())
+	}
+	if span <= math.MaxInt64 {
+		return int(c.MinValue + rng.Int63n(int64(span)))
+	}
+	return int(c.MinValue + int64(rng.Uint64()%span))
+}
+
 func (c *ColumnSpec) generateRandomInt(rng *rand.Rand) int {
+	if c.HasRange {
+		return c.generateRangeInt(rng)
+	}
 	if c.TypeLen == 64 {
 		return rng.Int()
 	}
@@ -99,9 +208,37 @@ func (c *ColumnSpec) generateRandomInt(rng *rand.Rand) int {
 	return v
 }
 
+// pickSetIndex returns the set index used for a row.
+func (c *ColumnSpec) pickSetIndex(rowID int, setLen int, rng *rand.Rand) int {
+	if c.Order == CycleOrder {
+		return rowID % setLen
+	}
+	return c.pickRandomSetIndex(setLen, rng)
+}
+
+// pickRandomSetIndex draws a set index following the column's distribution.
+func (c *ColumnSpec) pickRandomSetIndex(setLen int, rng *rand.Rand) int {
+	if c.Dist == SetZipf {
+		return int(rand.NewZipf(rng, c.ZipfS, 1, uint64(setLen-1)).Uint64())
+	}
+	return rng.Intn(setLen)
+}
+
 func (c *ColumnSpec) generateInt(rowID int, rng *rand.Rand) int {
+	if c.Order == SequenceOrder {
+		return c.withGaps(c.sequenceValue(rowID))
+	}
 	if len(c.IntSet) > 0 {
-		return int(c.IntSet[rng.Intn(len(c.IntSet))])
+		return int(c.IntSet[c.pickSetIndex(rowID, len(c.IntSet), rng)])
+	}
+	if c.IsForeignKey {
+		return c.generateRangeInt(rng)
+	}
+	if c.IsUnique && c.UniqueScope == UniqueScopeGlobal {
+		return c.withGaps(c.generateGlobalUniqueInt(rowID))
+	}
+	if len(c.Histogram) > 0 {
+		return c.generateHistogramInt(rng)
 	}
 	if c.StdDev > 0 {
 		return c.generateGaussianInt(rng)
@@ -110,7 +247,7 @@ func (c *ColumnSpec) generateInt(rowID int, rng *rand.Rand) int {
 	switch c.Order {
 	case NumericTotalOrder:
 		if c.IsUnique {
-			return rowID
+			return c.withGaps(rowID)
 		}
 		return c.generateRandomInt(rng)
 	case NumericPartialOrder:
@@ -127,25 +264,118 @@ func (c *ColumnSpec) generateInt(rowID int, rng *rand.Rand) int {
 		}
 		return c.generateRandomInt(rng)
 	default:
-		log.Printf("Unsupported order: %d", c.Order)
+		logger.Warnf("Unsupported order: %d", c.Order)
 	}
 	return c.generateRandomInt(rng)
 }
 
-func (c *ColumnSpec) generateString(rng *rand.Rand) string {
+// maxWhitespacePad is the maximum number of whitespace characters added to
+// each side of a padded value.
+const maxWhitespacePad = 3
+
+// padWhitespace adds leading and/or trailing whitespace to WhitespacePercent
+// of the values. It draws from rng only when the option is set, so seeded
+// output of other columns is unchanged.
+func (c *ColumnSpec) padWhitespace(b []byte, rng *rand.Rand) []byte {
+	if c.WhitespacePercent == 0 || rng.Intn(100) >= c.WhitespacePercent {
+		return b
+	}
+
+	var leading, trailing int
+	switch rng.Intn(3) {
+	case 0:
+		leading = rng.Intn(maxWhitespacePad) + 1
+	case 1:
+		trailing = rng.Intn(maxWhitespacePad) + 1
+	default:
+		leading = rng.Intn(maxWhitespacePad) + 1
+		trailing = rng.Intn(maxWhitespacePad) + 1
+	}
+
+	padded := make([]byte, 0, leading+len(b)+trailing)
+	for range leading {
+		padded = append(padded, c.WhitespaceChars[rng.Intn(len(c.WhitespaceChars))])
+	}
+	padded = append(padded, b...)
+	for range trailing {
+		padded = append(padded, c.WhitespaceChars[rng.Intn(len(c.WhitespaceChars))])
+	}
+	return padded
+}
+
+func (c *ColumnSpec) generateString(rowID int, rng *rand.Rand) string {
+	s := c.stringValue(rowID, rng)
+	if c.WhitespacePercent > 0 {
+		return string(c.padWhitespace([]byte(s), rng))
+	}
+	return s
+}
+
+// generateSetValue returns a random subset of a SET column's elements, in
+// definition order and joined by commas like MySQL does. It can be empty.
+func (c *ColumnSpec) generateSetValue(rng *rand.Rand) string {
+	var b strings.Builder
+	for _, elem := range c.ValueSet {
+		if rng.Intn(2) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(elem)
+	}
+	return b.String()
+}
+
+// isSetSubset reports whether values are random subsets of ValueSet, which is
+// the case for SET columns unless they cycle through the elements.
+func (c *ColumnSpec) isSetSubset() bool {
+	return c.SQLType == "set" && c.Order != CycleOrder
+}
+
+// DistinctValues returns the number of distinct non-NULL values the column
+// draws from its set, enum values or dict_cardinality pool, or 0 when its
+// values are not bounded that way.
+func (c *ColumnSpec) DistinctValues() int {
+	switch {
+	case c.isSetSubset():
+		// SET values are subsets of the elements.
+		return 0
+	case len(c.IntSet) > 0:
+		return len(c.IntSet)
+	case len(c.ValueSet) > 0:
+		return len(c.ValueSet)
+	default:
+		return c.DictCardinality
+	}
+}
+
+func (c *ColumnSpec) generateRawString(rowID int, rng *rand.Rand) string {
+	if c.isSetSubset() {
+		return c.generateSetValue(rng)
+	}
 	if len(c.ValueSet) > 0 {
-		return c.ValueSet[rng.Intn(len(c.ValueSet))]
+		return c.ValueSet[c.pickSetIndex(rowID, len(c.ValueSet), rng)]
+	}
+	if c.IsUnique && c.UniqueScope == UniqueScopeGlobal {
+		return uniqueStringFromRowID(rowID)
+	}
+	if c.regex != nil {
+		return string(generateRegex(c.regex, nil, rng))
+	}
+	if c.Faker != FakerNone {
+		return c.generateFake(rng)
+	}
+	if c.StringFormat == StringFormatDecimal {
+		return c.generateDecimalText(rowID, rng)
 	}
 	if c.IsUnique {
-		return uuid.New().String()
+		return uuid.Must(uuid.NewRandomFromReader(rng)).String()
 	}
 
-	lower := c.MinLen
-	upper := c.TypeLen
-	length := rng.Intn(upper-lower+1) + lower
-
+	length := c.stringLength(rowID, rng)
 	b := make([]byte, length)
-	generateStringWithCompress(b, length, c.Compress, rng)
+	c.fillString(b, length, rng)
 	return string(hack.String(b))
 }
 
@@ -154,38 +384,96 @@ func (c *ColumnSpec) generateJSON(_ *rand.Rand) string {
 	return "[1,2,3,4,5]"
 }
 
-func (c *ColumnSpec) generateRandomTime(format string, rng *rand.Rand) string {
-	now := time.Now()
+// fractionLayouts maps a fractional seconds precision to its layout suffix.
+var fractionLayouts = [...]string{"", ".0", ".00", ".000", ".0000", ".00000", ".000000"}
 
-	oneYearAgo := now.AddDate(-1, 0, 0)
+// timeLayout appends the column's fractional seconds to a time layout, e.g.
+// "2006-01-02 15:04:05.000000" for datetime(6).
+func (c *ColumnSpec) timeLayout(layout string) string {
+	if c.FSP <= 0 || c.FSP >= len(fractionLayouts) {
+		return layout
+	}
+	return layout + fractionLayouts[c.FSP]
+}
 
-	randomDuration := time.Duration(rng.Int63n(int64(now.Sub(oneYearAgo))))
+// truncateToFSP drops the sub-second digits beyond the column's fsp, the same
+// digits the CSV layout from timeLayout leaves out.
+func (c *ColumnSpec) truncateToFSP(t time.Time) time.Time {
+	fsp := min(max(c.FSP, 0), 6)
+	unit := time.Second
+	for range fsp {
+		unit /= 10
+	}
+	return t.Truncate(unit)
+}
 
-	randomTime := oneYearAgo.Add(randomDuration)
+// timeWindow returns the [start, end) window of generated time values. A
+// missing side defaults to one year from the other, or to the year before
+// the reference time when neither is set.
+func (c *ColumnSpec) timeWindow() (time.Time, time.Time) {
+	start, end := c.DateStart, c.DateEnd
+	switch {
+	case start.IsZero() && end.IsZero():
+		end = c.runContext().ReferenceTime
+		start = end.AddDate(-1, 0, 0)
+	case start.IsZero():
+		start = end.AddDate(-1, 0, 0)
+	case end.IsZero():
+		end = start.AddDate(1, 0, 0)
+	}
+	return start, end
+}
 
-	return randomTime.Format(format)
+// generateTime draws a time uniformly from the column's window with
+// microsecond precision, the finest precision of both CSV and Parquet, or
+// following the column's time_profile.
+func (c *ColumnSpec) generateTime(rng *rand.Rand) time.Time {
+	start, end := c.timeWindow()
+	if c.TimeProfile == TimeBusinessHours {
+		return businessHoursTime(start, end, rng)
+	}
+	span := end.UnixMicro() - start.UnixMicro()
+	return time.UnixMicro(start.UnixMicro() + rng.Int63n(span)).In(start.Location())
+}
+
+func (c *ColumnSpec) generateRandomTime(format string, rng *rand.Rand) string {
+	return c.generateTime(rng).Format(format)
 }
 
 func (c *ColumnSpec) generate(rowID int, rng *rand.Rand) (any, int16) {
-	if c.generateNULL(rng) {
-		return "\\N", 0
+	if c.generateNULL(rowID, rng) {
+		return NullValue, 0
 	}
 
 	switch c.SQLType {
-	case "int", "tinyint", "smallint", "mediumint", "decimal":
-		return c.generateInt(rowID, rng), 1
-	case "bigint", "double", "float":
-		return c.generateInt(rowID, rng), 1
-	case "char", "varchar", "varbinary", "blob", "text", "tinyblob":
-		return c.generateString(rng), 1
+	case "int", "tinyint", "smallint", "mediumint":
+		return c.generateInt(c.valueSource(rowID, rng)), 1
+	case "decimal":
+		return c.generateDecimalString(rowID, rng), 1
+	case "double", "float":
+		if c.hasFloatDist() || c.FloatFormat != 0 {
+			v := c.generateFloat(c.valueSource(rowID, rng))
+			if c.FloatFormat != 0 {
+				return c.formatFloat(v), 1
+			}
+			if c.SQLType == "float" {
+				return float32(v), 1
+			}
+			return v, 1
+		}
+		return c.generateInt(c.valueSource(rowID, rng)), 1
+	case "bigint":
+		return c.generateInt(c.valueSource(rowID, rng)), 1
+	case "char", "varchar", "binary", "varbinary", "blob", "text", "tinyblob", "enum", "set":
+		return c.generateString(rowID, rng), 1
 	case "json":
 		return c.generateJSON(rng), 1
 	case "timestamp", "datetime":
-		return c.generateRandomTime(time.DateTime, rng), 1
+		return c.generateRandomTime(c.timeLayout(time.DateTime), rng), 1
 	case "date":
 		return c.generateRandomTime(time.DateOnly, rng), 1
 	case "time":
-		return c.generateRandomTime(time.TimeOnly, rng), 1
+		return c.formatTimeOfDay(c.generateTimeOfDay(rng)), 1
 	case "year":
 		return rng.Intn(70) + 1970, 1
 	}
@@ -193,43 +481,43 @@ func (c *ColumnSpec) generate(rowID int, rng *rand.Rand) (any, int16) {
 }
 
 func (c *ColumnSpec) generateInt64Parquet(rowID int, out []int64, defLevel []int16, rng *rand.Rand) {
-	nullMap := c.generateBatchNull(len(out), rng)
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
 	for i := range len(out) {
 		if nullMap[i] {
 			defLevel[i] = 0
 		} else {
 			defLevel[i] = 1
-			out[i] = int64(c.generateInt(rowID+i, rng))
+			out[i] = int64(c.generateInt(c.valueSource(rowID+i, rng)))
 		}
 	}
 }
 
-func (c *ColumnSpec) generateDecimalInt32Parquet(_ int, out []int32, defLevel []int16, rng *rand.Rand) {
-	nullMap := c.generateBatchNull(len(out), rng)
+func (c *ColumnSpec) generateDecimalInt32Parquet(rowID int, out []int32, defLevel []int16, rng *rand.Rand) {
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
 	for i := range len(out) {
 		if nullMap[i] {
 			defLevel[i] = 0
 		} else {
 			defLevel[i] = 1
-			out[i] = int32(c.generateRandomInt(rng))
+			out[i] = int32(c.generateDecimalInt64(rowID+i, rng))
 		}
 	}
 }
 
-func (c *ColumnSpec) generateDecimalInt64Parquet(_ int, out []int64, defLevel []int16, rng *rand.Rand) {
-	nullMap := c.generateBatchNull(len(out), rng)
+func (c *ColumnSpec) generateDecimalInt64Parquet(rowID int, out []int64, defLevel []int16, rng *rand.Rand) {
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
 	for i := range len(out) {
 		if nullMap[i] {
 			defLevel[i] = 0
 		} else {
 			defLevel[i] = 1
-			out[i] = int64(c.generateRandomInt(rng))
+			out[i] = c.generateDecimalInt64(rowID+i, rng)
 		}
 	}
 }
 
-func (c *ColumnSpec) generateDecimalFixedLenParquet(_ int, out []parquet.FixedLenByteArray, defLevel []int16, rng *rand.Rand) {
-	nullMap := c.generateBatchNull(len(out), rng)
+func (c *ColumnSpec) generateDecimalFixedLenParquet(rowID int, out []parquet.FixedLenByteArray, defLevel []int16, rng *rand.Rand) {
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
 	for i := range len(out) {
 		if nullMap[i] {
 			defLevel[i] = 0
@@ -237,81 +525,51 @@ func (c *ColumnSpec) generateDecimalFixedLenParquet(_ int, out []parquet.FixedLe
 		}
 		defLevel[i] = 1
 		if len(c.IntSet) > 0 {
-			out[i] = fixedLenDecimalFromInt64(c.IntSet[rng.Intn(len(c.IntSet))], c.TypeLen)
+			out[i] = fixedLenDecimalFromInt64(c.IntSet[c.pickSetIndex(rowID+i, len(c.IntSet), rng)], c.TypeLen)
 		} else {
-			out[i] = generateDecimalBytes(c.TypeLen, rng)
+			out[i] = fixedLenDecimalFromBig(c.generateDecimalBig(rowID+i, rng), c.TypeLen)
 		}
 	}
 }
 
-func fixedLenDecimalFromInt64(unscaled int64, byteLen int) parquet.FixedLenByteArray {
-	// Parquet DECIMAL in fixed-len byte array: two's-complement big-endian.
-	v := big.NewInt(unscaled)
-	b := v.Bytes()
-	if len(b) > byteLen {
-		b = b[len(b)-byteLen:]
-	}
-	padded := make([]byte, byteLen)
-	copy(padded[byteLen-len(b):], b)
-
-	if unscaled < 0 {
-		for i := 0; i < byteLen-len(b); i++ {
-			padded[i] = 0xFF
-		}
-	}
-
-	return padded
-}
-
-// generateDecimalBytes generates a fixed-length byte array for a decimal value.
-// Currently, we don't take precision into consideration and just fill with
-// random bytes.
-func generateDecimalBytes(byteLen int, rng *rand.Rand) parquet.FixedLenByteArray {
-	buf := make([]byte, byteLen)
-	fillLength := min(byteLen, 32)
-	rng.Read(buf[fillLength:])
-	return buf
-}
-
 func (c *ColumnSpec) generateInt32Parquet(rowID int, out []int32, defLevel []int16, rng *rand.Rand) {
-	nullMap := c.generateBatchNull(len(out), rng)
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
 	for i := range len(out) {
 		if nullMap[i] {
 			defLevel[i] = 0
 		} else {
 			defLevel[i] = 1
-			out[i] = int32(c.generateInt(rowID+i, rng))
+			out[i] = int32(c.generateInt(c.valueSource(rowID+i, rng)))
 		}
 	}
 }
 
-// generateFloatParquet is same as generateInt64Parquet for now
 func (c *ColumnSpec) generateFloat64Parquet(rowID int, out []float64, defLevel []int16, rng *rand.Rand) {
-	nullMap := c.generateBatchNull(len(out), rng)
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
 	for i := range len(out) {
 		if nullMap[i] {
 			defLevel[i] = 0
 		} else {
 			defLevel[i] = 1
-			out[i] = float64(c.generateInt(rowID, rng)) + 0.1
+			out[i] = c.generateFloat(c.valueSource(rowID+i, rng))
 		}
 	}
 }
 
 func (c *ColumnSpec) generateFloat32Parquet(rowID int, out []float32, defLevel []int16, rng *rand.Rand) {
-	nullMap := c.generateBatchNull(len(out), rng)
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
 	for i := range len(out) {
 		if nullMap[i] {
 			defLevel[i] = 0
 		} else {
 			defLevel[i] = 1
-			out[i] = float32(c.generateInt(rowID, rng)) + 0.1
+			out[i] = float32(c.generateFloat(c.valueSource(rowID+i, rng)))
 		}
 	}
 }
 
-func (c *ColumnSpec) generateYearParquet(out []int32, defLevel []int16, rng *rand.Rand) {
-	nullMap := c.generateBatchNull(len(out), rng)
+func (c *ColumnSpec) generateYearParquet(rowID int, out []int32, defLevel []int16, rng *rand.Rand) {
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
 	for i := range len(out) {
 		if nullMap[i] {
 			defLevel[i] = 0
@@ -322,33 +580,50 @@ func (c *ColumnSpec) generateYearParquet(out []int32, defLevel []int16, rng *ran
 	}
 }
 
-func (c *ColumnSpec) generateTimestampParquet(out []int64, defLevel []int16, rng *rand.Rand) {
-	nullMap := c.generateBatchNull(len(out), rng)
+func (c *ColumnSpec) generateTimestampParquet(rowID int, out []int64, defLevel []int16, rng *rand.Rand) {
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
+	for i := range len(out) {
+		if nullMap[i] {
+			defLevel[i] = 0
+		} else {
+			defLevel[i] = 1
+			out[i] = c.timestampInt64(c.truncateToFSP(c.generateTime(rng)))
+		}
+	}
+}
+
+func (c *ColumnSpec) generateInt96Parquet(rowID int, out []parquet.Int96, defLevel []int16, rng *rand.Rand) {
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
 	for i := range len(out) {
 		if nullMap[i] {
 			defLevel[i] = 0
 		} else {
 			defLevel[i] = 1
-			// Random timestamp in the range of 0 to 50 years
-			out[i] = rng.Int63() % 1576800000000000
+			out[i] = Int96FromTime(c.truncateToFSP(c.generateTime(rng)))
 		}
 	}
 }
 
-func (c *ColumnSpec) generateDateParquet(out []int32, defLevel []int16, rng *rand.Rand) {
-	nullMap := c.generateBatchNull(len(out), rng)
+// daysSinceEpoch returns the Parquet DATE value of the calendar day of t.
+func daysSinceEpoch(t time.Time) int32 {
+	y, m, d := t.Date()
+	return int32(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+func (c *ColumnSpec) generateDateParquet(rowID int, out []int32, defLevel []int16, rng *rand.Rand) {
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
 	for i := range len(out) {
 		if nullMap[i] {
 			defLevel[i] = 0
 		} else {
 			defLevel[i] = 1
-			out[i] = rng.Int31() & 16383
+			out[i] = daysSinceEpoch(c.generateTime(rng))
 		}
 	}
 }
 
-func (c *ColumnSpec) generateJSONParquet(_ int, out []parquet.ByteArray, defLevel []int16, rng *rand.Rand) {
-	nullMap := c.generateBatchNull(len(out), rng)
+func (c *ColumnSpec) generateJSONParquet(rowID int, out []parquet.ByteArray, defLevel []int16, rng *rand.Rand) {
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
 	for i := range len(out) {
 		if nullMap[i] {
 			defLevel[i] = 0
@@ -359,8 +634,44 @@ func (c *ColumnSpec) generateJSONParquet(_ int, out []parquet.ByteArray, defLeve
 	}
 }
 
-func (c *ColumnSpec) generateStringParquet(_ int, out []parquet.ByteArray, defLevel []int16, rng *rand.Rand) {
-	nullMap := c.generateBatchNull(len(out), rng)
+func (c *ColumnSpec) generateStringParquet(rowID int, out []parquet.ByteArray, defLevel []int16, rng *rand.Rand) {
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
+
+	if c.IsUnique && c.UniqueScope == UniqueScopeGlobal && len(c.ValueSet) == 0 && c.DupKeyPercent == 0 && c.CaseVariantsPercent == 0 {
+		for i := range len(out) {
+			if nullMap[i] {
+				defLevel[i] = 0
+				continue
+			}
+			defLevel[i] = 1
+			out[i] = []byte(uniqueStringFromRowID(rowID + i))
+		}
+		return
+	}
+
+	if c.RunLength > 1 || c.DupKeyPercent > 0 || c.CaseVariantsPercent > 0 || c.DictCardinality > 0 {
+		for i := range len(out) {
+			if nullMap[i] {
+				defLevel[i] = 0
+				continue
+			}
+			defLevel[i] = 1
+			out[i] = []byte(c.stringValue(rowID+i, rng))
+		}
+		return
+	}
+
+	if c.isSetSubset() {
+		for i := range len(out) {
+			if nullMap[i] {
+				defLevel[i] = 0
+				continue
+			}
+			defLevel[i] = 1
+			out[i] = []byte(c.generateSetValue(rng))
+		}
+		return
+	}
 
 	if len(c.ValueSet) > 0 {
 		for i := range len(out) {
@@ -369,27 +680,68 @@ func (c *ColumnSpec) generateStringParquet(_ int, out []parquet.ByteArray, defLe
 				continue
 			}
 			defLevel[i] = 1
-			out[i] = []byte(c.ValueSet[rng.Intn(len(c.ValueSet))])
+			out[i] = []byte(c.ValueSet[c.pickSetIndex(rowID+i, len(c.ValueSet), rng)])
 		}
 		return
 	}
 
-	lower := c.MinLen
-	upper := c.TypeLen
-	slen := rng.Intn(upper-lower+1) + lower
+	if c.regex != nil {
+		for i := range len(out) {
+			if nullMap[i] {
+				defLevel[i] = 0
+				continue
+			}
+			defLevel[i] = 1
+			out[i] = generateRegex(c.regex, nil, rng)
+		}
+		return
+	}
 
-	buf := make([]byte, slen*len(out))
-	for i := range out {
-		generateStringWithCompress(buf[slen*i:slen*i+slen], slen, c.Compress, rng)
+	if c.Faker != FakerNone {
+		for i := range len(out) {
+			if nullMap[i] {
+				defLevel[i] = 0
+				continue
+			}
+			defLevel[i] = 1
+			out[i] = []byte(c.generateFake(rng))
+		}
+		return
+	}
+
+	if c.StringFormat == StringFormatDecimal {
+		for i := range len(out) {
+			if nullMap[i] {
+				defLevel[i] = 0
+				continue
+			}
+			defLevel[i] = 1
+			out[i] = []byte(c.generateDecimalText(rowID+i, rng))
+		}
+		return
 	}
 
+	// Every row draws its own length like the CSV path does, the values
+	// share one buffer.
+	lens := make([]int, len(out))
+	total := 0
+	for i := range len(out) {
+		if !nullMap[i] {
+			lens[i] = c.stringLength(rowID+i, rng)
+			total += lens[i]
+		}
+	}
+	buf := make([]byte, total)
 	for i := range len(out) {
 		if nullMap[i] {
 			defLevel[i] = 0
-		} else {
-			defLevel[i] = 1
-			out[i] = buf[i*slen : (i+1)*slen]
+			continue
 		}
+		defLevel[i] = 1
+		b := buf[:lens[i]:lens[i]]
+		buf = buf[lens[i]:]
+		c.fillString(b, len(b), rng)
+		out[i] = b
 	}
 }
 
@@ -443,12 +795,19 @@ func (c *ColumnSpec) FillParquetBatch(rowID int, valueBuffer any, defLevel []int
 			return fmt.Errorf("unexpected buffer type for double: %T", valueBuffer)
 		}
 		c.generateFloat64Parquet(rowID, buf, defLevel, rng)
-	case "varchar", "char", "blob", "tinyblob":
+	case "varchar", "char", "binary", "varbinary", "blob", "tinyblob", "enum", "set":
 		buf, ok := valueBuffer.([]parquet.ByteArray)
 		if !ok {
 			return fmt.Errorf("unexpected buffer type for string: %T", valueBuffer)
 		}
 		c.generateStringParquet(rowID, buf, defLevel, rng)
+		if c.WhitespacePercent > 0 {
+			for i := range buf {
+				if defLevel[i] == 1 {
+					buf[i] = c.padWhitespace(buf[i], rng)
+				}
+			}
+		}
 	case "json":
 		buf, ok := valueBuffer.([]parquet.ByteArray)
 		if !ok {
@@ -460,19 +819,41 @@ func (c *ColumnSpec) FillParquetBatch(rowID int, valueBuffer any, defLevel []int
 		if !ok {
 			return fmt.Errorf("unexpected buffer type for date: %T", valueBuffer)
 		}
-		c.generateDateParquet(buf, defLevel, rng)
-	case "timestamp", "datetime", "time":
+		c.generateDateParquet(rowID, buf, defLevel, rng)
+	case "time":
+		if c.TimestampUnit == TimestampMillis {
+			buf, ok := valueBuffer.([]int32)
+			if !ok {
+				return fmt.Errorf("unexpected buffer type for time: %T", valueBuffer)
+			}
+			c.generateTimeOfDayMillisParquet(rowID, buf, defLevel, rng)
+			break
+		}
 		buf, ok := valueBuffer.([]int64)
 		if !ok {
 			return fmt.Errorf("unexpected buffer type for time: %T", valueBuffer)
 		}
-		c.generateTimestampParquet(buf, defLevel, rng)
+		c.generateTimeOfDayParquet(rowID, buf, defLevel, rng)
+	case "timestamp", "datetime":
+		if c.TimestampUnit == TimestampInt96 {
+			buf, ok := valueBuffer.([]parquet.Int96)
+			if !ok {
+				return fmt.Errorf("unexpected buffer type for int96 time: %T", valueBuffer)
+			}
+			c.generateInt96Parquet(rowID, buf, defLevel, rng)
+			break
+		}
+		buf, ok := valueBuffer.([]int64)
+		if !ok {
+			return fmt.Errorf("unexpected buffer type for timestamp: %T", valueBuffer)
+		}
+		c.generateTimestampParquet(rowID, buf, defLevel, rng)
 	case "year":
 		buf, ok := valueBuffer.([]int32)
 		if !ok {
 			return fmt.Errorf("unexpected buffer type for year: %T", valueBuffer)
 		}
-		c.generateYearParquet(buf, defLevel, rng)
+		c.generateYearParquet(rowID, buf, defLevel, rng)
 	default:
 		return fmt.Errorf("unsupported column writer type: %s", c.SQLType)
 	}
@@ -482,7 +863,21 @@ func (c *ColumnSpec) FillParquetBatch(rowID int, valueBuffer any, defLevel []int
 
 // GenerateSingleField returns the string representation of a generated column value.
 func GenerateSingleField(rowID int, spec *ColumnSpec, rng *rand.Rand) string {
+	v, defLevel := spec.generate(rowID, rng)
+	if spec.NumberFormat != NumberFormatNone && spec.StringFormat == StringFormatNone && defLevel > 0 {
+		return spec.NumberFormat.apply(formatField(v))
+	}
+	return formatField(v)
+}
+
+// GenerateRawField is GenerateSingleField without number_format, the value
+// Parquet files hold.
+func GenerateRawField(rowID int, spec *ColumnSpec, rng *rand.Rand) string {
 	v, _ := spec.generate(rowID, rng)
+	return formatField(v)
+}
+
+func formatField(v any) string {
 	switch val := v.(type) {
 	case string:
 		return val
@@ -1,7 +1,11 @@
 package spec
 
 import (
+	"encoding/binary"
 	"math/big"
+	"math/rand"
+	"strconv"
+	"strings"
 
 	"github.com/apache/arrow-go/v18/parquet"
 )
@@ -27,3 +31,176 @@ func decimalMaxDigitsBits(precision int) int {
 	pow10.Sub(pow10, big.NewInt(1))
 	return pow10.BitLen()
 }
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// initDecimalBound computes the exclusive upper bound of the unscaled value
+// magnitude, limited by both the precision and the decimal_range option.
+func (c *ColumnSpec) initDecimalBound() {
+	bound := pow10(c.Precision)
+	if c.DecimalRange > 0 {
+		r := new(big.Float).SetFloat64(c.DecimalRange)
+		r.Mul(r, new(big.Float).SetInt(pow10(c.Scale)))
+		if rb, _ := r.Int(nil); rb.Sign() > 0 && rb.Cmp(bound) < 0 {
+			bound = rb
+		}
+	}
+	c.unscaledBound = bound
+}
+
+// roundRat rounds r to an integer with the given mode.
+func roundRat(r *big.Rat, mode Rounding) *big.Int {
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if mode == RoundTruncate || m.Sign() == 0 {
+		return q
+	}
+
+	// Compare the dropped fraction |m/denom| with 1/2.
+	cmp := new(big.Int).Lsh(new(big.Int).Abs(m), 1).Cmp(r.Denom())
+	if cmp < 0 || (cmp == 0 && mode == RoundHalfEven && q.Bit(0) == 0) {
+		return q
+	}
+	if m.Sign() > 0 {
+		return q.Add(q, big.NewInt(1))
+	}
+	return q.Sub(q, big.NewInt(1))
+}
+
+// scaleDecimal converts v into an unscaled value with the column's scale and
+// rounding mode. v is taken as its shortest decimal representation, so e.g.
+// 1.005 is exactly halfway at scale 2.
+func (c *ColumnSpec) scaleDecimal(v float64) *big.Int {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(v, 'g', -1, 64))
+	r.Mul(r, new(big.Rat).SetInt(pow10(c.Scale)))
+	return roundRat(r, c.Rounding)
+}
+
+// generateDecimalFromDistribution draws a value around mean within stddev,
+// like generateGaussianInt, clamped to the column's magnitude bound.
+func (c *ColumnSpec) generateDecimalFromDistribution(rng *rand.Rand) *big.Int {
+	v := (rng.Float64()-0.5)*2*float64(c.StdDev) + float64(c.Mean)
+	unscaled := c.scaleDecimal(v)
+
+	limit := new(big.Int).Sub(c.unscaledBound, big.NewInt(1))
+	if unscaled.Cmp(limit) > 0 {
+		return limit
+	}
+	if limit.Neg(limit); unscaled.Cmp(limit) < 0 {
+		return limit
+	}
+	return unscaled
+}
+
+// generateDecimalInt64 returns a random unscaled value for precision <= 18,
+// negative half of the time.
+func (c *ColumnSpec) generateDecimalInt64(rowID int, rng *rand.Rand) int64 {
+	if c.DecimalMode == DecimalRunningBalance {
+		return c.runningBalance(rowID)
+	}
+	if len(c.IntSet) > 0 {
+		return c.IntSet[c.pickSetIndex(rowID, len(c.IntSet), rng)]
+	}
+	if len(c.Histogram) > 0 {
+		return c.generateHistogramDecimal(rng).Int64()
+	}
+	if c.StdDev > 0 {
+		return c.generateDecimalFromDistribution(rng).Int64()
+	}
+	v := rng.Int63n(c.unscaledBound.Int64())
+	if rng.Intn(2) == 0 {
+		v = -v
+	}
+	return v
+}
+
+// generateDecimalBig returns a random unscaled value of any precision,
+// negative half of the time.
+func (c *ColumnSpec) generateDecimalBig(rowID int, rng *rand.Rand) *big.Int {
+	if c.DecimalMode == DecimalRunningBalance {
+		return big.NewInt(c.runningBalance(rowID))
+	}
+	if len(c.IntSet) > 0 {
+		return big.NewInt(c.IntSet[c.pickSetIndex(rowID, len(c.IntSet), rng)])
+	}
+	if len(c.Histogram) > 0 {
+		return c.generateHistogramDecimal(rng)
+	}
+	if c.StdDev > 0 {
+		return c.generateDecimalFromDistribution(rng)
+	}
+	v := new(big.Int).Rand(rng, c.unscaledBound)
+	if rng.Intn(2) == 0 {
+		v.Neg(v)
+	}
+	return v
+}
+
+// generateDecimalString returns a random decimal formatted with its scale.
+func (c *ColumnSpec) generateDecimalString(rowID int, rng *rand.Rand) string {
+	if c.Precision <= 18 {
+		return FormatDecimal(strconv.FormatInt(c.generateDecimalInt64(rowID, rng), 10), c.Scale)
+	}
+	return FormatDecimal(c.generateDecimalBig(rowID, rng).String(), c.Scale)
+}
+
+// FormatDecimal inserts the decimal point into an unscaled integer string.
+func FormatDecimal(unscaled string, scale int) string {
+	if scale <= 0 {
+		return unscaled
+	}
+
+	neg := strings.HasPrefix(unscaled, "-")
+	digits := strings.TrimPrefix(unscaled, "-")
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+
+	var b strings.Builder
+	b.Grow(len(digits) + 2)
+	if neg {
+		b.WriteByte('-')
+	}
+	b.WriteString(digits[:len(digits)-scale])
+	b.WriteByte('.')
+	b.WriteString(digits[len(digits)-scale:])
+	return b.String()
+}
+
+// fixedLenDecimalFromInt64 encodes an unscaled value as a Parquet DECIMAL
+// fixed-len byte array: two's-complement big-endian.
+func fixedLenDecimalFromInt64(unscaled int64, byteLen int) parquet.FixedLenByteArray {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(unscaled))
+
+	out := make([]byte, byteLen)
+	if byteLen <= len(b) {
+		copy(out, b[len(b)-byteLen:])
+		return out
+	}
+
+	if unscaled < 0 {
+		for i := range out[:byteLen-len(b)] {
+			out[i] = 0xFF
+		}
+	}
+	copy(out[byteLen-len(b):], b[:])
+	return out
+}
+
+// fixedLenDecimalFromBig is fixedLenDecimalFromInt64 for big unscaled values,
+// which must fit into byteLen bytes.
+func fixedLenDecimalFromBig(unscaled *big.Int, byteLen int) parquet.FixedLenByteArray {
+	out := make([]byte, byteLen)
+	if unscaled.Sign() >= 0 {
+		unscaled.FillBytes(out)
+		return out
+	}
+
+	// Two's complement of a negative value is 2^(8*byteLen) + value.
+	v := new(big.Int).Lsh(big.NewInt(1), uint(8*byteLen))
+	v.Add(v, unscaled)
+	v.FillBytes(out)
+	return out
+}
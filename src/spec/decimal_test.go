@@ -0,0 +1,156 @@
+package spec
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/parquet"
+)
+
+func TestFormatDecimal(t *testing.T) {
+	cases := []struct {
+		unscaled string
+		scale    int
+		want     string
+	}{
+		{"12345", 2, "123.45"},
+		{"-12345", 2, "-123.45"},
+		{"5", 0, "5"},
+		{"5", 3, "0.005"},
+		{"-5", 3, "-0.005"},
+		{"123", 3, "0.123"},
+		{"-123", 3, "-0.123"},
+		{"0", 2, "0.00"},
+		{"123456789012345678901234567890", 10, "12345678901234567890.1234567890"},
+	}
+	for _, tc := range cases {
+		if got := FormatDecimal(tc.unscaled, tc.scale); got != tc.want {
+			t.Errorf("FormatDecimal(%q, %d) = %q, want %q", tc.unscaled, tc.scale, got, tc.want)
+		}
+	}
+}
+
+func TestRoundRat(t *testing.T) {
+	cases := []struct {
+		num, denom int64
+		mode       Rounding
+		want       int64
+	}{
+		{5, 2, RoundHalfEven, 2},
+		{7, 2, RoundHalfEven, 4},
+		{-5, 2, RoundHalfEven, -2},
+		{-7, 2, RoundHalfEven, -4},
+		{5, 2, RoundHalfUp, 3},
+		{-5, 2, RoundHalfUp, -3},
+		{5, 2, RoundTruncate, 2},
+		{-5, 2, RoundTruncate, -2},
+		{26, 10, RoundHalfEven, 3},
+		{24, 10, RoundHalfUp, 2},
+		{-26, 10, RoundTruncate, -2},
+		{-26, 10, RoundHalfUp, -3},
+		{6, 1, RoundHalfEven, 6},
+	}
+	for _, tc := range cases {
+		got := roundRat(big.NewRat(tc.num, tc.denom), tc.mode)
+		if got.Int64() != tc.want {
+			t.Errorf("roundRat(%d/%d, %d) = %s, want %d", tc.num, tc.denom, tc.mode, got, tc.want)
+		}
+	}
+}
+
+func TestFixedLenDecimal(t *testing.T) {
+	cases := []struct {
+		unscaled int64
+		byteLen  int
+		want     []byte
+	}{
+		{1, 4, []byte{0, 0, 0, 1}},
+		{-1, 4, []byte{0xFF, 0xFF, 0xFF, 0xFF}},
+		{256, 2, []byte{1, 0}},
+		{-256, 2, []byte{0xFF, 0}},
+		{1, 12, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}},
+		{-2, 12, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE}},
+		{-1 << 40, 10, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0, 0}},
+	}
+	for _, tc := range cases {
+		if got := fixedLenDecimalFromInt64(tc.unscaled, tc.byteLen); !bytes.Equal(got, tc.want) {
+			t.Errorf("fixedLenDecimalFromInt64(%d, %d) = %x, want %x", tc.unscaled, tc.byteLen, got, tc.want)
+		}
+		if got := fixedLenDecimalFromBig(big.NewInt(tc.unscaled), tc.byteLen); !bytes.Equal(got, tc.want) {
+			t.Errorf("fixedLenDecimalFromBig(%d, %d) = %x, want %x", tc.unscaled, tc.byteLen, got, tc.want)
+		}
+	}
+
+	// Values beyond int64 round trip through two's complement.
+	_, byteLen := deduceTypeForDecimal(38)
+	for _, s := range []string{"99999999999999999999999999999999999999", "-99999999999999999999999999999999999999", "-9223372036854775809"} {
+		v, _ := new(big.Int).SetString(s, 10)
+		b := fixedLenDecimalFromBig(v, byteLen)
+		if len(b) != byteLen {
+			t.Fatalf("%s encoded into %d bytes, want %d", s, len(b), byteLen)
+		}
+		back := new(big.Int).SetBytes(b)
+		if b[0]&0x80 != 0 {
+			back.Sub(back, new(big.Int).Lsh(big.NewInt(1), uint(8*byteLen)))
+		}
+		if back.Cmp(v) != 0 {
+			t.Errorf("%s decoded back as %s", s, back)
+		}
+	}
+}
+
+func TestDecimalRoundingAtHalf(t *testing.T) {
+	cases := []struct {
+		rounding string
+		values   map[float64]string
+	}{
+		{"half_even", map[float64]string{1.005: "1.00", 1.015: "1.02", 2.125: "2.12", -1.005: "-1.00", -1.015: "-1.02"}},
+		{"half_up", map[float64]string{1.005: "1.01", 1.015: "1.02", 2.125: "2.13", -1.005: "-1.01", -1.015: "-1.02"}},
+		{"truncate", map[float64]string{1.005: "1.00", 1.019: "1.01", 2.125: "2.12", -1.005: "-1.00", -1.019: "-1.01"}},
+	}
+	for _, tc := range cases {
+		c := testSpec(t, "d decimal(10,2) COMMENT 'rounding="+tc.rounding+"'")
+		for v, want := range tc.values {
+			if got := FormatDecimal(c.scaleDecimal(v).String(), c.Scale); got != want {
+				t.Errorf("rounding=%s: %v = %s, want %s", tc.rounding, v, got, want)
+			}
+			// CSV values converted to Parquet are rounded the same way.
+			parsed, err := c.parseDecimal(strconv.FormatFloat(v, 'f', -1, 64))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := FormatDecimal(strconv.FormatInt(parsed.(int64), 10), c.Scale); got != want {
+				t.Errorf("rounding=%s: parsed %v = %s, want %s", tc.rounding, v, got, want)
+			}
+		}
+	}
+}
+
+func TestDecimalSetCycle(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, typ := range []string{"decimal(10,2)", "decimal(30,2)"} {
+		c := testSpec(t, "d "+typ+" COMMENT 'set=[150,225,300],order=cycle'")
+		for rowID := range 6 {
+			want := c.IntSet[rowID%len(c.IntSet)]
+			if got := c.generateDecimalBig(rowID, rng).Int64(); got != want {
+				t.Errorf("%s row %d: got %d, want %d", typ, rowID, got, want)
+			}
+		}
+		out := make([]parquet.FixedLenByteArray, 6)
+		c.generateDecimalFixedLenParquet(0, out, make([]int16, 6), rng)
+		for rowID, v := range out {
+			if want := fixedLenDecimalFromInt64(c.IntSet[rowID%len(c.IntSet)], c.TypeLen); !bytes.Equal(v, want) {
+				t.Errorf("%s Parquet row %d: got %x, want %x", typ, rowID, v, want)
+			}
+		}
+	}
+	c := testSpec(t, "d decimal(10,2) COMMENT 'set=[150,225,300],order=cycle'")
+	for rowID := range 6 {
+		if got, want := c.generateDecimalInt64(rowID, rng), c.IntSet[rowID%len(c.IntSet)]; got != want {
+			t.Errorf("row %d: got %d, want %d", rowID, got, want)
+		}
+	}
+}
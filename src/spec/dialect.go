@@ -0,0 +1,115 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// rewriteRule rewrites a vendor-specific construct into MySQL syntax.
+type rewriteRule struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+func rule(pattern, repl string) rewriteRule {
+	return rewriteRule{re: regexp.MustCompile(pattern), repl: repl}
+}
+
+var postgresRules = []rewriteRule{
+	rule(`(?i)\bBIGSERIAL\b`, "BIGINT AUTO_INCREMENT"),
+	rule(`(?i)\bSMALLSERIAL\b`, "SMALLINT AUTO_INCREMENT"),
+	rule(`(?i)\bSERIAL\b`, "INT AUTO_INCREMENT"),
+	rule(`(?i)\bGENERATED\s+(ALWAYS|BY\s+DEFAULT)\s+AS\s+IDENTITY(\s*\([^)]*\))?`, "AUTO_INCREMENT"),
+	rule(`(?i)\bTIMESTAMPTZ\b`, "TIMESTAMP"),
+	rule(`(?i)\bTIMESTAMP\s+WITH(OUT)?\s+TIME\s+ZONE\b`, "TIMESTAMP"),
+	rule(`(?i)\bBYTEA\b`, "BLOB"),
+	rule(`(?i)\bJSONB\b`, "JSON"),
+	rule(`(?i)\bUUID\b`, "CHAR(36)"),
+}
+
+var sqlServerRules = []rewriteRule{
+	rule(`\[([^\]]+)\]`, "`$1`"),
+	rule(`(?i)\bIDENTITY\s*(\(\s*\d+\s*,\s*\d+\s*\))?`, "AUTO_INCREMENT"),
+	rule(`(?i)\bN?VARCHAR\s*\(\s*MAX\s*\)`, "LONGTEXT"),
+	rule(`(?i)\bVARBINARY\s*\(\s*MAX\s*\)`, "LONGBLOB"),
+	rule(`(?i)\bNVARCHAR\b`, "VARCHAR"),
+	rule(`(?i)\bNCHAR\b`, "CHAR"),
+	rule(`(?i)\bNTEXT\b`, "TEXT"),
+	rule(`(?i)\b(DATETIME2|DATETIMEOFFSET|SMALLDATETIME)\b`, "DATETIME"),
+	rule(`(?i)\bUNIQUEIDENTIFIER\b`, "CHAR(36)"),
+	rule(`(?i)\bBIT\b`, "TINYINT(1)"),
+	rule(`(?i)\bMONEY\b`, "DECIMAL(19,4)"),
+	rule(`(?i)\b(NON)?CLUSTERED\b`, ""),
+}
+
+var sqliteRules = []rewriteRule{
+	rule(`(?i)\bAUTOINCREMENT\b`, "AUTO_INCREMENT"),
+}
+
+// dialectRules returns the rewrite rules for a sql_dialect hint. An empty
+// dialect means MySQL and needs no rewriting.
+func dialectRules(dialect string) ([]rewriteRule, error) {
+	switch strings.ToLower(strings.TrimSpace(dialect)) {
+	case "", "mysql", "tidb":
+		return nil, nil
+	case "postgres", "postgresql":
+		return postgresRules, nil
+	case "sqlserver", "mssql":
+		return sqlServerRules, nil
+	case "sqlite":
+		return sqliteRules, nil
+	default:
+		return nil, fmt.Errorf("unsupported sql dialect: %q", dialect)
+	}
+}
+
+// allDialectRules is used to retry parsing when no dialect is given.
+var allDialectRules = func() []rewriteRule {
+	// Postgres goes first since its IDENTITY clause is longer than SQL Server's.
+	rules := append([]rewriteRule{}, postgresRules...)
+	rules = append(rules, sqlServerRules...)
+	return append(rules, sqliteRules...)
+}()
+
+// rewriteDialect applies rules to the SQL outside of quoted strings and
+// identifiers, so comments like 'set=["a"]' are never touched.
+func rewriteDialect(sql string, rules []rewriteRule) string {
+	for _, r := range rules {
+		sql = rewriteOutsideQuotes(sql, r)
+	}
+	return sql
+}
+
+func rewriteOutsideQuotes(sql string, r rewriteRule) string {
+	var (
+		b     strings.Builder
+		start int
+		quote byte
+	)
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
+		switch {
+		case quote == 0 && (ch == '\'' || ch == '"' || ch == '`'):
+			b.WriteString(r.re.ReplaceAllString(sql[start:i], r.repl))
+			start = i
+			quote = ch
+		case quote != 0 && ch == '\\':
+			i++
+		case quote != 0 && ch == quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			b.WriteString(sql[start : i+1])
+			start = i + 1
+			quote = 0
+		}
+	}
+	if quote != 0 {
+		b.WriteString(sql[start:])
+	} else {
+		b.WriteString(r.re.ReplaceAllString(sql[start:], r.repl))
+	}
+	return b.String()
+}
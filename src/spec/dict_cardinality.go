@@ -0,0 +1,125 @@
+package spec
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+)
+
+// maxDictKeyTries bounds how many candidates are drawn for each value of a
+// dict_cardinality pool before the column is deemed too narrow.
+const maxDictKeyTries = 100
+
+// dictKey returns the pool key whose value rowID gets. Any DictCardinality
+// consecutive rows use every key once, in an order shuffled per column.
+func (c *ColumnSpec) dictKey(rowID int) int {
+	k := uint64(c.DictCardinality)
+	i := (c.dictMul*uint64(rowID%c.DictCardinality) + c.dictAdd) % k
+	return c.dictKeys[i]
+}
+
+// dictSource returns the random source of the value of pool key key.
+func (c *ColumnSpec) dictSource(key int) (int, *rand.Rand) {
+	return key, rand.New(&splitmixSource{state: c.runContext().Seed ^ c.runSalt ^ (uint64(key) * 0x9e3779b97f4a7c15)})
+}
+
+// dictReprs returns the values of key as written to CSV and Parquet, which
+// differ for float columns.
+func (c *ColumnSpec) dictReprs(key int) []string {
+	switch {
+	case isIntegerType(c.SQLType):
+		return []string{strconv.Itoa(c.generateInt(c.dictSource(key)))}
+	case c.SQLType == "float", c.SQLType == "double":
+		f := c.generateFloat(c.dictSource(key))
+		if c.SQLType == "float" {
+			f = float64(float32(f))
+		}
+		if c.FloatFormat != 0 {
+			return []string{c.formatFloat(f), strconv.FormatFloat(f, 'g', -1, 64)}
+		}
+		if c.hasFloatDist() {
+			return []string{strconv.FormatFloat(f, 'g', -1, 64)}
+		}
+		return []string{strconv.Itoa(c.generateInt(c.dictSource(key))), strconv.FormatFloat(f, 'g', -1, 64)}
+	default:
+		return []string{c.generateRawString(c.dictSource(key))}
+	}
+}
+
+// initDictPool picks the keys of the dict_cardinality pool, skipping keys
+// whose value repeats an earlier one, so the pool holds exactly
+// DictCardinality distinct values. It is called by SetRunContext.
+func (c *ColumnSpec) initDictPool() error {
+	if c.DictCardinality == 0 {
+		return nil
+	}
+	k := c.DictCardinality
+	seen := make([]map[string]struct{}, 2)
+	for i := range seen {
+		seen[i] = make(map[string]struct{}, k)
+	}
+	keys := make([]int, k)
+	for i := range k {
+		found := false
+		for try, key := 0, i; try < maxDictKeyTries; try, key = try+1, key+k {
+			reprs := c.dictReprs(key)
+			dup := false
+			for j, r := range reprs {
+				if _, ok := seen[j][r]; ok {
+					dup = true
+				}
+			}
+			if dup {
+				continue
+			}
+			for j, r := range reprs {
+				seen[j][r] = struct{}{}
+			}
+			keys[i], found = key, true
+			break
+		}
+		if !found {
+			return fmt.Errorf("column %s cannot hold %d distinct values for dict_cardinality, found %d", c.OrigName, k, i)
+		}
+	}
+	c.dictKeys = keys
+
+	// A multiplier coprime to k makes i -> mul*i+add a permutation of [0, k).
+	s := splitmixSource{state: c.runContext().Seed ^ c.runSalt}
+	c.dictMul = s.Uint64()%uint64(k) | 1
+	for gcd(c.dictMul, uint64(k)) != 1 {
+		c.dictMul++
+	}
+	c.dictAdd = s.Uint64() % uint64(k)
+	return nil
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// checkDictCardinality validates dict_cardinality once the unique keys of
+// the table are known.
+func (c *ColumnSpec) checkDictCardinality() error {
+	if c.DictCardinality == 0 {
+		return nil
+	}
+	switch c.SQLType {
+	case "tinyint", "smallint", "mediumint", "int", "bigint", "float", "double", "char", "varchar", "text":
+	default:
+		return fmt.Errorf("dict_cardinality is only supported for integer, float, double, char, varchar and text columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if c.IsUnique || len(c.IntSet) > 0 || len(c.ValueSet) > 0 || c.Order == SequenceOrder {
+		return fmt.Errorf("dict_cardinality cannot be combined with unique, set or order=sequence for column %s", c.OrigName)
+	}
+	if c.RunLength > 1 || c.DupKeyPercent > 0 || c.CaseVariantsPercent > 0 || c.WhitespacePercent > 0 {
+		return fmt.Errorf("dict_cardinality cannot be combined with run_length, dup_key_percent, case_variants_percent or whitespace_percent for column %s", c.OrigName)
+	}
+	if c.Encoding != "" && c.Encoding != EncodingDict {
+		return fmt.Errorf("dict_cardinality requires dictionary encoding, column %s has encoding=%s", c.OrigName, c.Encoding)
+	}
+	return nil
+}
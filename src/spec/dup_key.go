@@ -0,0 +1,74 @@
+package spec
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// recentRowWindow bounds how many rows back a duplicate key or case variant
+// is taken from.
+const recentRowWindow = 1000
+
+// earlierRow picks an earlier row of the same file, at most recentRowWindow
+// rows back, for percent% of the rows as chosen by salt. It returns rowID and
+// false for the other rows.
+func (r *RunContext) earlierRow(rowID int, percent float64, salt uint64) (int, bool) {
+	offset := r.Layout.rowInFile(rowID)
+	if offset == 0 {
+		return rowID, false
+	}
+	s := splitmixSource{state: r.Seed ^ salt ^ (uint64(rowID) * 0xff51afd7ed558ccd)}
+	if float64(s.Uint64()%1_000_000) >= percent*10_000 {
+		return rowID, false
+	}
+	return rowID - 1 - int(s.Uint64()%uint64(min(offset, recentRowWindow))), true
+}
+
+// keyRow returns the row whose value rowID gets. For dup_key_percent of the
+// rows it is an earlier, non-duplicate row of the same file, so the value
+// repeats a recently written key; otherwise it is rowID itself.
+func (c *ColumnSpec) keyRow(rowID int) int {
+	if c.DupKeyPercent == 0 {
+		return rowID
+	}
+	for {
+		prev, ok := c.runContext().earlierRow(rowID, c.DupKeyPercent, c.runSalt)
+		if !ok {
+			return rowID
+		}
+		rowID = prev
+	}
+}
+
+// valueSource returns the row and random source to generate the value of
+// rowID from. With dup_key_percent or case_variants_percent every row gets a
+// source derived from its key row, so an earlier value can be reproduced
+// exactly. With dict_cardinality it is derived from the row's pool key.
+func (c *ColumnSpec) valueSource(rowID int, rng *rand.Rand) (int, *rand.Rand) {
+	if c.DictCardinality > 0 {
+		return c.dictSource(c.dictKey(rowID))
+	}
+	if c.DupKeyPercent == 0 && c.CaseVariantsPercent == 0 {
+		return rowID, c.valueRand(rowID, rng)
+	}
+	key := c.keyRow(rowID)
+	return key, rand.New(&splitmixSource{state: c.runContext().Seed ^ c.runSalt ^ (uint64(key) * 0x9e3779b97f4a7c15)})
+}
+
+// checkDupKey validates dup_key_percent once the unique keys of the table
+// are known.
+func (c *ColumnSpec) checkDupKey() error {
+	if c.DupKeyPercent == 0 {
+		return nil
+	}
+	if !c.IsUnique {
+		return fmt.Errorf("dup_key_percent requires a unique column, column %s is not unique", c.OrigName)
+	}
+	if !isRunLengthSupported(c.SQLType) {
+		return fmt.Errorf("dup_key_percent is only supported for integer and string columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if c.RunLength > 1 || c.Order == SequenceOrder || len(c.IntSet) > 0 || len(c.ValueSet) > 0 {
+		return fmt.Errorf("dup_key_percent cannot be combined with run_length, order=sequence or set for column %s", c.OrigName)
+	}
+	return nil
+}
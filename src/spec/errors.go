@@ -0,0 +1,54 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by GetSpecFromSQL for a single column are *ColumnError
+// values wrapping one of these, so callers can check them with errors.Is.
+var (
+	ErrUnsupportedType  = errors.New("unsupported column type")
+	ErrMalformedComment = errors.New("malformed comment")
+	ErrInvalidOption    = errors.New("invalid column option")
+	ErrInvalidDecimal   = errors.New("invalid decimal")
+)
+
+// ErrNotMultiTable is returned by GetTablesFromSQL when the file holds at
+// most one CREATE TABLE statement and doesn't parse as a whole, callers fall
+// back to the more lenient GetSpecFromSQL then.
+var ErrNotMultiTable = errors.New("not a multi-table schema")
+
+// ColumnError is an error about a column of the schema.
+type ColumnError struct {
+	Column string
+	// Kind is one of the Err* errors of this package.
+	Kind error
+	// Err describes the problem, its message already names the column.
+	Err error
+}
+
+func newColumnError(column string, kind, err error) *ColumnError {
+	return &ColumnError{Column: column, Kind: kind, Err: err}
+}
+
+func (e *ColumnError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ColumnError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// commentError classifies an error from parseComment.
+func commentError(column string, err error) *ColumnError {
+	if errors.Is(err, ErrMalformedComment) {
+		return newColumnError(column, ErrMalformedComment, err)
+	}
+	return newColumnError(column, ErrInvalidOption, err)
+}
+
+// columnErrorf returns a *ColumnError of the given kind.
+func columnErrorf(column string, kind error, format string, args ...any) *ColumnError {
+	return newColumnError(column, kind, fmt.Errorf(format, args...))
+}
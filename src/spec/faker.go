@@ -0,0 +1,156 @@
+package spec
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Faker picks a generator of realistic looking values for a string column.
+type Faker int
+
+const (
+	FakerNone Faker = iota
+	FakerEmail
+	FakerFirstName
+	FakerLastName
+	FakerFullName
+	FakerCity
+	FakerCountry
+	FakerPhone
+	FakerIPv4
+	FakerUUID
+)
+
+var fakerNames = map[string]Faker{
+	"email":      FakerEmail,
+	"first_name": FakerFirstName,
+	"last_name":  FakerLastName,
+	"full_name":  FakerFullName,
+	"city":       FakerCity,
+	"country":    FakerCountry,
+	"phone":      FakerPhone,
+	"ipv4":       FakerIPv4,
+	"uuid":       FakerUUID,
+}
+
+func (f Faker) String() string {
+	for name, v := range fakerNames {
+		if v == f {
+			return name
+		}
+	}
+	return "none"
+}
+
+func parseFaker(s string) (Faker, error) {
+	if f, ok := fakerNames[s]; ok {
+		return f, nil
+	}
+	return FakerNone, fmt.Errorf("unknown faker %q, must be email, first_name, last_name, full_name, city, country, phone, ipv4 or uuid", s)
+}
+
+var fakeFirstNames = []string{
+	"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda",
+	"William", "Elizabeth", "David", "Barbara", "Richard", "Susan", "Joseph", "Jessica",
+	"Thomas", "Sarah", "Charles", "Karen", "Daniel", "Nancy", "Matthew", "Lisa",
+	"Anthony", "Betty", "Mark", "Sandra", "Wei", "Yuki", "Aarav", "Sofia",
+	"Lucas", "Emma", "Mateo", "Olivia", "Hiroshi", "Priya", "Omar", "Fatima",
+}
+
+var fakeLastNames = []string{
+	"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis",
+	"Rodriguez", "Martinez", "Hernandez", "Lopez", "Gonzalez", "Wilson", "Anderson", "Thomas",
+	"Taylor", "Moore", "Jackson", "Martin", "Lee", "Perez", "Thompson", "White",
+	"Harris", "Clark", "Lewis", "Walker", "Chen", "Wang", "Tanaka", "Sato",
+	"Kim", "Park", "Patel", "Singh", "Muller", "Schmidt", "Rossi", "Silva",
+}
+
+var fakeCities = []string{
+	"New York", "Los Angeles", "Chicago", "Houston", "Phoenix", "Seattle", "Boston", "Denver",
+	"London", "Paris", "Berlin", "Madrid", "Rome", "Amsterdam", "Stockholm", "Vienna",
+	"Tokyo", "Osaka", "Seoul", "Beijing", "Shanghai", "Singapore", "Mumbai", "Sydney",
+	"Toronto", "Vancouver", "Mexico City", "Sao Paulo", "Buenos Aires", "Cairo", "Lagos", "Dubai",
+}
+
+var fakeCountries = []string{
+	"United States", "Canada", "Mexico", "Brazil", "Argentina", "United Kingdom", "France", "Germany",
+	"Spain", "Italy", "Netherlands", "Sweden", "Austria", "Japan", "South Korea", "China",
+	"Singapore", "India", "Australia", "Egypt", "Nigeria", "United Arab Emirates", "South Africa", "Norway",
+}
+
+var fakeEmailDomains = []string{
+	"example.com", "example.org", "example.net", "mail.test", "corp.test", "inbox.test",
+}
+
+// fakePhoneFormats are phone number templates, # is replaced by a digit.
+var fakePhoneFormats = []string{
+	"+1-###-###-####", "(###) ###-####", "###-###-####", "+44 ## #### ####", "+81-##-####-####",
+}
+
+func pick(words []string, rng *rand.Rand) string {
+	return words[rng.Intn(len(words))]
+}
+
+// generateFake returns a value of the column's faker, cut to TypeLen bytes.
+func (c *ColumnSpec) generateFake(rng *rand.Rand) string {
+	var s string
+	switch c.Faker {
+	case FakerEmail:
+		var b strings.Builder
+		b.WriteString(strings.ToLower(pick(fakeFirstNames, rng)))
+		b.WriteString([...]string{".", "_", ""}[rng.Intn(3)])
+		b.WriteString(strings.ToLower(pick(fakeLastNames, rng)))
+		if rng.Intn(2) == 0 {
+			fmt.Fprintf(&b, "%d", rng.Intn(1000))
+		}
+		b.WriteByte('@')
+		b.WriteString(pick(fakeEmailDomains, rng))
+		s = b.String()
+	case FakerFirstName:
+		s = pick(fakeFirstNames, rng)
+	case FakerLastName:
+		s = pick(fakeLastNames, rng)
+	case FakerFullName:
+		s = pick(fakeFirstNames, rng) + " " + pick(fakeLastNames, rng)
+	case FakerCity:
+		s = pick(fakeCities, rng)
+	case FakerCountry:
+		s = pick(fakeCountries, rng)
+	case FakerPhone:
+		b := []byte(pick(fakePhoneFormats, rng))
+		for i, ch := range b {
+			if ch == '#' {
+				b[i] = byte('0' + rng.Intn(10))
+			}
+		}
+		s = string(b)
+	case FakerIPv4:
+		s = fmt.Sprintf("%d.%d.%d.%d", rng.Intn(223)+1, rng.Intn(256), rng.Intn(256), rng.Intn(254)+1)
+	case FakerUUID:
+		s = uuid.Must(uuid.NewRandomFromReader(rng)).String()
+	}
+	if len(s) > c.TypeLen {
+		s = s[:c.TypeLen]
+	}
+	return s
+}
+
+// checkFaker validates faker once the unique keys of the table are known.
+func (c *ColumnSpec) checkFaker() error {
+	if c.Faker == FakerNone {
+		return nil
+	}
+	if !isStringType(c.SQLType) || c.IsBinary() || c.SQLType == "enum" || c.SQLType == "set" || c.SQLType == "json" {
+		return fmt.Errorf("faker is only supported for text columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if c.regex != nil || len(c.ValueSet) > 0 {
+		return fmt.Errorf("faker cannot be combined with regex or set for column %s", c.OrigName)
+	}
+	if c.IsUnique && c.Faker != FakerUUID {
+		return fmt.Errorf("faker=%s cannot generate unique values for column %s, only faker=uuid can", c.Faker, c.OrigName)
+	}
+	return nil
+}
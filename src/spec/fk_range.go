@@ -0,0 +1,188 @@
+package spec
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FKMetadataPrefix prefixes the Parquet key-value metadata recording the
+// fk_range of a column, e.g. data_writer.fk_range.user_id=[0,1000000).
+const FKMetadataPrefix = "data_writer.fk_range."
+
+// parseFKRange parses a half-open range like [0,1000000).
+func parseFKRange(v string) (int64, int64, error) {
+	if !strings.HasPrefix(v, "[") || !strings.HasSuffix(v, ")") {
+		return 0, 0, fmt.Errorf("must be written as [low,high)")
+	}
+	lo, hi, ok := strings.Cut(v[1:len(v)-1], ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("must be written as [low,high)")
+	}
+	low, err := strconv.ParseInt(lo, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	high, err := strconv.ParseInt(hi, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	if low >= high {
+		return 0, 0, fmt.Errorf("low must be less than high")
+	}
+	return low, high, nil
+}
+
+// parseFK parses fk=min:max, an inclusive range, or fk=table.col, which
+// is returned as ref.
+func parseFK(v string) (low, high int64, ref string, err error) {
+	if lo, hi, ok := strings.Cut(v, ":"); ok {
+		if low, err = strconv.ParseInt(lo, 10, 64); err != nil {
+			return 0, 0, "", err
+		}
+		if high, err = strconv.ParseInt(hi, 10, 64); err != nil {
+			return 0, 0, "", err
+		}
+		if low > high {
+			return 0, 0, "", fmt.Errorf("min must not be greater than max")
+		}
+		return low, high, "", nil
+	}
+	table, col, ok := strings.Cut(v, ".")
+	if !ok || table == "" || col == "" || strings.Contains(col, ".") {
+		return 0, 0, "", fmt.Errorf("must be written as min:max or table.col")
+	}
+	return 0, 0, strings.ToLower(v), nil
+}
+
+// checkFKOptions rejects the options that conflict with fk and fk_range.
+func (c *ColumnSpec) checkFKOptions(opt string, hasMin, hasMax bool) error {
+	if !isIntegerType(c.SQLType) {
+		return fmt.Errorf("%s is only supported for integer columns, column %s is %s", opt, c.OrigName, c.SQLType)
+	}
+	if hasMin || hasMax || len(c.IntSet) > 0 || len(c.Histogram) > 0 || c.StdDev > 0 || c.Order == SequenceOrder {
+		return fmt.Errorf("%s cannot be combined with min/max, set, histogram, mean/stddev or order=sequence for column %s", opt, c.OrigName)
+	}
+	return nil
+}
+
+// setFKRange draws the values of the column uniformly from the key space
+// [low, high] of a parent table, which must fit the declared integer type.
+func (c *ColumnSpec) setFKRange(opt string, low, high int64) error {
+	lower, upper := c.intTypeRange()
+	if low < lower || high > upper {
+		return fmt.Errorf("%s key space %d to %d doesn't fit %s for column %s", opt, low, high, c.SQLType, c.OrigName)
+	}
+	c.MinValue, c.MaxValue = low, high
+	c.HasRange, c.IsForeignKey = true, true
+	return nil
+}
+
+// checkFKRange validates fk and fk_range once the unique keys of the table
+// are known. Keys drawn from a parent's key space repeat, so the column
+// cannot be unique.
+func (c *ColumnSpec) checkFKRange() error {
+	if (c.IsForeignKey || c.FKRef != "") && c.IsUnique {
+		return fmt.Errorf("fk and fk_range cannot be used on unique column %s", c.OrigName)
+	}
+	return nil
+}
+
+// RowSpan describes the rows of a run, which give the key space of parent
+// columns whose values follow the row: the first global row ID, the rows of
+// all files and the most rows of one file.
+type RowSpan struct {
+	First   int
+	Total   int
+	PerFile int
+}
+
+// ResolveFKRefs sets the key space of every fk=table.col column to the
+// values of the referenced column.
+func ResolveFKRefs(tables map[string]*Table, span RowSpan) error {
+	visiting := make(map[*ColumnSpec]bool)
+	for name, t := range tables {
+		for _, c := range t.Specs {
+			if err := resolveFKRef(tables, c, span, visiting); err != nil {
+				return fmt.Errorf("table %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveFKRef resolves c, and first the column it references when that
+// one is an fk=table.col column itself.
+func resolveFKRef(tables map[string]*Table, c *ColumnSpec, span RowSpan, visiting map[*ColumnSpec]bool) error {
+	if c.FKRef == "" || c.IsForeignKey {
+		return nil
+	}
+	if visiting[c] {
+		return fmt.Errorf("fk=%s of column %s references itself through other columns", c.FKRef, c.OrigName)
+	}
+	visiting[c] = true
+
+	tableName, colName, _ := strings.Cut(c.FKRef, ".")
+	table, ok := tables[tableName]
+	if !ok {
+		return fmt.Errorf("fk=%s of column %s references a table that is not in the schema", c.FKRef, c.OrigName)
+	}
+	var parent *ColumnSpec
+	for _, s := range table.Specs {
+		if s.OrigName == colName {
+			parent = s
+		}
+	}
+	if parent == nil || parent == c {
+		return fmt.Errorf("fk=%s of column %s references no other column", c.FKRef, c.OrigName)
+	}
+	if err := resolveFKRef(tables, parent, span, visiting); err != nil {
+		return err
+	}
+	low, high, err := keySpace(table, parent, span)
+	if err != nil {
+		return fmt.Errorf("fk=%s of column %s: %w", c.FKRef, c.OrigName, err)
+	}
+	return c.setFKRange("fk="+c.FKRef, low, high)
+}
+
+// keySpace returns the range of the values of the integer column p of t.
+func keySpace(t *Table, p *ColumnSpec, span RowSpan) (int64, int64, error) {
+	if !isIntegerType(p.SQLType) {
+		return 0, 0, fmt.Errorf("column %s is %s, not an integer", p.OrigName, p.SQLType)
+	}
+	if p.HasRange {
+		return p.MinValue, p.MaxValue, nil
+	}
+	first, rows := span.First, span.Total
+	if t.Broadcast {
+		first, rows = 0, span.PerFile
+	}
+	switch {
+	case p.GapPercent > 0:
+	case p.Order == SequenceOrder && p.SequenceScope == SequenceGlobal:
+		p.KeysFollowRows = true
+		return 1, int64(rows), nil
+	case p.Order == SequenceOrder && p.SequenceScope == SequenceFile:
+		p.KeysFollowRows = true
+		return 0, int64(span.PerFile - 1), nil
+	case p.Order == NumericTotalOrder && p.IsUnique && p.UniqueScope != UniqueScopeGlobal:
+		p.KeysFollowRows = true
+		return int64(first), int64(first + rows - 1), nil
+	}
+	return 0, 0, fmt.Errorf("the key space of column %s is unknown, give it min/max, fk, order=sequence or a unique order=total_order without gap_percent", p.OrigName)
+}
+
+// FKRangeString returns the fk_range of the column as written in the
+// comment, or "" if it has none. A range ending at MaxInt64 has no exclusive
+// upper bound and is printed with an inclusive one, [low,9223372036854775807].
+func (c *ColumnSpec) FKRangeString() string {
+	if !c.IsForeignKey {
+		return ""
+	}
+	if c.MaxValue == math.MaxInt64 {
+		return "[" + strconv.FormatInt(c.MinValue, 10) + "," + strconv.FormatInt(c.MaxValue, 10) + "]"
+	}
+	return "[" + strconv.FormatInt(c.MinValue, 10) + "," + strconv.FormatInt(c.MaxValue+1, 10) + ")"
+}
@@ -0,0 +1,37 @@
+package spec
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFKRangeString(t *testing.T) {
+	tests := []struct {
+		min, max int64
+		want     string
+	}{
+		{0, 999999, "[0,1000000)"},
+		{-5, -1, "[-5,0)"},
+		{0, math.MaxInt64, "[0,9223372036854775807]"},
+	}
+	for _, tt := range tests {
+		c := &ColumnSpec{IsForeignKey: true, MinValue: tt.min, MaxValue: tt.max}
+		if got := c.FKRangeString(); got != tt.want {
+			t.Errorf("FKRangeString(%d, %d) = %s, want %s", tt.min, tt.max, got, tt.want)
+		}
+	}
+	if got := (&ColumnSpec{}).FKRangeString(); got != "" {
+		t.Errorf("FKRangeString without fk_range = %q, want empty", got)
+	}
+}
+
+func TestParseFKRangeRoundTrip(t *testing.T) {
+	low, high, err := parseFKRange("[10,20)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &ColumnSpec{IsForeignKey: true, MinValue: low, MaxValue: high - 1}
+	if got := c.FKRangeString(); got != "[10,20)" {
+		t.Errorf("FKRangeString = %s, want [10,20)", got)
+	}
+}
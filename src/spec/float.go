@@ -0,0 +1,97 @@
+package spec
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// maxFloatPrecision bounds the precision of float_format.
+const maxFloatPrecision = 30
+
+// FloatDistribution decides how float_min/float_max values are drawn.
+type FloatDistribution int
+
+const (
+	// FloatUniform draws uniformly from [FloatMin, FloatMax).
+	FloatUniform FloatDistribution = iota
+	// FloatGaussian draws from a normal distribution of Mean and StdDev,
+	// clamped to [FloatMin, FloatMax] when they are set.
+	FloatGaussian
+)
+
+// hasFloatDist reports whether the column draws genuine floats from
+// float_min/float_max or float_dist.
+func (c *ColumnSpec) hasFloatDist() bool {
+	return c.HasFloatRange || c.FloatDist == FloatGaussian
+}
+
+// generateFloat returns a float value of rowID. Without float options it is
+// the integer value of rowID plus a random fraction, so the values fill the
+// mantissa. Set values are kept exact.
+func (c *ColumnSpec) generateFloat(rowID int, rng *rand.Rand) float64 {
+	if c.FloatDist == FloatGaussian {
+		v := rng.NormFloat64()*float64(c.StdDev) + float64(c.Mean)
+		if c.HasFloatRange {
+			v = min(max(v, c.FloatMin), c.FloatMax)
+		}
+		return v
+	}
+	if c.HasFloatRange {
+		return c.FloatMin + rng.Float64()*(c.FloatMax-c.FloatMin)
+	}
+
+	v := float64(c.generateInt(rowID, rng))
+	if len(c.IntSet) > 0 {
+		return v
+	}
+	return v + rng.Float64()
+}
+
+// parseFloatFormat parses float_format: a strconv.FormatFloat verb, f, e,
+// E, g or G, optionally followed by the precision, e.g. f2 or e6.
+func parseFloatFormat(s string) (byte, int, error) {
+	if s == "" || !strings.ContainsRune("feEgG", rune(s[0])) {
+		return 0, 0, fmt.Errorf("must start with f, e, E, g or G")
+	}
+	if len(s) == 1 {
+		return s[0], -1, nil
+	}
+	prec, err := strconv.Atoi(s[1:])
+	if err != nil || prec < 0 || prec > maxFloatPrecision {
+		return 0, 0, fmt.Errorf("precision must be between 0 and %d", maxFloatPrecision)
+	}
+	return s[0], prec, nil
+}
+
+// formatFloat formats v with the column's float_format, at the precision of
+// a float or double.
+func (c *ColumnSpec) formatFloat(v float64) string {
+	bitSize := 64
+	if c.SQLType == "float" {
+		bitSize = 32
+	}
+	return strconv.FormatFloat(v, c.FloatFormat, c.FloatPrecision, bitSize)
+}
+
+// checkFloat validates float_min, float_max and float_dist once the unique
+// keys of the table are known.
+func (c *ColumnSpec) checkFloat() error {
+	if !c.hasFloatDist() {
+		return nil
+	}
+	if c.SQLType != "float" && c.SQLType != "double" {
+		return fmt.Errorf("float_min/float_max/float_dist are only supported for float and double columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if c.HasFloatRange && !(c.FloatMin < c.FloatMax) {
+		return fmt.Errorf("float_min must be less than float_max for column %s", c.OrigName)
+	}
+	if c.FloatDist == FloatGaussian && c.StdDev <= 0 {
+		return fmt.Errorf("float_dist=gaussian requires stddev > 0 for column %s", c.OrigName)
+	}
+	if c.IsUnique || len(c.IntSet) > 0 || c.Order == SequenceOrder {
+		return fmt.Errorf("float_min/float_max/float_dist cannot be combined with unique, set or order=sequence for column %s", c.OrigName)
+	}
+	return nil
+}
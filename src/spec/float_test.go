@@ -0,0 +1,84 @@
+package spec
+
+import (
+	"math/rand"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestParquetFloatsDistinctWithinBatch(t *testing.T) {
+	const rows = 4096
+	rng := rand.New(rand.NewSource(1))
+
+	c := testSpec(t, "v double NOT NULL")
+	doubles := make([]float64, rows)
+	if err := c.FillParquetBatch(0, doubles, make([]int16, rows), rng); err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[float64]bool, rows)
+	for _, v := range doubles {
+		seen[v] = true
+	}
+	if len(seen) < rows*99/100 {
+		t.Errorf("double batch has %d distinct values of %d", len(seen), rows)
+	}
+
+	c = testSpec(t, "v float NOT NULL")
+	floats := make([]float32, rows)
+	if err := c.FillParquetBatch(0, floats, make([]int16, rows), rng); err != nil {
+		t.Fatal(err)
+	}
+	seen32 := make(map[float32]bool, rows)
+	for _, v := range floats {
+		seen32[v] = true
+	}
+	if len(seen32) < rows*99/100 {
+		t.Errorf("float batch has %d distinct values of %d", len(seen32), rows)
+	}
+}
+
+func TestFloatFormat(t *testing.T) {
+	cases := []struct {
+		option string
+		match  *regexp.Regexp
+	}{
+		{"f2", regexp.MustCompile(`^-?\d+\.\d{2}$`)},
+		{"f0", regexp.MustCompile(`^-?\d+$`)},
+		{"e3", regexp.MustCompile(`^-?\d\.\d{3}e[+-]\d{2,3}$`)},
+		{"E3", regexp.MustCompile(`^-?\d\.\d{3}E[+-]\d{2,3}$`)},
+	}
+	for _, tc := range cases {
+		for _, sqlType := range []string{"double", "float"} {
+			c := testSpec(t, "v "+sqlType+" NOT NULL COMMENT 'float_format="+tc.option+", float_min=-100000, float_max=100000'")
+			rng := rand.New(rand.NewSource(1))
+			for i := range 200 {
+				s := GenerateSingleField(i, c, rng)
+				if !tc.match.MatchString(s) {
+					t.Fatalf("%s float_format=%s: %q doesn't match %s", sqlType, tc.option, s, tc.match)
+				}
+				v, err := strconv.ParseFloat(s, 64)
+				if err != nil || v < -100000 || v > 100000 {
+					t.Fatalf("%s float_format=%s: %q is outside [-100000, 100000]", sqlType, tc.option, s)
+				}
+			}
+		}
+	}
+
+	// Fixed values are rounded, not truncated, to the precision.
+	c := testSpec(t, "v double COMMENT 'float_format=f1'")
+	for v, want := range map[float64]string{1.25: "1.2", 1.35: "1.4", -0.05: "-0.1", 12345.678: "12345.7"} {
+		if got := c.formatFloat(v); got != want {
+			t.Errorf("f1 %v = %q, want %q", v, got, want)
+		}
+	}
+	c = testSpec(t, "v double COMMENT 'float_format=e2'")
+	if got := c.formatFloat(12345.678); got != "1.23e+04" {
+		t.Errorf("e2 12345.678 = %q, want 1.23e+04", got)
+	}
+	for _, bad := range []string{"x2", "f31", "f-1", ""} {
+		if _, _, err := parseFloatFormat(bad); err == nil {
+			t.Errorf("float_format=%s is accepted", bad)
+		}
+	}
+}
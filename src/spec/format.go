@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 	"text/tabwriter"
+
+	"github.com/apache/arrow-go/v18/parquet"
 )
 
 // DisplaySQLType returns a formatted SQL type for preview output.
@@ -18,6 +20,10 @@ func (c *ColumnSpec) DisplaySQLType() string {
 		if c.Precision > 0 {
 			return fmt.Sprintf("decimal(%d)", c.Precision)
 		}
+	case "timestamp", "datetime", "time":
+		if c.FSP > 0 {
+			return fmt.Sprintf("%s(%d)", c.SQLType, c.FSP)
+		}
 	case "char", "varchar", "binary", "varbinary":
 		if c.TypeLen > 0 {
 			return fmt.Sprintf("%s(%d)", c.SQLType, c.TypeLen)
@@ -26,16 +32,26 @@ func (c *ColumnSpec) DisplaySQLType() string {
 	return c.SQLType
 }
 
+// DisplayParquetType returns the Parquet physical type used for the column.
+func (c *ColumnSpec) DisplayParquetType() string {
+	if c.Type == parquet.Types.FixedLenByteArray {
+		return fmt.Sprintf("%s(%d)", c.Type, c.TypeLen)
+	}
+	return c.Type.String()
+}
+
 // FormatSpecsTable renders a human-readable table for column specs.
 func FormatSpecsTable(specs []*ColumnSpec) string {
 	var buf bytes.Buffer
 	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
 
-	fmt.Fprintln(w, "Name\tType\tMinLen\tMaxLen\tNull%\tUnique\tOrder\tSet")
+	fmt.Fprintln(w, "Name\tType\tParquet\tMinLen\tTypeLen\tPrecision\tScale\tNull%\tUnique\tOrder\tSet")
 	for _, c := range specs {
 		nullPercent := "-"
 		if c.NullPercent > 0 {
 			nullPercent = strconv.Itoa(c.NullPercent)
+		} else if c.NullCount > 0 {
+			nullPercent = strconv.Itoa(c.NullCount) + " rows"
 		}
 
 		minLen := "-"
@@ -43,14 +59,26 @@ func FormatSpecsTable(specs []*ColumnSpec) string {
 			minLen = strconv.Itoa(c.MinLen)
 		}
 
-		maxLen := "-"
+		typeLen := "-"
 		if c.TypeLen > 0 {
-			maxLen = strconv.Itoa(c.TypeLen)
+			typeLen = strconv.Itoa(c.TypeLen)
+		}
+
+		precision, scale := "-", "-"
+		if c.Precision > 0 {
+			precision = strconv.Itoa(c.Precision)
+			scale = strconv.Itoa(c.Scale)
 		}
 
 		unique := "-"
 		if c.IsUnique {
 			unique = "yes"
+			if c.UniqueScope == UniqueScopeGlobal {
+				unique = "global"
+			}
+			if c.DupKeyPercent > 0 {
+				unique += fmt.Sprintf(" (%g%% dup)", c.DupKeyPercent)
+			}
 		}
 
 		set := "-"
@@ -65,7 +93,9 @@ func FormatSpecsTable(specs []*ColumnSpec) string {
 		}
 
 		order := "-"
-		if set != "-" {
+		if set != "-" && c.Order == CycleOrder {
+			order = "cycle"
+		} else if set != "-" {
 			order = "n/a"
 		} else if isNumericOrderSupported(c.SQLType) {
 			switch c.Order {
@@ -75,16 +105,24 @@ func FormatSpecsTable(specs []*ColumnSpec) string {
 				order = "partial"
 			case NumericRandomOrder:
 				order = "random"
+			case SequenceOrder:
+				order = "sequence"
+			}
+			if c.GapPercent > 0 {
+				order += fmt.Sprintf(" (%g%% gaps)", c.GapPercent)
 			}
 		} else {
 			order = "n/a"
 		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
 			c.OrigName,
 			c.DisplaySQLType(),
+			c.DisplayParquetType(),
 			minLen,
-			maxLen,
+			typeLen,
+			precision,
+			scale,
 			nullPercent,
 			unique,
 			order,
@@ -0,0 +1,40 @@
+package spec
+
+import "fmt"
+
+// withGaps spreads the values of a monotonic integer column so that about
+// gap_percent of them are followed by skipped values, like ids of deleted
+// rows. One gap falls at a random position of every 100/gap_percent values,
+// so the gaps before v are known without looking at earlier rows. Gaps that
+// land next to each other skip more than one value.
+func (c *ColumnSpec) withGaps(v int) int {
+	if c.GapPercent == 0 || v < 0 {
+		return v
+	}
+	interval := 100 / c.GapPercent
+	// Every interval before v's holds one gap below v.
+	m := int(float64(v) / interval)
+	s := splitmixSource{state: c.runContext().Seed ^ c.runSalt ^ (uint64(m) * 0xbf58476d1ce4e5b9)}
+	at := (float64(m) + float64(s.Uint64()>>11)/(1<<53)) * interval
+	if int(at) < v {
+		m++
+	}
+	return v + m
+}
+
+// checkGap validates gap_percent once the unique keys of the table are known.
+func (c *ColumnSpec) checkGap() error {
+	if c.GapPercent == 0 {
+		return nil
+	}
+	if !isIntegerType(c.SQLType) {
+		return fmt.Errorf("gap_percent is only supported for integer columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if c.Order != SequenceOrder && (c.Order != NumericTotalOrder || !c.IsUnique) {
+		return fmt.Errorf("gap_percent requires order=sequence or a unique column with order=total_order, column %s", c.OrigName)
+	}
+	if len(c.IntSet) > 0 || len(c.Histogram) > 0 || c.StdDev > 0 || c.RunLength > 1 || c.DupKeyPercent > 0 {
+		return fmt.Errorf("gap_percent cannot be combined with set, histogram, mean/stddev, run_length or dup_key_percent for column %s", c.OrigName)
+	}
+	return nil
+}
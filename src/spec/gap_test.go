@@ -0,0 +1,34 @@
+package spec
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestGapFrequency(t *testing.T) {
+	const values = 100_000
+	for _, percent := range []float64{0.5, 2, 10} {
+		c := testSpec(t, "id bigint COMMENT 'order=sequence, gap_percent="+strconv.FormatFloat(percent, 'g', -1, 64)+"'")
+		prev, skipped, gaps := c.withGaps(0), 0, 0
+		for v := 1; v < values; v++ {
+			next := c.withGaps(v)
+			if next <= prev {
+				t.Fatalf("gap_percent=%v: value %d maps to %d after %d", percent, v, next, prev)
+			}
+			if d := next - prev - 1; d > 0 {
+				gaps++
+				skipped += d
+			}
+			prev = next
+		}
+		// One gap per 100/gap_percent values, gaps next to each other merge.
+		want := values * percent / 100
+		if math.Abs(float64(skipped)-want) > want*0.02+2 {
+			t.Errorf("gap_percent=%v: %d values skipped, want about %.0f", percent, skipped, want)
+		}
+		if float64(gaps) < want*0.85 || gaps > skipped {
+			t.Errorf("gap_percent=%v: %d gaps for %d skipped values", percent, gaps, skipped)
+		}
+	}
+}
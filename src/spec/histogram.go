@@ -0,0 +1,136 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"sort"
+)
+
+// HistogramBucket is a [Lower, Upper) value range drawn with a relative
+// Weight.
+type HistogramBucket struct {
+	Lower  float64
+	Upper  float64
+	Weight float64
+
+	// cumWeight is the sum of the weights up to and including this bucket.
+	cumWeight float64
+	// intLower and intCount are the integers in the bucket.
+	intLower int64
+	intCount int64
+	// unscaledLower and unscaledSpan are the unscaled decimals in the bucket.
+	unscaledLower *big.Int
+	unscaledSpan  *big.Int
+}
+
+// parseHistogram parses histogram=[[lower,upper,weight],...]. Buckets must not
+// overlap and weights must be positive, they don't need to sum to 100.
+func parseHistogram(v string) ([]HistogramBucket, error) {
+	var raw [][]float64
+	if err := json.Unmarshal([]byte(v), &raw); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("no buckets")
+	}
+
+	buckets := make([]HistogramBucket, 0, len(raw))
+	for _, b := range raw {
+		if len(b) != 3 {
+			return nil, fmt.Errorf("bucket %v must be [lower, upper, weight]", b)
+		}
+		if !(b[0] < b[1]) {
+			return nil, fmt.Errorf("bucket %v must have lower < upper", b)
+		}
+		if !(b[2] > 0) || math.IsInf(b[2], 0) {
+			return nil, fmt.Errorf("bucket %v must have a positive weight", b)
+		}
+		buckets = append(buckets, HistogramBucket{Lower: b[0], Upper: b[1], Weight: b[2]})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Lower < buckets[j].Lower })
+	var total float64
+	for i := range buckets {
+		if i > 0 && buckets[i].Lower < buckets[i-1].Upper {
+			return nil, fmt.Errorf("buckets [%v,%v) and [%v,%v) overlap",
+				buckets[i-1].Lower, buckets[i-1].Upper, buckets[i].Lower, buckets[i].Upper)
+		}
+		total += buckets[i].Weight
+		buckets[i].cumWeight = total
+	}
+	return buckets, nil
+}
+
+// initHistogramInts computes the integers of each bucket, which must fit the
+// column's integer type.
+func (c *ColumnSpec) initHistogramInts() error {
+	lower, upper := c.intTypeRange()
+	for i := range c.Histogram {
+		b := &c.Histogram[i]
+		lo, hi := math.Ceil(b.Lower), math.Ceil(b.Upper)-1
+		if lo > hi {
+			return fmt.Errorf("histogram bucket [%v,%v) has no integers for column %s", b.Lower, b.Upper, c.OrigName)
+		}
+		if lo < float64(lower) || hi > float64(upper) || hi-lo >= math.MaxInt64 {
+			return fmt.Errorf("histogram bucket [%v,%v) doesn't fit %s for column %s", b.Lower, b.Upper, c.SQLType, c.OrigName)
+		}
+		b.intLower = int64(lo)
+		b.intCount = int64(hi) - b.intLower + 1
+	}
+	return nil
+}
+
+// initHistogramDecimals computes the unscaled values of each bucket at the
+// column's scale, which must be within its precision.
+func (c *ColumnSpec) initHistogramDecimals() error {
+	scale := new(big.Rat).SetInt(pow10(c.Scale))
+	ceil := func(v float64) *big.Int {
+		r := new(big.Rat).SetFloat64(v)
+		r.Mul(r, scale)
+		q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+		if m.Sign() > 0 {
+			q.Add(q, big.NewInt(1))
+		}
+		return q
+	}
+
+	bound := pow10(c.Precision)
+	for i := range c.Histogram {
+		b := &c.Histogram[i]
+		lo, hi := ceil(b.Lower), ceil(b.Upper)
+		if lo.Cmp(hi) >= 0 {
+			return fmt.Errorf("histogram bucket [%v,%v) has no values at scale %d for column %s", b.Lower, b.Upper, c.Scale, c.OrigName)
+		}
+		// Values must be within (-10^precision, 10^precision).
+		if new(big.Int).Neg(lo).Cmp(bound) >= 0 || hi.Cmp(bound) > 0 {
+			return fmt.Errorf("histogram bucket [%v,%v) doesn't fit decimal(%d,%d) for column %s", b.Lower, b.Upper, c.Precision, c.Scale, c.OrigName)
+		}
+		b.unscaledLower = lo
+		b.unscaledSpan = hi.Sub(hi, lo)
+	}
+	return nil
+}
+
+// pickBucket picks a histogram bucket by weight.
+func (c *ColumnSpec) pickBucket(rng *rand.Rand) *HistogramBucket {
+	r := rng.Float64() * c.Histogram[len(c.Histogram)-1].cumWeight
+	i := sort.Search(len(c.Histogram), func(i int) bool { return r < c.Histogram[i].cumWeight })
+	return &c.Histogram[min(i, len(c.Histogram)-1)]
+}
+
+// generateHistogramInt draws an integer uniformly from a weighted bucket.
+func (c *ColumnSpec) generateHistogramInt(rng *rand.Rand) int {
+	b := c.pickBucket(rng)
+	return int(b.intLower + rng.Int63n(b.intCount))
+}
+
+// generateHistogramDecimal draws an unscaled decimal uniformly from a
+// weighted bucket.
+func (c *ColumnSpec) generateHistogramDecimal(rng *rand.Rand) *big.Int {
+	b := c.pickBucket(rng)
+	v := new(big.Int).Rand(rng, b.unscaledSpan)
+	return v.Add(v, b.unscaledLower)
+}
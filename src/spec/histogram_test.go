@@ -0,0 +1,61 @@
+package spec
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestHistogramDistribution(t *testing.T) {
+	const samples = 20_000
+	buckets := []struct {
+		lower, upper, share float64
+	}{
+		{0, 100, 0.5},
+		{100, 200, 0.3},
+		{200, 1000, 0.2},
+	}
+	for _, column := range []string{
+		"v int NOT NULL COMMENT 'histogram=[[0,100,50],[100,200,30],[200,1000,20]]'",
+		"v decimal(8,2) NOT NULL COMMENT 'histogram=[[200,1000,2],[0,100,5],[100,200,3]]'",
+	} {
+		c := testSpec(t, column)
+		rng := rand.New(rand.NewSource(1))
+		counts := make([]int, len(buckets))
+		sums := make([]float64, len(buckets))
+		for i := range samples {
+			s := GenerateRawField(i, c, rng)
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				t.Fatalf("%s: %q is not a number", column, s)
+			}
+			found := false
+			for j, b := range buckets {
+				if v >= b.lower && v < b.upper {
+					counts[j]++
+					sums[j] += v
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("%s: %v is outside every bucket", column, v)
+			}
+		}
+
+		for j, b := range buckets {
+			// The standard deviation of a share is below 0.0036 here, so
+			// 0.02 is more than five of them.
+			if share := float64(counts[j]) / samples; math.Abs(share-b.share) > 0.02 {
+				t.Errorf("%s: bucket [%v,%v) has %.3f of the values, want %.2f", column, b.lower, b.upper, share, b.share)
+			}
+			// Values are uniform within a bucket, so their mean is its
+			// middle, up to a few standard errors.
+			mid, width := (b.lower+b.upper)/2, b.upper-b.lower
+			stderr := width / math.Sqrt(12*float64(counts[j]))
+			if mean := sums[j] / float64(counts[j]); math.Abs(mean-mid) > 5*stderr {
+				t.Errorf("%s: bucket [%v,%v) has mean %.2f, want about %v", column, b.lower, b.upper, mean, mid)
+			}
+		}
+	}
+}
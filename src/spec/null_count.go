@@ -0,0 +1,31 @@
+package spec
+
+import "math/bits"
+
+// isNullRow reports whether rowID is one of the NullCount NULL rows of its
+// file. The rows of a file are shuffled by a keyed permutation and the first
+// NullCount of them are NULL, so every file gets exactly NullCount NULLs no
+// matter how it is split into batches and row groups.
+func (c *ColumnSpec) isNullRow(rowID int) bool {
+	run := c.runContext()
+	n := run.Layout.rowsOfFile(rowID)
+	if c.NullCount >= n {
+		return true
+	}
+	key := run.Seed ^ c.runSalt ^ mixRowID(run.Layout.fileOfRow(rowID))
+	return permuteRange(uint64(run.Layout.rowInFile(rowID)), uint64(n), key) < uint64(c.NullCount)
+}
+
+// permuteRange maps x in [0, n) to another value in [0, n), a bijection
+// chosen by key. It walks the cycle of a permutation of the enclosing power
+// of two until the value falls back into range.
+func permuteRange(x, n, key uint64) uint64 {
+	width := bits.Len64(n - 1)
+	mask := uint64(1)<<width - 1
+	for {
+		x = permuteBits(x^(key&mask), width)
+		if x < n {
+			return x
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package spec
+
+import "strings"
+
+// NumberFormat defines how numeric values are formatted in CSV files.
+type NumberFormat int
+
+const (
+	// NumberFormatNone writes numbers as is, e.g. 1234.56.
+	NumberFormatNone NumberFormat = iota
+	// NumberFormatUS groups thousands with ',' and uses '.' as the decimal
+	// point, e.g. 1,234.56.
+	NumberFormatUS
+	// NumberFormatEU groups thousands with '.' and uses ',' as the decimal
+	// point, e.g. 1.234,56.
+	NumberFormatEU
+)
+
+// parseNumberFormat parses the value of the number_format option.
+func parseNumberFormat(v string) (NumberFormat, bool) {
+	switch v {
+	case "none":
+		return NumberFormatNone, true
+	case "us", "en_US":
+		return NumberFormatUS, true
+	case "eu", "de_DE":
+		return NumberFormatEU, true
+	}
+	return NumberFormatNone, false
+}
+
+func (f NumberFormat) separators() (group, point byte) {
+	if f == NumberFormatEU {
+		return '.', ','
+	}
+	return ',', '.'
+}
+
+// apply reformats a plain number such as "-1234567.89". Values that are not
+// plain decimal numbers (e.g. exponents) are returned unchanged.
+func (f NumberFormat) apply(s string) string {
+	if f == NumberFormatNone {
+		return s
+	}
+
+	sign, digits := "", s
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		sign, digits = digits[:1], digits[1:]
+	}
+	intPart, fracPart, hasFrac := strings.Cut(digits, ".")
+	if intPart == "" || strings.Trim(intPart, "0123456789") != "" ||
+		strings.Trim(fracPart, "0123456789") != "" {
+		return s
+	}
+
+	group, point := f.separators()
+	var b strings.Builder
+	b.Grow(len(s) + len(intPart)/3)
+	b.WriteString(sign)
+	for i := range len(intPart) {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(group)
+		}
+		b.WriteByte(intPart[i])
+	}
+	if hasFrac {
+		b.WriteByte(point)
+		b.WriteString(fracPart)
+	}
+	return b.String()
+}
+
+// isNumberFormatSupported reports whether number_format applies to sqlType.
+func isNumberFormatSupported(sqlType string) bool {
+	switch sqlType {
+	case "tinyint", "smallint", "mediumint", "int", "bigint",
+		"float", "double", "decimal":
+		return true
+	default:
+		return false
+	}
+}
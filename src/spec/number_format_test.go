@@ -0,0 +1,67 @@
+package spec
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestNumberFormatApply(t *testing.T) {
+	cases := []struct {
+		in     string
+		us, eu string
+	}{
+		{"0", "0", "0"},
+		{"999", "999", "999"},
+		{"1000", "1,000", "1.000"},
+		{"-1234567", "-1,234,567", "-1.234.567"},
+		{"1234.56", "1,234.56", "1.234,56"},
+		{"-1234567.89", "-1,234,567.89", "-1.234.567,89"},
+		{"123456.5", "123,456.5", "123.456,5"},
+		{"0.25", "0.25", "0,25"},
+		{"+1000", "+1,000", "+1.000"},
+		// Not plain numbers, kept as they are.
+		{"1.5e+10", "1.5e+10", "1.5e+10"},
+		{"NaN", "NaN", "NaN"},
+		{"", "", ""},
+	}
+	for _, tc := range cases {
+		if got := NumberFormatUS.apply(tc.in); got != tc.us {
+			t.Errorf("us %q = %q, want %q", tc.in, got, tc.us)
+		}
+		if got := NumberFormatEU.apply(tc.in); got != tc.eu {
+			t.Errorf("eu %q = %q, want %q", tc.in, got, tc.eu)
+		}
+		if got := NumberFormatNone.apply(tc.in); got != tc.in {
+			t.Errorf("none %q = %q", tc.in, got)
+		}
+	}
+
+	for name, want := range map[string]NumberFormat{"us": NumberFormatUS, "en_US": NumberFormatUS, "eu": NumberFormatEU, "de_DE": NumberFormatEU, "none": NumberFormatNone} {
+		if got, ok := parseNumberFormat(name); !ok || got != want {
+			t.Errorf("parseNumberFormat(%q) = %v, %v", name, got, ok)
+		}
+	}
+	if _, ok := parseNumberFormat("fr_FR"); ok {
+		t.Error("parseNumberFormat accepts fr_FR")
+	}
+}
+
+func TestNumberFormatEuropeanDecimals(t *testing.T) {
+	c := testSpec(t, "d decimal(12,2) NOT NULL COMMENT 'number_format=eu'")
+	rng := rand.New(rand.NewSource(1))
+	for i := range 1000 {
+		s := GenerateSingleField(i, c, rng)
+		intPart, frac, ok := strings.Cut(s, ",")
+		if !ok || len(frac) != 2 || strings.Contains(frac, ".") {
+			t.Fatalf("%q has no two digit decimal comma", s)
+		}
+		// Groups of three digits, separated by dots.
+		groups := strings.Split(strings.TrimPrefix(intPart, "-"), ".")
+		for j, g := range groups {
+			if (j > 0 && len(g) != 3) || len(g) == 0 || len(g) > 3 {
+				t.Fatalf("%q is not grouped by thousands", s)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package spec
+
+import (
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/parquet/schema"
+)
+
+// BigQuery NUMERIC holds up to 29 integer and 9 fractional digits, larger
+// decimals need BIGNUMERIC, which holds up to 38 fractional digits.
+const (
+	bigQueryNumericScale     = 9
+	bigQueryNumericIntDigits = 29
+	bigQueryBigNumericScale  = 38
+)
+
+// ApplyParquetDialect selects Parquet logical types for a target system and
+// returns warnings about columns it may not load as expected. The empty
+// dialect keeps the default types.
+func ApplyParquetDialect(specs []*ColumnSpec, dialect string) ([]string, error) {
+	switch dialect {
+	case "":
+		return nil, nil
+	case "bigquery":
+		var warnings []string
+		for _, c := range specs {
+			warnings = append(warnings, c.applyBigQueryTypes()...)
+		}
+		return warnings, nil
+	default:
+		return nil, fmt.Errorf("unsupported parquet dialect %q", dialect)
+	}
+}
+
+// applyBigQueryTypes maps the column to the logical type BigQuery loads into
+// the closest column type. Binary columns stay unannotated and load as BYTES.
+func (c *ColumnSpec) applyBigQueryTypes() []string {
+	var warnings []string
+	switch c.SQLType {
+	case "timestamp":
+		// TIMESTAMP is an instant, loaded from UTC-adjusted timestamps.
+		if c.TimestampUnit != TimestampInt96 {
+			c.Logical = schema.NewTimestampLogicalType(true, c.timeUnit())
+		}
+	case "datetime":
+		// DATETIME is a wall clock, loaded from timestamps not adjusted to
+		// UTC. INT96 has no such flag and loads as TIMESTAMP.
+		if c.TimestampUnit == TimestampInt96 {
+			warnings = append(warnings, fmt.Sprintf("column %s: int96 datetime values load as TIMESTAMP rather than DATETIME", c.OrigName))
+		} else {
+			c.Logical = schema.NewTimestampLogicalType(false, c.timeUnit())
+		}
+	case "date":
+		c.Logical = schema.DateLogicalType{}
+	case "decimal":
+		c.Logical = schema.NewDecimalLogicalType(int32(c.Precision), int32(c.Scale))
+		switch {
+		case c.Scale > bigQueryBigNumericScale:
+			warnings = append(warnings, fmt.Sprintf("column %s: decimal(%d,%d) has more than %d fractional digits, which BigQuery cannot load", c.OrigName, c.Precision, c.Scale, bigQueryBigNumericScale))
+		case c.Scale > bigQueryNumericScale || c.Precision-c.Scale > bigQueryNumericIntDigits:
+			warnings = append(warnings, fmt.Sprintf("column %s: decimal(%d,%d) exceeds NUMERIC and needs a BIGNUMERIC column in BigQuery", c.OrigName, c.Precision, c.Scale))
+		}
+	case "json":
+		c.Logical = schema.JSONLogicalType{}
+	case "char", "varchar", "enum", "set":
+		// Without the annotation BigQuery loads BYTES instead of STRING.
+		c.Logical = schema.StringLogicalType{}
+	}
+	return warnings
+}
+
+// ParquetNode returns the Parquet schema node of the column.
+func (c *ColumnSpec) ParquetNode() (schema.Node, error) {
+	if c.Logical != nil {
+		return schema.NewPrimitiveNodeLogical(c.OrigName, c.ParquetRepetition(), c.Logical, c.Type, c.TypeLen, -1)
+	}
+	return schema.NewPrimitiveNodeConverted(
+		c.OrigName,
+		c.ParquetRepetition(),
+		c.Type, c.Converted,
+		c.TypeLen, c.Precision, c.Scale,
+		-1,
+	)
+}
@@ -0,0 +1,101 @@
+package spec
+
+import (
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/parquet"
+)
+
+// Encodings accepted by the encoding= option.
+const (
+	EncodingPlain                = "plain"
+	EncodingDict                 = "dict"
+	EncodingDeltaBinaryPacked    = "delta_binary_packed"
+	EncodingByteStreamSplit      = "byte_stream_split"
+	EncodingDeltaLengthByteArray = "delta_length_byte_array"
+)
+
+// parquetEncodings maps an encoding= value to the physical types it supports,
+// nil meaning all of them.
+var parquetEncodings = map[string][]parquet.Type{
+	EncodingPlain:             nil,
+	EncodingDict:              nil,
+	EncodingDeltaBinaryPacked: {parquet.Types.Int32, parquet.Types.Int64},
+	EncodingByteStreamSplit: {parquet.Types.Int32, parquet.Types.Int64, parquet.Types.Float,
+		parquet.Types.Double, parquet.Types.FixedLenByteArray},
+	EncodingDeltaLengthByteArray: {parquet.Types.ByteArray},
+}
+
+// checkParquetEncoding reports whether encoding can write values of type t.
+func checkParquetEncoding(encoding string, t parquet.Type) error {
+	types, ok := parquetEncodings[encoding]
+	if !ok {
+		return fmt.Errorf("unknown encoding %q, must be plain, dict, delta_binary_packed, byte_stream_split or delta_length_byte_array", encoding)
+	}
+	if types == nil {
+		return nil
+	}
+	for _, supported := range types {
+		if t == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("encoding %s does not support Parquet type %s", encoding, t)
+}
+
+// ParquetEncoding returns the encoding set by the encoding= option and
+// whether it is dictionary encoding. ok is false without the option.
+func (c *ColumnSpec) ParquetEncoding() (encoding parquet.Encoding, dict bool, ok bool) {
+	switch c.Encoding {
+	case EncodingPlain:
+		return parquet.Encodings.Plain, false, true
+	case EncodingDict:
+		return parquet.Encodings.Plain, true, true
+	case EncodingDeltaBinaryPacked:
+		return parquet.Encodings.DeltaBinaryPacked, false, true
+	case EncodingByteStreamSplit:
+		return parquet.Encodings.ByteStreamSplit, false, true
+	case EncodingDeltaLengthByteArray:
+		return parquet.Encodings.DeltaLengthByteArray, false, true
+	default:
+		return parquet.Encodings.Plain, false, false
+	}
+}
+
+// MaxPlainBytes returns the largest PLAIN encoded size of one value of the
+// column, including the length prefix of byte arrays. String sizes are
+// bounded by the column length, which regex values may exceed.
+func (c *ColumnSpec) MaxPlainBytes() int {
+	switch c.Type {
+	case parquet.Types.Boolean:
+		return 1
+	case parquet.Types.Int32, parquet.Types.Float:
+		return 4
+	case parquet.Types.Int64, parquet.Types.Double:
+		return 8
+	case parquet.Types.Int96:
+		return 12
+	case parquet.Types.FixedLenByteArray:
+		return c.TypeLen
+	}
+
+	n := c.TypeLen
+	switch {
+	case c.SQLType == "json":
+		n = len(c.generateJSON(nil))
+	case c.isSetSubset():
+		n = max(len(c.ValueSet)-1, 0)
+		for _, v := range c.ValueSet {
+			n += len(v)
+		}
+	case len(c.ValueSet) > 0:
+		n = 0
+		for _, v := range c.ValueSet {
+			n = max(n, len(v))
+		}
+	}
+	if c.WhitespacePercent > 0 {
+		n += 2 * maxWhitespacePad
+	}
+	return 4 + n
+}
@@ -0,0 +1,119 @@
+package spec
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/apache/arrow-go/v18/parquet"
+)
+
+// ParseParquetValue parses a CSV field formatted like GenerateSingleField
+// into the Parquet value of the column: int32, int64, parquet.Int96, float32,
+// float64, parquet.ByteArray or parquet.FixedLenByteArray. The caller handles
+// NULLs.
+func (c *ColumnSpec) ParseParquetValue(s string) (any, error) {
+	switch c.SQLType {
+	case "decimal":
+		return c.parseDecimal(s)
+	case "date":
+		t, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return nil, err
+		}
+		return daysSinceEpoch(t), nil
+	case "timestamp", "datetime":
+		t, err := time.Parse(time.DateTime, s)
+		if err != nil {
+			return nil, err
+		}
+		if c.TimestampUnit == TimestampInt96 {
+			return Int96FromTime(t), nil
+		}
+		return c.timestampInt64(t), nil
+	case "time":
+		v, err := parseTimeOfDay(s)
+		if err != nil {
+			return nil, err
+		}
+		return c.timeOfDayValue(v), nil
+	}
+
+	switch c.Type {
+	case parquet.Types.Int32:
+		v, err := strconv.ParseInt(s, 10, 64)
+		return int32(v), err
+	case parquet.Types.Int64:
+		return strconv.ParseInt(s, 10, 64)
+	case parquet.Types.Float:
+		v, err := strconv.ParseFloat(s, 32)
+		return float32(v), err
+	case parquet.Types.Double:
+		return strconv.ParseFloat(s, 64)
+	case parquet.Types.ByteArray:
+		return parquet.ByteArray(s), nil
+	default:
+		return nil, fmt.Errorf("unsupported parquet type %v for column %s", c.Type, c.OrigName)
+	}
+}
+
+// parseDecimal converts a decimal string into an unscaled value with the
+// column's scale, rounding extra digits with the column's rounding mode.
+func (c *ColumnSpec) parseDecimal(s string) (any, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt(pow10(c.Scale)))
+	unscaled := roundRat(r, c.Rounding)
+
+	switch c.Type {
+	case parquet.Types.Int32:
+		if unscaled.BitLen() > 31 {
+			return nil, fmt.Errorf("decimal %q out of range", s)
+		}
+		return int32(unscaled.Int64()), nil
+	case parquet.Types.Int64:
+		if !unscaled.IsInt64() {
+			return nil, fmt.Errorf("decimal %q out of range", s)
+		}
+		return unscaled.Int64(), nil
+	case parquet.Types.FixedLenByteArray:
+		if unscaled.BitLen() >= 8*c.TypeLen {
+			return nil, fmt.Errorf("decimal %q out of range", s)
+		}
+		return fixedLenDecimalFromBig(unscaled, c.TypeLen), nil
+	default:
+		return nil, fmt.Errorf("unsupported decimal parquet type: %v", c.Type)
+	}
+}
+
+// CheckValue reports whether a field accepted by ParseParquetValue also fits
+// the declared type: integers within the range of their width, decimals
+// within their precision and scale.
+func (c *ColumnSpec) CheckValue(s string) error {
+	switch {
+	case isIntegerType(c.SQLType):
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		if lower, upper := c.intTypeRange(); v < lower || v > upper {
+			return fmt.Errorf("%d out of range for %s", v, c.SQLType)
+		}
+	case c.SQLType == "decimal":
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return fmt.Errorf("invalid decimal %q", s)
+		}
+		r.Mul(r, new(big.Rat).SetInt(pow10(c.Scale)))
+		if !r.IsInt() {
+			return fmt.Errorf("decimal %q has more than %d fractional digits", s, c.Scale)
+		}
+		if new(big.Int).Abs(r.Num()).Cmp(pow10(c.Precision)) >= 0 {
+			return fmt.Errorf("decimal %q exceeds precision %d", s, c.Precision)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package spec
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp/syntax"
+	"unicode/utf8"
+)
+
+// maxRegexRepeat bounds the repeats of *, + and {n,}.
+const maxRegexRepeat = 8
+
+// printableASCII is the range random characters are drawn from when a
+// character class allows it.
+var printableASCII = []rune{0x20, 0x7e}
+
+// compileRegex parses a regex= pattern and checks that strings can be
+// generated from it.
+func compileRegex(pattern string) (*syntax.Regexp, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRegex(re); err != nil {
+		return nil, err
+	}
+	return re, nil
+}
+
+func checkRegex(re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpLiteral, syntax.OpCharClass,
+		syntax.OpAnyChar, syntax.OpAnyCharNotNL,
+		syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary,
+		syntax.OpCapture, syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat,
+		syntax.OpConcat, syntax.OpAlternate:
+	default:
+		return fmt.Errorf("unsupported regex construct %q", re.String())
+	}
+	if re.Op == syntax.OpCharClass && len(re.Rune) == 0 {
+		return fmt.Errorf("regex class %q matches nothing", re.String())
+	}
+	for _, sub := range re.Sub {
+		if err := checkRegex(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateRegex appends a random string matching re to b. Anchors and word
+// boundaries are ignored.
+func generateRegex(re *syntax.Regexp, b []byte, rng *rand.Rand) []byte {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			b = utf8.AppendRune(b, r)
+		}
+	case syntax.OpCharClass:
+		b = utf8.AppendRune(b, pickClassRune(re.Rune, rng))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b = utf8.AppendRune(b, pickClassRune(printableASCII, rng))
+	case syntax.OpCapture:
+		b = generateRegex(re.Sub[0], b, rng)
+	case syntax.OpStar:
+		b = repeatRegex(re.Sub[0], 0, maxRegexRepeat, b, rng)
+	case syntax.OpPlus:
+		b = repeatRegex(re.Sub[0], 1, maxRegexRepeat, b, rng)
+	case syntax.OpQuest:
+		b = repeatRegex(re.Sub[0], 0, 1, b, rng)
+	case syntax.OpRepeat:
+		hi := re.Max
+		if hi < 0 {
+			hi = re.Min + maxRegexRepeat
+		}
+		b = repeatRegex(re.Sub[0], re.Min, hi, b, rng)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			b = generateRegex(sub, b, rng)
+		}
+	case syntax.OpAlternate:
+		b = generateRegex(re.Sub[rng.Intn(len(re.Sub))], b, rng)
+	}
+	return b
+}
+
+func repeatRegex(re *syntax.Regexp, lo, hi int, b []byte, rng *rand.Rand) []byte {
+	for range lo + rng.Intn(hi-lo+1) {
+		b = generateRegex(re, b, rng)
+	}
+	return b
+}
+
+// pickClassRune draws a rune from the ranges of a character class, preferring
+// printable ASCII so negated classes like \D stay readable.
+func pickClassRune(ranges []rune, rng *rand.Rand) rune {
+	if ascii := intersectRanges(ranges, printableASCII); len(ascii) > 0 {
+		ranges = ascii
+	}
+	total := 0
+	for i := 0; i < len(ranges); i += 2 {
+		total += int(ranges[i+1]-ranges[i]) + 1
+	}
+	n := rng.Intn(total)
+	for i := 0; i < len(ranges); i += 2 {
+		size := int(ranges[i+1]-ranges[i]) + 1
+		if n < size {
+			return ranges[i] + rune(n)
+		}
+		n -= size
+	}
+	return ranges[0]
+}
+
+// intersectRanges returns the parts of the [lo, hi] pairs in ranges that fall
+// in the single pair bound.
+func intersectRanges(ranges, bound []rune) []rune {
+	var out []rune
+	for i := 0; i < len(ranges); i += 2 {
+		lo, hi := max(ranges[i], bound[0]), min(ranges[i+1], bound[1])
+		if lo <= hi {
+			out = append(out, lo, hi)
+		}
+	}
+	return out
+}
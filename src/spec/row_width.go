@@ -0,0 +1,24 @@
+package spec
+
+import "math/rand"
+
+// isWideRow reports whether rowID is a wide row of the row width profile,
+// which makes WidePercent% of the rows wide, with every string column at its
+// maximum length, and the other rows narrow, with every string column at its
+// minimum length. It only depends on the row ID and the run seed, so all
+// columns of a row agree.
+func (r *RunContext) isWideRow(rowID int) bool {
+	s := splitmixSource{state: r.Seed ^ (uint64(rowID) * 0xff51afd7ed558ccd)}
+	return int(s.Uint64()%100) < r.WidePercent
+}
+
+// stringLength returns the length of a random string value of rowID.
+func (c *ColumnSpec) stringLength(rowID int, rng *rand.Rand) int {
+	if run := c.runContext(); run.WidePercent >= 0 {
+		if run.isWideRow(rowID) {
+			return c.TypeLen
+		}
+		return c.MinLen
+	}
+	return rng.Intn(c.TypeLen-c.MinLen+1) + c.MinLen
+}
@@ -0,0 +1,56 @@
+package spec
+
+import "time"
+
+// RunContext holds the settings every column of a generation run shares. The
+// orchestrator builds one per run and sets it on its specs with
+// SetRunContext, so runs in one process don't see each other's settings.
+type RunContext struct {
+	// Seed is mixed into the random sources derived from row IDs, e.g. of
+	// run_length columns, so runs differ unless it comes from common.seed.
+	Seed uint64
+	// ReferenceTime is the upper bound of generated time values. It is in
+	// UTC so the wall clock written to CSV is the instant Parquet stores as
+	// UTC micros.
+	ReferenceTime time.Time
+	// WidePercent is the percentage of wide rows of the row width profile,
+	// or -1 if there is no profile and string lengths are uniform.
+	WidePercent int
+	// Layout locates rows in files and folders.
+	Layout SequenceLayout
+}
+
+// NewRunContext returns the settings of an unseeded run without row width
+// profile or layout.
+func NewRunContext() *RunContext {
+	now := time.Now()
+	return &RunContext{
+		Seed:          uint64(now.UnixNano()),
+		ReferenceTime: now.UTC(),
+		WidePercent:   -1,
+	}
+}
+
+// defaultRunContext is used by specs never given a run, e.g. by convert.
+var defaultRunContext = NewRunContext()
+
+// SetRunContext makes specs generate with the settings of run and draws the
+// dict_cardinality pools, which depend on its seed. It must be called before
+// generation starts.
+func SetRunContext(specs []*ColumnSpec, run *RunContext) error {
+	for _, c := range specs {
+		c.run = run
+		if err := c.initDictPool(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runContext returns the run of the column.
+func (c *ColumnSpec) runContext() *RunContext {
+	if c.run == nil {
+		return defaultRunContext
+	}
+	return c.run
+}
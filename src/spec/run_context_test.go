@@ -0,0 +1,54 @@
+package spec
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func runLengthValues(c *ColumnSpec, rows int) []string {
+	rng := rand.New(rand.NewSource(1))
+	values := make([]string, rows)
+	for i := range values {
+		values[i] = GenerateSingleField(i, c, rng)
+	}
+	return values
+}
+
+func TestRunContextIsPerSpec(t *testing.T) {
+	const column = "v bigint COMMENT 'run_length=10'"
+	a, b, c := testSpec(t, column), testSpec(t, column), testSpec(t, column)
+	SetRunContext([]*ColumnSpec{a}, &RunContext{Seed: 1, WidePercent: -1})
+	SetRunContext([]*ColumnSpec{b}, &RunContext{Seed: 2, WidePercent: -1})
+	SetRunContext([]*ColumnSpec{c}, &RunContext{Seed: 1, WidePercent: -1})
+
+	va, vb, vc := runLengthValues(a, 200), runLengthValues(b, 200), runLengthValues(c, 200)
+	same := 0
+	for i := range va {
+		if va[i] != vc[i] {
+			t.Fatalf("row %d: runs with the same seed differ, %s and %s", i, va[i], vc[i])
+		}
+		if va[i] == vb[i] {
+			same++
+		}
+	}
+	if same == len(va) {
+		t.Error("runs with different seeds generate the same values")
+	}
+}
+
+func TestRunContextReferenceTime(t *testing.T) {
+	ref := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	seeded := testSpec(t, "ts datetime")
+	SetRunContext([]*ColumnSpec{seeded}, &RunContext{ReferenceTime: ref, WidePercent: -1})
+	unseeded := testSpec(t, "ts datetime")
+
+	start, end := seeded.timeWindow()
+	if !end.Equal(ref) || !start.Equal(ref.AddDate(-1, 0, 0)) {
+		t.Errorf("window of the run = [%s, %s), want the year before %s", start, end, ref)
+	}
+	// A spec without run keeps the default, the other run doesn't leak.
+	if _, end := unseeded.timeWindow(); end.Equal(ref) {
+		t.Errorf("spec without run uses the reference time of another run")
+	}
+}
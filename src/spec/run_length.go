@@ -0,0 +1,55 @@
+package spec
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// splitmixSource is a cheap rand.Source64 used to draw the value of a run.
+type splitmixSource struct {
+	state uint64
+}
+
+func (s *splitmixSource) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+func (s *splitmixSource) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func (s *splitmixSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// valueRand returns the random source for the value of rowID. With
+// run_length, all rows of a run of RunLength consecutive row IDs share a
+// source derived from the run number, so they get the same value no matter
+// how rows are split into files and batches.
+func (c *ColumnSpec) valueRand(rowID int, rng *rand.Rand) *rand.Rand {
+	if c.RunLength <= 1 {
+		return rng
+	}
+	run := uint64(rowID / c.RunLength)
+	return rand.New(&splitmixSource{state: c.runContext().Seed ^ c.runSalt ^ (run * 0xff51afd7ed558ccd)})
+}
+
+// isRunLengthSupported reports whether run_length applies to sqlType.
+func isRunLengthSupported(sqlType string) bool {
+	switch sqlType {
+	case "tinyint", "smallint", "mediumint", "int", "bigint":
+		return true
+	}
+	return isStringType(sqlType)
+}
+
+// columnSalt separates the runs of different columns.
+func columnSalt(name string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return h.Sum64()
+}
@@ -0,0 +1,48 @@
+package spec
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRunLengthMeasured(t *testing.T) {
+	const rows, runLength = 10_000, 7
+	for _, column := range []string{
+		"v int NOT NULL COMMENT 'run_length=7'",
+		"v varchar(20) NOT NULL COMMENT 'run_length=7'",
+	} {
+		c := testSpec(t, column)
+		rng := rand.New(rand.NewSource(1))
+		values := make([]string, rows)
+		for i := range values {
+			values[i] = GenerateSingleField(i, c, rng)
+		}
+
+		// Measure the runs: every run starts at a multiple of run_length,
+		// and only runs of equal neighbours merge.
+		runs, mismatches := 1, 0
+		for i := 1; i < rows; i++ {
+			if values[i] == values[i-1] {
+				continue
+			}
+			runs++
+			if i%runLength != 0 {
+				mismatches++
+			}
+		}
+		if mismatches > 0 {
+			t.Errorf("%s: %d value changes inside a run", column, mismatches)
+		}
+		if avg := float64(rows) / float64(runs); avg < runLength*0.95 || avg > runLength*1.1 {
+			t.Errorf("%s: average run length %.2f, want about %d", column, avg, runLength)
+		}
+
+		// Rows generated on their own, e.g. at the start of another file,
+		// get the value of their run.
+		for _, rowID := range []int{3, 700, 9999} {
+			if got := GenerateSingleField(rowID, c, rng); got != values[rowID] {
+				t.Errorf("%s: row %d = %q alone, %q in order", column, rowID, got, values[rowID])
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package spec
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+)
+
+// DecimalMode decides how the values of a decimal column are generated.
+type DecimalMode int
+
+const (
+	// DecimalRandom draws every value independently.
+	DecimalRandom DecimalMode = iota
+	// DecimalRunningBalance adds a random delta to the previous row's value,
+	// like the balance of a ledger.
+	DecimalRunningBalance
+)
+
+// defaultBalanceDelta bounds the deltas of a running balance, in column
+// units, when delta_min/delta_max are not set.
+const defaultBalanceDelta = 100
+
+// balanceState remembers the last balance computed in each file, so rows
+// generated in order only add one delta.
+type balanceState struct {
+	mu   sync.Mutex
+	last map[int]balanceEntry // keyed by the first row of the file
+}
+
+type balanceEntry struct {
+	rowID   int
+	balance int64
+}
+
+// parseBalanceValue parses a decimal option into an unscaled value at the
+// column's scale.
+func (c *ColumnSpec) parseBalanceValue(k, v string) (int64, error) {
+	r, ok := new(big.Rat).SetString(v)
+	if !ok {
+		return 0, fmt.Errorf("invalid %s for column %s: %q", k, c.OrigName, v)
+	}
+	r.Mul(r, new(big.Rat).SetInt(pow10(c.Scale)))
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return 0, fmt.Errorf("invalid %s for column %s: %q, must fit the column's scale %d", k, c.OrigName, v, c.Scale)
+	}
+	return r.Num().Int64(), nil
+}
+
+// balanceDelta returns the delta added at rowID, picked from the row ID and
+// the run seed.
+func (c *ColumnSpec) balanceDelta(rowID int) int64 {
+	s := splitmixSource{state: c.runContext().Seed ^ c.runSalt ^ (uint64(rowID) * 0xd6e8feb86659fd93)}
+	return c.BalanceDeltaMin + int64(s.Uint64()%uint64(c.BalanceDeltaMax-c.BalanceDeltaMin+1))
+}
+
+// runningBalance returns the unscaled balance of rowID. The first row of
+// every file holds balance_start and each later row adds its delta to the
+// row before. Rows generated out of order, e.g. by concurrent row groups,
+// sum the deltas from the start of the file again.
+func (c *ColumnSpec) runningBalance(rowID int) int64 {
+	layout := &c.runContext().Layout
+	fileStart := rowID - layout.rowInFile(rowID)
+	c.balance.mu.Lock()
+	e, ok := c.balance.last[fileStart]
+	c.balance.mu.Unlock()
+	if !ok || e.rowID > rowID {
+		e = balanceEntry{rowID: fileStart, balance: c.BalanceStart}
+	}
+	for r := e.rowID + 1; r <= rowID; r++ {
+		e.balance += c.balanceDelta(r)
+	}
+	e.rowID = rowID
+
+	c.balance.mu.Lock()
+	if layout.RowsPerFile > 0 && layout.rowInFile(rowID) == layout.rowsOfFile(rowID)-1 {
+		delete(c.balance.last, fileStart)
+	} else {
+		c.balance.last[fileStart] = e
+	}
+	c.balance.mu.Unlock()
+	return e.balance
+}
+
+// MaxBalance returns the largest magnitude the unscaled balance of a file
+// with rows rows can reach.
+func (c *ColumnSpec) MaxBalance(rows int) *big.Int {
+	step := max(abs64(c.BalanceDeltaMin), abs64(c.BalanceDeltaMax))
+	v := new(big.Int).Mul(big.NewInt(step), big.NewInt(int64(max(rows-1, 0))))
+	return v.Add(v, big.NewInt(abs64(c.BalanceStart)))
+}
+
+// BalanceFits reports whether every balance of a file with rows rows fits the
+// column's precision and an int64.
+func (c *ColumnSpec) BalanceFits(rows int) bool {
+	v := c.MaxBalance(rows)
+	return v.Cmp(c.unscaledBound) < 0 && v.Cmp(big.NewInt(math.MaxInt64)) <= 0
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// checkDecimalMode validates decimal_mode and its options.
+func (c *ColumnSpec) checkDecimalMode(hasBalanceOpts bool) error {
+	if c.DecimalMode == DecimalRandom {
+		if hasBalanceOpts {
+			return fmt.Errorf("balance_start/delta_min/delta_max require decimal_mode=running_balance for column %s", c.OrigName)
+		}
+		return nil
+	}
+	if c.SQLType != "decimal" {
+		return fmt.Errorf("decimal_mode is only supported for decimal columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if len(c.IntSet) > 0 || len(c.Histogram) > 0 || c.StdDev > 0 || c.DecimalRange > 0 {
+		return fmt.Errorf("decimal_mode=running_balance cannot be combined with set, histogram, mean/stddev or decimal_range for column %s", c.OrigName)
+	}
+	if c.BalanceDeltaMin > c.BalanceDeltaMax {
+		return fmt.Errorf("delta_min must not be greater than delta_max for column %s", c.OrigName)
+	}
+	return nil
+}
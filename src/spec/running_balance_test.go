@@ -0,0 +1,82 @@
+package spec
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestRunningBalanceIsCumulative(t *testing.T) {
+	c := testSpec(t, "b decimal(12,2) COMMENT 'decimal_mode=running_balance, balance_start=100.50, delta_min=-5, delta_max=7.25'")
+	const rowsPerFile = 200
+	if err := SetRunContext([]*ColumnSpec{c}, &RunContext{
+		Seed:        1,
+		WidePercent: -1,
+		Layout:      SequenceLayout{RowsPerFile: rowsPerFile, LastFile: 1, LastFileRows: rowsPerFile},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	minDelta, maxDelta := big.NewRat(-5, 1), big.NewRat(725, 100)
+	var prev *big.Rat
+	for rowID := range 2 * rowsPerFile {
+		s := GenerateRawField(rowID, c, rng)
+		v, ok := new(big.Rat).SetString(s)
+		if !ok {
+			t.Fatalf("row %d: %q is not a decimal", rowID, s)
+		}
+		if rowID%rowsPerFile == 0 {
+			// Every file starts again at balance_start.
+			if v.Cmp(big.NewRat(10050, 100)) != 0 {
+				t.Fatalf("row %d: first balance of the file is %s, want 100.50", rowID, s)
+			}
+		} else {
+			delta := new(big.Rat).Sub(v, prev)
+			if delta.Cmp(minDelta) < 0 || delta.Cmp(maxDelta) > 0 {
+				t.Fatalf("row %d: delta %s is outside [-5, 7.25]", rowID, delta.FloatString(2))
+			}
+			want := new(big.Rat).Add(prev, new(big.Rat).SetFrac64(c.balanceDelta(rowID), 100))
+			if v.Cmp(want) != 0 {
+				t.Fatalf("row %d: balance %s, want previous balance plus delta %s", rowID, s, want.FloatString(2))
+			}
+		}
+		prev = v
+	}
+}
+
+func TestRunningBalanceOutOfOrder(t *testing.T) {
+	c := testSpec(t, "b decimal(12,2) COMMENT 'decimal_mode=running_balance'")
+	if err := SetRunContext([]*ColumnSpec{c}, &RunContext{
+		Seed:        7,
+		WidePercent: -1,
+		Layout:      SequenceLayout{RowsPerFile: 100, LastFile: 0, LastFileRows: 100},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	inOrder := make([]string, 100)
+	for i := range inOrder {
+		inOrder[i] = GenerateRawField(i, c, rng)
+	}
+	// Concurrent row groups generate later rows first.
+	for _, rowID := range []int{99, 50, 10, 75} {
+		if got := GenerateRawField(rowID, c, rng); got != inOrder[rowID] {
+			t.Errorf("row %d generated out of order = %s, in order %s", rowID, got, inOrder[rowID])
+		}
+	}
+}
+
+func TestValidateOutputRunningBalanceFits(t *testing.T) {
+	c := testSpec(t, "b decimal(5,2) COMMENT 'decimal_mode=running_balance, delta_min=-10, delta_max=10'")
+	// 999.99 is the largest balance, 100 rows of 10 stay within it.
+	if err := ValidateOutput([]*ColumnSpec{c}, OutputLayout{Format: "csv", RowsPerFile: 100, MaxRowsPerFile: 100}); err != nil {
+		t.Errorf("100 rows: %v", err)
+	}
+	if err := ValidateOutput([]*ColumnSpec{c}, OutputLayout{Format: "csv", RowsPerFile: 100, MaxRowsPerFile: 101}); err == nil {
+		t.Error("101 rows can reach 1000.00, which does not fit decimal(5,2)")
+	}
+	if err := ValidateOutput([]*ColumnSpec{c}, OutputLayout{Format: "parquet", RowsPerFile: 100, MaxRowsPerFile: 100, TargetSize: true}); err == nil {
+		t.Error("running balance with target_compressed_size is accepted")
+	}
+}
@@ -0,0 +1,72 @@
+package spec
+
+// SequenceScope decides where an order=sequence column starts over.
+type SequenceScope int
+
+const (
+	// SequenceGlobal counts 1, 2, 3... across the run in generation order.
+	SequenceGlobal SequenceScope = iota
+	// SequenceFile counts the rows of each file from 0.
+	SequenceFile
+	// SequencePartition counts the rows of each folder from 0, file after
+	// file in file number order.
+	SequencePartition
+)
+
+func (s SequenceScope) String() string {
+	switch s {
+	case SequenceFile:
+		return "file"
+	case SequencePartition:
+		return "partition"
+	default:
+		return "global"
+	}
+}
+
+// SequenceLayout describes how rows are split into files and folders, for
+// sequence_scope=file|partition, dup_key_percent and null_count. The last
+// file holds the extra rows of common.total_rows. The zero value is an
+// unknown layout.
+type SequenceLayout struct {
+	RowsPerFile  int
+	LastFile     int
+	LastFileRows int
+	// PartitionOffset returns the rows of the earlier files of fileNo's folder.
+	PartitionOffset func(fileNo int) int
+}
+
+// sequenceValue returns the value of an order=sequence column for rowID.
+func (c *ColumnSpec) sequenceValue(rowID int) int {
+	layout := &c.runContext().Layout
+	if c.SequenceScope == SequenceGlobal || layout.RowsPerFile <= 0 {
+		return int(c.sequence.Add(1))
+	}
+	v := layout.rowInFile(rowID)
+	if c.SequenceScope == SequencePartition {
+		v += layout.PartitionOffset(layout.fileOfRow(rowID))
+	}
+	return v
+}
+
+// fileOfRow returns the file number of rowID.
+func (l *SequenceLayout) fileOfRow(rowID int) int {
+	return min(rowID/l.RowsPerFile, l.LastFile)
+}
+
+// rowsOfFile returns the number of rows of rowID's file.
+func (l *SequenceLayout) rowsOfFile(rowID int) int {
+	if l.fileOfRow(rowID) == l.LastFile {
+		return l.LastFileRows
+	}
+	return l.RowsPerFile
+}
+
+// rowInFile returns the position of rowID in its file, or rowID itself if
+// the layout is unknown.
+func (l *SequenceLayout) rowInFile(rowID int) int {
+	if l.RowsPerFile <= 0 {
+		return rowID
+	}
+	return rowID - l.fileOfRow(rowID)*l.RowsPerFile
+}
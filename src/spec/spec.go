@@ -4,9 +4,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"os"
+	"regexp"
+	"regexp/syntax"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
+
+	"dataWriter/src/util/logger"
 
 	"github.com/apache/arrow-go/v18/parquet"
 	"github.com/apache/arrow-go/v18/parquet/schema"
@@ -15,7 +24,9 @@ import (
 	"github.com/pingcap/tidb/pkg/meta/model"
 	"github.com/pingcap/tidb/pkg/parser"
 	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/charset"
 	"github.com/pingcap/tidb/pkg/parser/mysql"
+	parsertypes "github.com/pingcap/tidb/pkg/parser/types"
 	_ "github.com/pingcap/tidb/pkg/planner/core" // to setup expression.EvalSimpleAst for in core_init
 	"github.com/pingcap/tidb/pkg/types"
 
@@ -26,6 +37,16 @@ import (
 // validChar is a set of characters used to generate random strings.
 const validChar = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_&*!.;<>?:-+()[]{}"
 
+// unicodeChars is a curated set of multibyte code points used by
+// charset=unicode: accented Latin, Greek, Cyrillic, CJK, Hangul and emoji.
+var unicodeChars = []rune("éèêëàâäçñöüßøåÉÑÜ" +
+	"αβγδλπΩ" + "дежзийяЖЯ" +
+	"数据测试中文字符表格日本語東京" + "한국어데이터" +
+	"😀😂🚀🎉🌍🔥✅❤")
+
+// defaultWhitespaceChars is used to pad values when whitespace_percent is set.
+const defaultWhitespaceChars = " \t"
+
 // NumericOrder defines the order of numeric data in a column.
 type NumericOrder int
 
@@ -33,6 +54,70 @@ const (
 	NumericTotalOrder NumericOrder = iota
 	NumericPartialOrder
 	NumericRandomOrder
+	// CycleOrder walks through the set in order, row N gets set[N % len(set)].
+	CycleOrder
+	// SequenceOrder takes values 1, 2, 3... from a counter shared by all
+	// threads, in the order rows are generated.
+	SequenceOrder
+)
+
+// UniqueScope defines where values of a unique column are guaranteed unique.
+type UniqueScope int
+
+const (
+	// UniqueScopeFile is the default, random unique values (e.g. UUIDs) are
+	// not reproducible and may in theory repeat across files.
+	UniqueScopeFile UniqueScope = iota
+	// UniqueScopeGlobal derives values from the global row ID
+	// (fileNo*rows + localRow), so they never repeat in the whole dataset.
+	UniqueScopeGlobal
+)
+
+// Charset defines which characters generated strings are made of.
+type Charset int
+
+const (
+	// CharsetASCII uses validChar only.
+	CharsetASCII Charset = iota
+	// CharsetUnicode mixes in multibyte UTF-8 characters from unicodeChars.
+	CharsetUnicode
+	// CharsetHex uses lowercase hex digits.
+	CharsetHex
+	// CharsetAlnum uses digits and ASCII letters.
+	CharsetAlnum
+	// CharsetLower uses lowercase ASCII letters.
+	CharsetLower
+	// CharsetUpper uses uppercase ASCII letters.
+	CharsetUpper
+	// CharsetCustom uses the characters listed in the charset option.
+	CharsetCustom
+)
+
+// SetDistribution defines how values are picked from a set.
+type SetDistribution int
+
+const (
+	// SetUniform picks every value with the same probability.
+	SetUniform SetDistribution = iota
+	// SetZipf picks the i-th value with probability proportional to
+	// 1/(1+i)^ZipfS, so the first values dominate.
+	SetZipf
+)
+
+// defaultZipfS is the Zipf exponent used when zipf_s is not set.
+const defaultZipfS = 1.1
+
+// Rounding defines how decimal values drawn from a distribution are rounded
+// to the column's scale.
+type Rounding int
+
+const (
+	// RoundHalfEven rounds ties to the even neighbor (banker's rounding).
+	RoundHalfEven Rounding = iota
+	// RoundHalfUp rounds ties away from zero.
+	RoundHalfUp
+	// RoundTruncate drops the extra digits, rounding toward zero.
+	RoundTruncate
 )
 
 // ColumnSpec defines the properties of a column to generate
@@ -41,22 +126,148 @@ type ColumnSpec struct {
 	SQLType   string               // type in SQL, e.g., "int", "varchar"
 	Type      parquet.Type         // used for parquet file
 	Converted schema.ConvertedType // used for parquet file
+	Logical   schema.LogicalType   // overrides Converted when set, see ApplyParquetDialect
+	// Encoding is the Parquet encoding set by the encoding= option, empty
+	// to let the generator choose.
+	Encoding string
 
 	TypeLen   int // length of the type, e.g., 64 for bigint, 32 for int
 	MinLen    int // minimum length for string types, defaults to TypeLen * 0.75
-	Precision int // used for decimal type, not implemented yet
-	Scale     int // used for decimal type, not implemented yet
+	Precision int // total decimal digits of decimal(p,s), also for decimal strings
+	Scale     int // digits after the decimal point of decimal(p,s)
+	FSP       int // fractional seconds precision for time types, 0-6
 
 	// Below are used for generate specified data
 	NullPercent int
+	// NullCount is the exact number of NULLs of each file, replacing
+	// NullPercent.
+	NullCount   int
 	ValueSet    []string
 	IntSet      []int64
 	IsUnique    bool
+	UniqueScope UniqueScope
 	Order       NumericOrder
 	Mean        int
 	StdDev      int
 	Signed      bool
 	Compress    int
+	Charset     Charset
+	// chars and charRunes are the alphabet of Charset, charRunes is only
+	// set for a custom charset with multibyte characters.
+	chars     string
+	charRunes []rune
+
+	// MinValue and MaxValue bound integer values when HasRange is set.
+	MinValue int64
+	MaxValue int64
+	HasRange bool
+	// IsForeignKey marks [MinValue, MaxValue] as the key space of a parent
+	// table, set by fk or fk_range.
+	IsForeignKey bool
+	// FKRef is the table.col of fk=table.col, whose key space is set by
+	// ResolveFKRefs.
+	FKRef string
+	// KeysFollowRows is set by ResolveFKRefs when an fk=table.col column
+	// draws from this column's values, which follow the row layout.
+	KeysFollowRows bool
+
+	// FloatMin and FloatMax bound float values when HasFloatRange is set.
+	FloatMin      float64
+	FloatMax      float64
+	HasFloatRange bool
+	// FloatDist decides how float values are drawn.
+	FloatDist FloatDistribution
+	// FloatFormat and FloatPrecision are the strconv.FormatFloat verb and
+	// precision of float values written as text, FloatFormat is 0 when
+	// float_format is not set.
+	FloatFormat    byte
+	FloatPrecision int
+
+	// DecimalMode decides how decimal values are generated. A running
+	// balance starts every file at BalanceStart and adds a delta from
+	// [BalanceDeltaMin, BalanceDeltaMax] per row, all unscaled.
+	DecimalMode     DecimalMode
+	BalanceStart    int64
+	BalanceDeltaMin int64
+	BalanceDeltaMax int64
+	balance         *balanceState
+
+	// DecimalRange bounds the magnitude of generated decimal values.
+	DecimalRange float64
+	// Rounding is used when scaling decimals generated from mean/stddev.
+	Rounding Rounding
+	// NumberFormat is applied to numeric values written to CSV and to
+	// format=decimal strings.
+	NumberFormat NumberFormat
+
+	// Dist and ZipfS decide how values are picked from ValueSet or IntSet.
+	Dist  SetDistribution
+	ZipfS float64
+
+	// Histogram draws values from weighted buckets.
+	Histogram []HistogramBucket
+
+	// Required writes the column as a required Parquet field, it never has
+	// NULLs.
+	Required bool
+
+	// RunLength repeats each value for this many consecutive rows.
+	RunLength int
+	runSalt   uint64
+
+	// DupKeyPercent is the percentage of rows of a unique column that repeat
+	// an earlier key, deliberately violating the constraint.
+	DupKeyPercent float64
+	// CaseVariantsPercent is the percentage of rows of a string column that
+	// repeat an earlier value with different letter case.
+	CaseVariantsPercent float64
+	// TypeNoisePercent is the percentage of rows of a numeric or time column
+	// written to CSV as a token that does not parse as the column's type.
+	TypeNoisePercent float64
+	// GapPercent is the percentage of values of a monotonic integer column
+	// followed by skipped values.
+	GapPercent float64
+
+	// DictCardinality draws the values from a pool of exactly this many
+	// distinct values, for dictionary encoding benchmarks.
+	DictCardinality int
+	dictKeys        []int
+	dictMul         uint64
+	dictAdd         uint64
+
+	// Regex generates string values matching the pattern.
+	Regex string
+	regex *syntax.Regexp
+	// Faker generates realistic looking values, e.g. emails or city names.
+	Faker Faker
+	// StringFormat writes string values in a format, e.g. decimals of
+	// Precision and Scale.
+	StringFormat StringFormat
+
+	// sequence is the last value of an order=sequence column with global
+	// scope.
+	sequence      *atomic.Int64
+	SequenceScope SequenceScope
+
+	// DateStart and DateEnd bound generated time values, a zero value means
+	// the default window of the year before the reference time.
+	DateStart time.Time
+	DateEnd   time.Time
+	// TimeProfile weights generated times, e.g. toward business hours.
+	TimeProfile TimeProfile
+	// TimestampUnit is the Parquet type of timestamp and datetime values.
+	TimestampUnit TimestampUnit
+
+	// WhitespacePercent is the percentage of string values padded with
+	// leading and/or trailing whitespace drawn from WhitespaceChars.
+	WhitespacePercent int
+	WhitespaceChars   string
+
+	unscaledBound *big.Int // exclusive bound of unscaled decimal magnitude
+
+	// run holds the settings of the run generating the column, see
+	// SetRunContext.
+	run *RunContext
 }
 
 func splitCommentOpts(comment string) ([]string, error) {
@@ -79,9 +290,14 @@ func splitCommentOpts(comment string) ([]string, error) {
 			if !inQuotes {
 				bracketDepth--
 				if bracketDepth < 0 {
-					return nil, fmt.Errorf("malformed comment: %q", comment)
+					return nil, fmt.Errorf("%w: %q", ErrMalformedComment, comment)
 				}
 			}
+		case ')':
+			// Closes a half-open range like [0,10) at the end of an option.
+			if !inQuotes && bracketDepth == 1 && (i+1 == len(comment) || comment[i+1] == ',') {
+				bracketDepth--
+			}
 		case ',':
 			if !inQuotes && bracketDepth == 0 {
 				opt := comment[start:i]
@@ -94,7 +310,7 @@ func splitCommentOpts(comment string) ([]string, error) {
 	}
 
 	if inQuotes || bracketDepth != 0 {
-		return nil, fmt.Errorf("malformed comment: %q", comment)
+		return nil, fmt.Errorf("%w: %q", ErrMalformedComment, comment)
 	}
 
 	if start < len(comment) {
@@ -120,15 +336,28 @@ func (c *ColumnSpec) parseComment(comment string) error {
 		return err
 	}
 
+	var hasMin, hasMax, hasFloatMin, hasFloatMax, hasSequenceScope, hasFKRange, hasFK bool
+	var fkLow, fkHigh int64
+	var timestampUnit *TimestampUnit
+	var hasPrecision, hasScale bool
+	var hasBalanceOpts, hasDeltaMin, hasDeltaMax bool
+
 	for _, opt := range opts {
 		s := strings.SplitN(opt, "=", 2)
 		if len(s) != 2 {
-			return fmt.Errorf("malformed comment option: %q", opt)
+			return fmt.Errorf("%w option: %q", ErrMalformedComment, opt)
 		}
 		k, v := s[0], s[1]
 		switch k {
 		case "null_percent":
 			c.NullPercent, _ = strconv.Atoi(v)
+		case "null_count":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid null_count for column %s: %q, must be >= 1", c.OrigName, v)
+			}
+			c.NullCount = n
+			c.runSalt = columnSalt(c.OrigName)
 		case "max_length":
 			c.TypeLen, _ = strconv.Atoi(v)
 		case "min_length":
@@ -137,6 +366,242 @@ func (c *ColumnSpec) parseComment(comment string) error {
 			c.Mean, _ = strconv.Atoi(v)
 		case "stddev":
 			c.StdDev, _ = strconv.Atoi(v)
+		case "fsp":
+			fsp, err := strconv.Atoi(v)
+			if err != nil || fsp < 0 || fsp > 6 {
+				return fmt.Errorf("invalid fsp for column %s: %q", c.OrigName, v)
+			}
+			c.FSP = fsp
+		case "decimal_range":
+			r, err := strconv.ParseFloat(v, 64)
+			if err != nil || r <= 0 {
+				return fmt.Errorf("invalid decimal_range for column %s: %q", c.OrigName, v)
+			}
+			c.DecimalRange = r
+		case "min", "max":
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s for column %s: %q", k, c.OrigName, v)
+			}
+			if k == "min" {
+				c.MinValue, hasMin = n, true
+			} else {
+				c.MaxValue, hasMax = n, true
+			}
+		case "fk_range":
+			fkLow, fkHigh, err = parseFKRange(v)
+			if err != nil {
+				return fmt.Errorf("invalid fk_range for column %s: %q, %v", c.OrigName, v, err)
+			}
+			fkHigh--
+			hasFKRange = true
+		case "fk":
+			fkLow, fkHigh, c.FKRef, err = parseFK(v)
+			if err != nil {
+				return fmt.Errorf("invalid fk for column %s: %q, %v", c.OrigName, v, err)
+			}
+			hasFK = true
+		case "float_min", "float_max":
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+				return fmt.Errorf("invalid %s for column %s: %q", k, c.OrigName, v)
+			}
+			if k == "float_min" {
+				c.FloatMin, hasFloatMin = f, true
+			} else {
+				c.FloatMax, hasFloatMax = f, true
+			}
+		case "decimal_mode":
+			switch v {
+			case "random":
+				c.DecimalMode = DecimalRandom
+			case "running_balance":
+				c.DecimalMode = DecimalRunningBalance
+				c.balance = &balanceState{last: make(map[int]balanceEntry)}
+				c.runSalt = columnSalt(c.OrigName)
+			default:
+				return fmt.Errorf("invalid decimal_mode for column %s: %q", c.OrigName, v)
+			}
+		case "balance_start", "delta_min", "delta_max":
+			n, err := c.parseBalanceValue(k, v)
+			if err != nil {
+				return err
+			}
+			switch k {
+			case "balance_start":
+				c.BalanceStart = n
+			case "delta_min":
+				c.BalanceDeltaMin, hasDeltaMin = n, true
+			case "delta_max":
+				c.BalanceDeltaMax, hasDeltaMax = n, true
+			}
+			hasBalanceOpts = true
+		case "float_dist":
+			switch v {
+			case "uniform":
+				c.FloatDist = FloatUniform
+			case "gaussian":
+				c.FloatDist = FloatGaussian
+			default:
+				return fmt.Errorf("invalid float_dist for column %s: %q", c.OrigName, v)
+			}
+		case "float_format":
+			f, prec, err := parseFloatFormat(v)
+			if err != nil {
+				return fmt.Errorf("invalid float_format for column %s: %q, %v", c.OrigName, v, err)
+			}
+			c.FloatFormat, c.FloatPrecision = f, prec
+		case "dup_key_percent":
+			pct, err := strconv.ParseFloat(v, 64)
+			if err != nil || pct <= 0 || pct > 100 {
+				return fmt.Errorf("invalid dup_key_percent for column %s: %q, must be in (0, 100]", c.OrigName, v)
+			}
+			c.DupKeyPercent = pct
+			c.runSalt = columnSalt(c.OrigName)
+		case "type_noise_percent":
+			pct, err := strconv.ParseFloat(v, 64)
+			if err != nil || pct <= 0 || pct > 100 {
+				return fmt.Errorf("invalid type_noise_percent for column %s: %q, must be in (0, 100]", c.OrigName, v)
+			}
+			c.TypeNoisePercent = pct
+			c.runSalt = columnSalt(c.OrigName)
+		case "dict_cardinality":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid dict_cardinality for column %s: %q, must be >= 1", c.OrigName, v)
+			}
+			c.DictCardinality = n
+			c.runSalt = columnSalt(c.OrigName)
+		case "gap_percent":
+			pct, err := strconv.ParseFloat(v, 64)
+			if err != nil || pct <= 0 || pct > 100 {
+				return fmt.Errorf("invalid gap_percent for column %s: %q, must be in (0, 100]", c.OrigName, v)
+			}
+			c.GapPercent = pct
+			c.runSalt = columnSalt(c.OrigName)
+		case "encoding":
+			enc := strings.ToLower(v)
+			if err := checkParquetEncoding(enc, c.Type); err != nil {
+				return fmt.Errorf("invalid encoding for column %s: %w", c.OrigName, err)
+			}
+			c.Encoding = enc
+		case "case_variants_percent":
+			pct, err := strconv.ParseFloat(v, 64)
+			if err != nil || pct <= 0 || pct > 100 {
+				return fmt.Errorf("invalid case_variants_percent for column %s: %q, must be in (0, 100]", c.OrigName, v)
+			}
+			c.CaseVariantsPercent = pct
+			c.runSalt = columnSalt(c.OrigName)
+		case "whitespace_percent":
+			pct, err := strconv.Atoi(v)
+			if err != nil || pct < 0 || pct > 100 {
+				return fmt.Errorf("invalid whitespace_percent for column %s: %q", c.OrigName, v)
+			}
+			c.WhitespacePercent = pct
+			c.WhitespaceChars = defaultWhitespaceChars
+		case "date_start", "date_end":
+			t, err := parseDateOption(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s for column %s: %q", k, c.OrigName, v)
+			}
+			if k == "date_start" {
+				c.DateStart = t
+			} else {
+				c.DateEnd = t
+			}
+		case "time_profile":
+			switch v {
+			case "uniform":
+				c.TimeProfile = TimeUniform
+			case "business_hours":
+				c.TimeProfile = TimeBusinessHours
+			default:
+				return fmt.Errorf("invalid time_profile for column %s: %q", c.OrigName, v)
+			}
+		case "timestamp_unit":
+			u, ok := timestampUnitNames[v]
+			if !ok {
+				return fmt.Errorf("invalid timestamp_unit for column %s: %q, must be millis, micros, nanos or int96", c.OrigName, v)
+			}
+			timestampUnit = &u
+		case "rounding":
+			switch v {
+			case "half_even":
+				c.Rounding = RoundHalfEven
+			case "half_up":
+				c.Rounding = RoundHalfUp
+			case "truncate":
+				c.Rounding = RoundTruncate
+			default:
+				return fmt.Errorf("invalid rounding for column %s: %q", c.OrigName, v)
+			}
+		case "dist":
+			switch v {
+			case "uniform":
+				c.Dist = SetUniform
+			case "zipf":
+				c.Dist = SetZipf
+			default:
+				return fmt.Errorf("invalid dist for column %s: %q", c.OrigName, v)
+			}
+		case "zipf_s":
+			s, err := strconv.ParseFloat(v, 64)
+			if err != nil || !(s > 1) || math.IsInf(s, 0) {
+				return fmt.Errorf("invalid zipf_s for column %s: %q, must be > 1", c.OrigName, v)
+			}
+			c.ZipfS = s
+		case "histogram":
+			buckets, err := parseHistogram(v)
+			if err != nil {
+				return fmt.Errorf("invalid histogram for column %s: %v", c.OrigName, err)
+			}
+			c.Histogram = buckets
+		case "repetition":
+			switch v {
+			case "optional":
+				c.Required = false
+			case "required":
+				c.Required = true
+			case "repeated":
+				return fmt.Errorf("repetition=repeated for column %s needs LIST support, which is not implemented", c.OrigName)
+			default:
+				return fmt.Errorf("invalid repetition for column %s: %q", c.OrigName, v)
+			}
+		case "run_length":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid run_length for column %s: %q, must be >= 1", c.OrigName, v)
+			}
+			c.RunLength = n
+			c.runSalt = columnSalt(c.OrigName)
+		case "format":
+			if v != "decimal" {
+				return fmt.Errorf("invalid format for column %s: %q, only decimal is supported", c.OrigName, v)
+			}
+			c.StringFormat = StringFormatDecimal
+		case "precision", "scale":
+			if c.SQLType == "decimal" {
+				return fmt.Errorf("%s of decimal column %s is set by its type", k, c.OrigName)
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s for column %s: %q", k, c.OrigName, v)
+			}
+			if k == "precision" {
+				c.Precision, hasPrecision = n, true
+			} else {
+				c.Scale, hasScale = n, true
+			}
+		case "number_format":
+			f, ok := parseNumberFormat(v)
+			if !ok {
+				return fmt.Errorf("invalid number_format for column %s: %q", c.OrigName, v)
+			}
+			c.NumberFormat = f
+		case "charset":
+			if err := c.setCharset(v); err != nil {
+				return err
+			}
 		case "compress":
 			compress, err := strconv.Atoi(v)
 			if err != nil {
@@ -155,6 +620,44 @@ func (c *ColumnSpec) parseComment(comment string) error {
 				continue
 			}
 			return fmt.Errorf("invalid set for column %s: %q", c.OrigName, v)
+		case "unique_scope":
+			switch v {
+			case "file":
+				c.UniqueScope = UniqueScopeFile
+			case "global":
+				c.UniqueScope = UniqueScopeGlobal
+				c.IsUnique = true
+			default:
+				return fmt.Errorf("invalid unique_scope for column %s: %q", c.OrigName, v)
+			}
+		case "sequence_scope":
+			switch v {
+			case "global":
+				c.SequenceScope = SequenceGlobal
+			case "file":
+				c.SequenceScope = SequenceFile
+			case "partition":
+				c.SequenceScope = SequencePartition
+			default:
+				return fmt.Errorf("invalid sequence_scope for column %s: %q", c.OrigName, v)
+			}
+			hasSequenceScope = true
+		case "faker":
+			f, err := parseFaker(v)
+			if err != nil {
+				return fmt.Errorf("invalid faker for column %s: %w", c.OrigName, err)
+			}
+			c.Faker = f
+		case "regex":
+			// Quotes allow commas in the pattern, e.g. regex="\d{3,4}".
+			if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+				v = v[1 : len(v)-1]
+			}
+			re, err := compileRegex(v)
+			if err != nil {
+				return fmt.Errorf("invalid regex for column %s: %v", c.OrigName, err)
+			}
+			c.Regex, c.regex = v, re
 		case "order":
 			switch v {
 			case "total_order":
@@ -163,14 +666,354 @@ func (c *ColumnSpec) parseComment(comment string) error {
 				c.Order = NumericPartialOrder
 			case "random_order":
 				c.Order = NumericRandomOrder
+			case "cycle":
+				c.Order = CycleOrder
+			case "sequence":
+				c.Order = SequenceOrder
+				c.sequence = new(atomic.Int64)
 			default:
 				return fmt.Errorf("invalid order for column %s: %q", c.OrigName, v)
 			}
 		}
 	}
+
+	if c.DecimalMode == DecimalRunningBalance {
+		step := new(big.Int).Mul(pow10(c.Scale), big.NewInt(defaultBalanceDelta))
+		if !step.IsInt64() {
+			return fmt.Errorf("decimal_mode=running_balance does not support scale %d of column %s", c.Scale, c.OrigName)
+		}
+		if !hasDeltaMin {
+			c.BalanceDeltaMin = -step.Int64()
+		}
+		if !hasDeltaMax {
+			c.BalanceDeltaMax = step.Int64()
+		}
+	}
+	if err := c.checkDecimalMode(hasBalanceOpts); err != nil {
+		return err
+	}
+	if hasFloatMin != hasFloatMax {
+		return fmt.Errorf("float_min and float_max must be set together for column %s", c.OrigName)
+	}
+	c.HasFloatRange = hasFloatMin
+
+	if c.Order == CycleOrder && len(c.ValueSet) == 0 && len(c.IntSet) == 0 {
+		return fmt.Errorf("order=cycle requires a set for column %s", c.OrigName)
+	}
+	if hasSequenceScope && c.Order != SequenceOrder {
+		return fmt.Errorf("sequence_scope requires order=sequence for column %s", c.OrigName)
+	}
+	if c.Order == SequenceOrder {
+		if !isIntegerType(c.SQLType) {
+			return fmt.Errorf("order=sequence is only supported for integer columns, column %s is %s", c.OrigName, c.SQLType)
+		}
+		if len(c.IntSet) > 0 || len(c.Histogram) > 0 || c.StdDev > 0 || hasMin || hasMax || c.RunLength > 1 {
+			return fmt.Errorf("order=sequence cannot be combined with set, histogram, mean/stddev, min/max or run_length for column %s", c.OrigName)
+		}
+	}
+	if !c.DateStart.IsZero() || !c.DateEnd.IsZero() {
+		if !isTimeType(c.SQLType) || c.SQLType == "time" {
+			return fmt.Errorf("date_start/date_end is only supported for date, datetime and timestamp columns, column %s is %s", c.OrigName, c.SQLType)
+		}
+		if !c.DateStart.IsZero() && !c.DateEnd.IsZero() && !c.DateStart.Before(c.DateEnd) {
+			return fmt.Errorf("date_start must be before date_end for column %s", c.OrigName)
+		}
+	}
+	if c.TimeProfile != TimeUniform && !isTimeType(c.SQLType) {
+		return fmt.Errorf("time_profile is only supported for time columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if timestampUnit != nil {
+		if err := c.setTimestampUnit(*timestampUnit); err != nil {
+			return err
+		}
+	}
+	if c.Rounding != RoundHalfEven && c.SQLType != "decimal" {
+		return fmt.Errorf("rounding is only supported for decimal columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if c.Dist == SetZipf {
+		if len(c.ValueSet) == 0 && len(c.IntSet) == 0 {
+			return fmt.Errorf("dist=zipf requires a set for column %s", c.OrigName)
+		}
+		if c.Order == CycleOrder {
+			return fmt.Errorf("dist=zipf cannot be used with order=cycle for column %s", c.OrigName)
+		}
+		if c.ZipfS == 0 {
+			c.ZipfS = defaultZipfS
+		}
+	} else if c.ZipfS != 0 {
+		return fmt.Errorf("zipf_s requires dist=zipf for column %s", c.OrigName)
+	}
+	if c.Required && c.NullPercent > 0 {
+		return fmt.Errorf("repetition=required cannot be used with null_percent for column %s", c.OrigName)
+	}
+	if c.NullCount > 0 {
+		if c.NullPercent > 0 {
+			return fmt.Errorf("null_count cannot be combined with null_percent for column %s", c.OrigName)
+		}
+		if c.Required {
+			return fmt.Errorf("repetition=required cannot be used with null_count for column %s", c.OrigName)
+		}
+	}
+	if len(c.Histogram) > 0 {
+		if c.StdDev > 0 || hasMin || hasMax {
+			return fmt.Errorf("histogram cannot be combined with mean/stddev or min/max for column %s", c.OrigName)
+		}
+		switch {
+		case isIntegerType(c.SQLType):
+			if err := c.initHistogramInts(); err != nil {
+				return err
+			}
+		case c.SQLType == "decimal":
+			if err := c.initHistogramDecimals(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("histogram is only supported for integer and decimal columns, column %s is %s", c.OrigName, c.SQLType)
+		}
+	}
+	if c.RunLength > 1 && !isRunLengthSupported(c.SQLType) {
+		return fmt.Errorf("run_length is only supported for integer and string columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if c.FloatFormat != 0 && c.SQLType != "float" && c.SQLType != "double" {
+		return fmt.Errorf("float_format is only supported for float and double columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if c.NumberFormat != NumberFormatNone && !isNumberFormatSupported(c.SQLType) && c.StringFormat == StringFormatNone {
+		return fmt.Errorf("number_format is only supported for numeric columns and format=decimal, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if err := c.setDecimalStringFormat(hasPrecision, hasScale); err != nil {
+		return err
+	}
+	if c.Charset != CharsetASCII && (!isStringType(c.SQLType) || c.IsBinary()) {
+		return fmt.Errorf("charset is only supported for string columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if c.regex != nil {
+		if !isStringType(c.SQLType) {
+			return fmt.Errorf("regex is only supported for string columns, column %s is %s", c.OrigName, c.SQLType)
+		}
+		if len(c.ValueSet) > 0 {
+			return fmt.Errorf("regex cannot be combined with set for column %s", c.OrigName)
+		}
+	}
+	if c.WhitespacePercent > 0 && !isStringType(c.SQLType) {
+		return fmt.Errorf("whitespace_percent is only supported for string columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if err := c.checkCaseVariants(); err != nil {
+		return err
+	}
+	if err := c.checkTypeNoise(); err != nil {
+		return err
+	}
+	if hasFK && hasFKRange {
+		return fmt.Errorf("fk and fk_range cannot both be set for column %s", c.OrigName)
+	}
+	if hasFK || hasFKRange {
+		opt := "fk_range"
+		if hasFK {
+			opt = "fk"
+		}
+		if err := c.checkFKOptions(opt, hasMin, hasMax); err != nil {
+			return err
+		}
+		if c.FKRef != "" {
+			return nil
+		}
+		return c.setFKRange(opt, fkLow, fkHigh)
+	}
+	if hasMin || hasMax {
+		return c.setIntRange(hasMin, hasMax)
+	}
+	return nil
+}
+
+// OutputLayout describes the files of a run, for the column options that
+// depend on them.
+type OutputLayout struct {
+	// Format is the file format: csv, ndjson or parquet.
+	Format string
+	// RowsPerFile is common.rows, MaxRowsPerFile the rows of the largest
+	// file, which holds the remainder of common.total_rows.
+	RowsPerFile    int
+	MaxRowsPerFile int
+	// MinRowGroupRows is the rows of the smallest row group, or 0 if unknown.
+	MinRowGroupRows int
+	// TargetSize is set when parquet.target_compressed_size decides the rows
+	// of a file while writing it.
+	TargetSize bool
+	// MaxFileBytes is set when common.max_file_bytes ends the files instead
+	// of common.rows.
+	MaxFileBytes bool
+}
+
+// ValidateOutput checks the options of specs that depend on the files they
+// are written to.
+func ValidateOutput(specs []*ColumnSpec, out OutputLayout) error {
+	for _, c := range specs {
+		if c.FKRef != "" && !c.IsForeignKey {
+			return fmt.Errorf("fk=%s of column %s needs a schema holding the referenced table", c.FKRef, c.OrigName)
+		}
+		if c.TypeNoisePercent > 0 && out.Format != "csv" {
+			return fmt.Errorf("type_noise_percent of column %s is only supported for CSV output", c.OrigName)
+		}
+		if out.TargetSize {
+			switch {
+			case c.Order == SequenceOrder && c.SequenceScope != SequenceGlobal:
+				return fmt.Errorf("sequence_scope=%s of column %s cannot be used with parquet.target_compressed_size", c.SequenceScope, c.OrigName)
+			case c.DecimalMode == DecimalRunningBalance:
+				return fmt.Errorf("decimal_mode=running_balance of column %s cannot be used with parquet.target_compressed_size", c.OrigName)
+			case c.NullCount > 0:
+				return fmt.Errorf("null_count of column %s cannot be used with parquet.target_compressed_size", c.OrigName)
+			// Files write rows past common.rows, so their global row IDs
+			// overlap with the next file's.
+			case c.IsUnique && c.UniqueScope == UniqueScopeGlobal:
+				return fmt.Errorf("unique_scope=global of column %s cannot be used with parquet.target_compressed_size", c.OrigName)
+			case c.IsUnique && c.Order == NumericTotalOrder:
+				return fmt.Errorf("order=total_order of unique column %s cannot be used with parquet.target_compressed_size", c.OrigName)
+			case c.DupKeyPercent > 0:
+				return fmt.Errorf("dup_key_percent of column %s cannot be used with parquet.target_compressed_size", c.OrigName)
+			case c.KeysFollowRows:
+				return fmt.Errorf("column %s is referenced by fk and cannot be used with parquet.target_compressed_size", c.OrigName)
+			}
+		}
+		if out.MaxFileBytes {
+			switch {
+			case c.Order == SequenceOrder && c.SequenceScope != SequenceGlobal:
+				return fmt.Errorf("sequence_scope=%s of column %s cannot be used with common.max_file_bytes", c.SequenceScope, c.OrigName)
+			case c.NullCount > 0:
+				return fmt.Errorf("null_count of column %s cannot be used with common.max_file_bytes", c.OrigName)
+			case c.DupKeyPercent > 0:
+				return fmt.Errorf("dup_key_percent of column %s cannot be used with common.max_file_bytes", c.OrigName)
+			}
+		}
+		if c.DictCardinality > 0 && out.MinRowGroupRows > 0 && out.MinRowGroupRows < c.DictCardinality {
+			return fmt.Errorf("dict_cardinality=%d of column %s needs row groups of at least that many rows, row groups have %d",
+				c.DictCardinality, c.OrigName, out.MinRowGroupRows)
+		}
+		if c.DecimalMode == DecimalRunningBalance && !c.BalanceFits(out.MaxRowsPerFile) {
+			return fmt.Errorf("running balance of column %s can reach %s over %d rows, which does not fit decimal(%d,%d); lower balance_start, delta_min/delta_max or the rows per file",
+				c.OrigName, FormatDecimal(c.MaxBalance(out.MaxRowsPerFile).String(), c.Scale), out.MaxRowsPerFile, c.Precision, c.Scale)
+		}
+		if c.NullCount > out.RowsPerFile {
+			return fmt.Errorf("null_count=%d of column %s exceeds the %d rows per file", c.NullCount, c.OrigName, out.RowsPerFile)
+		}
+	}
+	return nil
+}
+
+// ExcludeWhitespace removes chars from the padding characters, e.g. a tab
+// when it is also the CSV separator. Spaces are kept as the fallback.
+func (c *ColumnSpec) ExcludeWhitespace(chars string) {
+	if c.WhitespacePercent == 0 {
+		return
+	}
+	kept := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(chars, r) {
+			return -1
+		}
+		return r
+	}, c.WhitespaceChars)
+	if kept == "" {
+		kept = " "
+	}
+	c.WhitespaceChars = kept
+}
+
+// setIntRange validates min/max against the declared integer type, the
+// missing side defaults to the type bound.
+func (c *ColumnSpec) setIntRange(hasMin, hasMax bool) error {
+	if !isIntegerType(c.SQLType) {
+		return fmt.Errorf("min/max is only supported for integer columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+
+	lower, upper := c.intTypeRange()
+	if !hasMin {
+		c.MinValue = lower
+	}
+	if !hasMax {
+		c.MaxValue = upper
+	}
+	if c.MinValue > c.MaxValue {
+		return fmt.Errorf("min %d is greater than max %d for column %s", c.MinValue, c.MaxValue, c.OrigName)
+	}
+	if c.MinValue < lower || c.MaxValue > upper {
+		return fmt.Errorf("range [%d,%d] doesn't fit %s for column %s", c.MinValue, c.MaxValue, c.SQLType, c.OrigName)
+	}
+	c.HasRange = true
 	return nil
 }
 
+// intTypeRange returns the value range of the declared integer width.
+func (c *ColumnSpec) intTypeRange() (int64, int64) {
+	if c.TypeLen >= 64 {
+		return math.MinInt64, math.MaxInt64
+	}
+	if c.Signed {
+		return -(1 << (c.TypeLen - 1)), 1<<(c.TypeLen-1) - 1
+	}
+	return 0, 1<<c.TypeLen - 1
+}
+
+func isIntegerType(sqlType string) bool {
+	switch sqlType {
+	case "tinyint", "smallint", "mediumint", "int", "bigint":
+		return true
+	default:
+		return false
+	}
+}
+
+func isTimeType(sqlType string) bool {
+	switch sqlType {
+	case "date", "datetime", "timestamp", "time":
+		return true
+	default:
+		return false
+	}
+}
+
+// dateOptionLayouts are accepted by date_start/date_end. Spaces are stripped
+// from comments, so a time part must be joined with 'T'.
+var dateOptionLayouts = []string{time.DateOnly, "2006-01-02T15:04:05", time.RFC3339}
+
+// parseDateOption parses a date_start/date_end value, in UTC unless the value
+// has a zone.
+func parseDateOption(v string) (time.Time, error) {
+	var err error
+	for _, layout := range dateOptionLayouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, v, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// ParquetRepetition returns the repetition of the column's Parquet field.
+func (c *ColumnSpec) ParquetRepetition() parquet.Repetition {
+	if c.Required {
+		return parquet.Repetitions.Required
+	}
+	return parquet.Repetitions.Optional
+}
+
+// IsNumeric reports whether the column's values are plain numbers.
+func (c *ColumnSpec) IsNumeric() bool {
+	return isNumberFormatSupported(c.SQLType) || c.SQLType == "year"
+}
+
+// IsBinary reports whether the column holds arbitrary bytes rather than text.
+func (c *ColumnSpec) IsBinary() bool {
+	return c.SQLType == "binary" || c.SQLType == "varbinary"
+}
+
+func isStringType(sqlType string) bool {
+	switch sqlType {
+	case "char", "varchar", "binary", "varbinary", "blob", "text", "tinyblob", "enum", "set":
+		return true
+	default:
+		return false
+	}
+}
+
 var DefaultSpecs = map[byte]*ColumnSpec{
 	mysql.TypeNewDecimal: {
 		SQLType:   "decimal",
@@ -195,9 +1038,7 @@ var DefaultSpecs = map[byte]*ColumnSpec{
 	mysql.TypeDuration: {
 		SQLType:   "time",
 		Type:      parquet.Types.Int64,
-		Converted: schema.ConvertedTypes.Int64,
-		TypeLen:   8,
-		Signed:    true,
+		Converted: schema.ConvertedTypes.TimeMicros,
 	},
 	mysql.TypeYear: {
 		SQLType:   "year",
@@ -295,6 +1136,18 @@ var DefaultSpecs = map[byte]*ColumnSpec{
 		Converted: schema.ConvertedTypes.None,
 		TypeLen:   64,
 	},
+	mysql.TypeEnum: {
+		SQLType:   "enum",
+		Type:      parquet.Types.ByteArray,
+		Converted: schema.ConvertedTypes.None,
+		TypeLen:   64,
+	},
+	mysql.TypeSet: {
+		SQLType:   "set",
+		Type:      parquet.Types.ByteArray,
+		Converted: schema.ConvertedTypes.None,
+		TypeLen:   64,
+	},
 	// TODO(joechenrh): check if we can use nested type for JSON
 	mysql.TypeJSON: {
 		SQLType:   "json",
@@ -320,9 +1173,18 @@ func (c *ColumnSpec) String() string {
 	if c.NullPercent > 0 {
 		builder.WriteString(", NullPercent: " + strconv.Itoa(c.NullPercent))
 	}
+	if c.NullCount > 0 {
+		builder.WriteString(", NullCount: " + strconv.Itoa(c.NullCount))
+	}
 
 	if c.IsUnique {
 		builder.WriteString(", IsUnique: true")
+		if c.UniqueScope == UniqueScopeGlobal {
+			builder.WriteString(", UniqueScope: global")
+		}
+		if c.DupKeyPercent > 0 {
+			builder.WriteString(", DupKeyPercent: " + strconv.FormatFloat(c.DupKeyPercent, 'g', -1, 64))
+		}
 	}
 
 	switch c.Order {
@@ -332,6 +1194,35 @@ func (c *ColumnSpec) String() string {
 		builder.WriteString(", Order: partial_order")
 	case NumericRandomOrder:
 		builder.WriteString(", Order: random_order")
+	case CycleOrder:
+		builder.WriteString(", Order: cycle")
+	case SequenceOrder:
+		builder.WriteString(", Order: sequence, SequenceScope: " + c.SequenceScope.String())
+	}
+	if c.TypeNoisePercent > 0 {
+		builder.WriteString(", TypeNoisePercent: " + strconv.FormatFloat(c.TypeNoisePercent, 'g', -1, 64))
+	}
+	if c.GapPercent > 0 {
+		builder.WriteString(", GapPercent: " + strconv.FormatFloat(c.GapPercent, 'g', -1, 64))
+	}
+
+	if c.Regex != "" {
+		builder.WriteString(", Regex: " + c.Regex)
+	}
+	if c.Faker != FakerNone {
+		builder.WriteString(", Faker: " + c.Faker.String())
+	}
+	if c.StringFormat == StringFormatDecimal {
+		builder.WriteString(", Format: decimal")
+	}
+	if c.Encoding != "" {
+		builder.WriteString(", Encoding: " + c.Encoding)
+	}
+	if c.DictCardinality > 0 {
+		builder.WriteString(", DictCardinality: " + strconv.Itoa(c.DictCardinality))
+	}
+	if c.CaseVariantsPercent > 0 {
+		builder.WriteString(", CaseVariantsPercent: " + strconv.FormatFloat(c.CaseVariantsPercent, 'g', -1, 64))
 	}
 
 	if c.Mean != 0 {
@@ -342,6 +1233,49 @@ func (c *ColumnSpec) String() string {
 		builder.WriteString(", StdDev: " + strconv.Itoa(c.StdDev))
 	}
 
+	if c.DecimalMode == DecimalRunningBalance {
+		builder.WriteString(", DecimalMode: running_balance")
+		builder.WriteString(", BalanceStart: " + FormatDecimal(strconv.FormatInt(c.BalanceStart, 10), c.Scale))
+		builder.WriteString(", DeltaMin: " + FormatDecimal(strconv.FormatInt(c.BalanceDeltaMin, 10), c.Scale))
+		builder.WriteString(", DeltaMax: " + FormatDecimal(strconv.FormatInt(c.BalanceDeltaMax, 10), c.Scale))
+	}
+	if c.HasFloatRange {
+		builder.WriteString(", FloatMin: " + strconv.FormatFloat(c.FloatMin, 'g', -1, 64))
+		builder.WriteString(", FloatMax: " + strconv.FormatFloat(c.FloatMax, 'g', -1, 64))
+	}
+	if c.FloatDist == FloatGaussian {
+		builder.WriteString(", FloatDist: gaussian")
+	}
+	if c.FloatFormat != 0 {
+		builder.WriteString(", FloatFormat: " + string(c.FloatFormat))
+		if c.FloatPrecision >= 0 {
+			builder.WriteString(strconv.Itoa(c.FloatPrecision))
+		}
+	}
+
+	if c.IsForeignKey {
+		builder.WriteString(", FKRange: " + c.FKRangeString())
+	} else if c.FKRef != "" {
+		builder.WriteString(", FK: " + c.FKRef)
+	} else if c.HasRange {
+		builder.WriteString(", Min: " + strconv.FormatInt(c.MinValue, 10))
+		builder.WriteString(", Max: " + strconv.FormatInt(c.MaxValue, 10))
+	}
+
+	switch c.Charset {
+	case CharsetASCII:
+	case CharsetCustom:
+		chars := c.chars
+		if c.charRunes != nil {
+			chars = string(c.charRunes)
+		}
+		builder.WriteString(", Charset: " + strconv.Quote(chars))
+	default:
+		builder.WriteString(", Charset: " + c.Charset.String())
+	}
+	if c.WhitespacePercent > 0 {
+		builder.WriteString(", WhitespacePercent: " + strconv.Itoa(c.WhitespacePercent))
+	}
 	if c.Compress > 0 {
 		builder.WriteString(", Compress: " + strconv.Itoa(c.Compress))
 	}
@@ -353,6 +1287,9 @@ func (c *ColumnSpec) String() string {
 	if c.Scale > 0 {
 		builder.WriteString(", Scale: " + strconv.Itoa(c.Scale))
 	}
+	if c.TimestampUnit != TimestampMicros {
+		builder.WriteString(", TimestampUnit: " + c.TimestampUnit.String())
+	}
 
 	builder.WriteString("}")
 	return builder.String()
@@ -381,8 +1318,84 @@ func getTableInfoBySQL(createTableSQL string) (table *model.TableInfo, err error
 	return nil, errors.New("not a CREATE TABLE statement")
 }
 
+// getTableInfosBySQL parses every CREATE TABLE statement of the query and
+// ignores other statements.
+func getTableInfosBySQL(query string) ([]*model.TableInfo, error) {
+	p := parser.New()
+	p.SetSQLMode(mysql.ModeANSIQuotes)
+
+	stmts, _, err := p.Parse(query, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	metaBuildCtx := ddl.NewMetaBuildContextWithSctx(mock.NewContext())
+	var tables []*model.TableInfo
+	for _, stmt := range stmts {
+		s, ok := stmt.(*ast.CreateTableStmt)
+		if !ok {
+			continue
+		}
+		tbInfo, err := ddl.BuildTableInfoWithStmt(metaBuildCtx, s, mysql.DefaultCharset, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, tbInfo)
+	}
+	if len(tables) == 0 {
+		return nil, errors.New("no CREATE TABLE statement")
+	}
+	return tables, nil
+}
+
+// parseTableInfo parses the query after rewriting it for the dialect. When
+// no dialect is given and parsing fails, it retries once with all rewrite
+// rules and reports the original error if that doesn't help either.
+func parseTableInfo(query string, dialect string) (*model.TableInfo, error) {
+	return parseWithDialect(query, dialect, getTableInfoBySQL)
+}
+
+// parseWithDialect runs parse on the query rewritten for the dialect, see
+// parseTableInfo.
+func parseWithDialect[T any](query string, dialect string, parse func(string) (T, error)) (T, error) {
+	rules, err := dialectRules(dialect)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if len(rules) > 0 {
+		return parse(rewriteDialect(query, rules))
+	}
+
+	result, err := parse(query)
+	if err == nil {
+		return result, nil
+	}
+	if retried, retryErr := parse(rewriteDialect(query, allDialectRules)); retryErr == nil {
+		return retried, nil
+	}
+	return result, err
+}
+
 // readAndCleanSQL reads SQL file and cleans up comments and extra content
 func readAndCleanSQL(sqlPath string) (string, error) {
+	query, err := readSQL(sqlPath)
+	if err != nil {
+		return "", err
+	}
+
+	// Find the last closing parenthesis and truncate everything after it except ";"
+	lastParenIndex := strings.LastIndex(query, ")")
+	if lastParenIndex != -1 {
+		// Keep everything up to and including the last ")" and add ";"
+		query = query[:lastParenIndex+1] + ";"
+	}
+
+	return query, nil
+}
+
+// readSQL reads SQL file and skips the comment lines at its beginning.
+func readSQL(sqlPath string) (string, error) {
 	data, err := os.ReadFile(sqlPath)
 	if err != nil {
 		return "", err
@@ -404,38 +1417,95 @@ func readAndCleanSQL(sqlPath string) (string, error) {
 
 	// Keep all lines from the first non-comment line onwards
 	filteredLines = lines[startIndex:]
-	query := strings.Join(filteredLines, "\n")
-
-	// Find the last closing parenthesis and truncate everything after it except ";"
-	lastParenIndex := strings.LastIndex(query, ")")
-	if lastParenIndex != -1 {
-		// Keep everything up to and including the last ")" and add ";"
-		query = query[:lastParenIndex+1] + ";"
-	}
+	return strings.Join(filteredLines, "\n"), nil
+}
 
-	return query, nil
+// ParseOptions controls how a schema is turned into column specs.
+type ParseOptions struct {
+	// SkipUnsupported drops columns of unsupported types with a warning
+	// instead of failing the whole schema.
+	SkipUnsupported bool
+	// Dialect is the SQL dialect of the schema, e.g. postgres or sqlserver.
+	// Its constructs are rewritten into MySQL syntax before parsing.
+	Dialect string
 }
 
 // GetSpecFromSQL parses a CREATE TABLE SQL file into column specs.
-func GetSpecFromSQL(sqlPath string) ([]*ColumnSpec, error) {
+func GetSpecFromSQL(sqlPath string, opts ParseOptions) ([]*ColumnSpec, error) {
 	query, err := readAndCleanSQL(sqlPath)
 	if err != nil {
 		return nil, err
 	}
 
-	tbInfo, err := getTableInfoBySQL(query)
+	tbInfo, err := parseTableInfo(query, opts.Dialect)
 	if err != nil {
 		return nil, err
 	}
+	return specsFromTableInfo(tbInfo, opts)
+}
+
+// createTableRe matches the start of a CREATE TABLE statement.
+var createTableRe = regexp.MustCompile(`(?i)\bcreate\s+(temporary\s+)?table\b`)
+
+// GetTablesFromSQL parses every CREATE TABLE statement of a SQL file and
+// returns each table by its lowercase name.
+func GetTablesFromSQL(sqlPath string, opts ParseOptions) (map[string]*Table, error) {
+	query, err := readSQL(sqlPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := parseWithDialect(query, opts.Dialect, getTableInfosBySQL)
+	if err != nil {
+		if len(createTableRe.FindAllStringIndex(query, 2)) < 2 {
+			return nil, fmt.Errorf("%w: %w", ErrNotMultiTable, err)
+		}
+		return nil, err
+	}
+
+	tablesByName := make(map[string]*Table, len(tables))
+	for _, tbInfo := range tables {
+		name := tbInfo.Name.L
+		if _, ok := tablesByName[name]; ok {
+			return nil, fmt.Errorf("duplicate table %s", name)
+		}
+		specs, err := specsFromTableInfo(tbInfo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", name, err)
+		}
+		table := &Table{Specs: specs}
+		if err := table.parseComment(tbInfo.Comment); err != nil {
+			return nil, fmt.Errorf("table %s: %w", name, err)
+		}
+		tablesByName[name] = table
+	}
+	return tablesByName, nil
+}
 
+// specsFromTableInfo turns the columns of a parsed table into column specs.
+func specsFromTableInfo(tbInfo *model.TableInfo, opts ParseOptions) ([]*ColumnSpec, error) {
 	specs := make([]*ColumnSpec, 0, len(tbInfo.Columns))
+	// specByOffset maps column offsets to specs, nil for skipped columns.
+	specByOffset := make([]*ColumnSpec, len(tbInfo.Columns))
 	for _, col := range tbInfo.Columns {
 		spec, ok := DefaultSpecs[col.GetType()]
 		if !ok {
-			return nil, errors.New("unsupported column type: " + strconv.Itoa(int(col.GetType())))
+			if opts.SkipUnsupported {
+				logger.Warnf("Skip column %s with unsupported type %s", col.Name.O, parsertypes.TypeStr(col.GetType()))
+				continue
+			}
+			return nil, columnErrorf(col.Name.O, ErrUnsupportedType, "unsupported column type %s for column %s", parsertypes.TypeStr(col.GetType()), col.Name.O)
 		}
 		spec = spec.Clone()
 		spec.OrigName = col.Name.L
+		if col.GetCharset() == charset.CharsetBin {
+			switch col.GetType() {
+			case mysql.TypeString:
+				spec.SQLType = "binary"
+			case mysql.TypeVarchar, mysql.TypeVarString:
+				spec.SQLType = "varbinary"
+			}
+		}
 		spec.Order = NumericRandomOrder
 		spec.Compress = 100 // default no compression for data generation
 
@@ -447,31 +1517,49 @@ func GetSpecFromSQL(sqlPath string) ([]*ColumnSpec, error) {
 			spec.Precision = col.FieldType.GetFlen()
 			spec.Scale = col.FieldType.GetDecimal()
 			if spec.Precision == 0 {
-				return nil, errors.New("unsupported decimal precision=0 for column: " + spec.OrigName)
+				return nil, columnErrorf(spec.OrigName, ErrInvalidDecimal, "unsupported decimal precision=0 for column: %s", spec.OrigName)
 			}
 			if spec.Scale < 0 || spec.Scale > spec.Precision {
-				return nil, errors.New("invalid decimal scale for column: " + spec.OrigName)
+				return nil, columnErrorf(spec.OrigName, ErrInvalidDecimal, "invalid decimal scale for column: %s", spec.OrigName)
 			}
 			spec.Type, spec.TypeLen = deduceTypeForDecimal(spec.Precision)
 		}
+		switch col.GetType() {
+		case mysql.TypeTimestamp, mysql.TypeDatetime, mysql.TypeDuration:
+			spec.FSP = max(col.GetDecimal(), 0)
+		case mysql.TypeEnum, mysql.TypeSet:
+			// A set= comment option below can still narrow the values.
+			spec.ValueSet = slices.Clone(col.GetElems())
+		}
 		if col.Comment != "" {
 			if err := spec.parseComment(col.Comment); err != nil {
-				return nil, err
+				return nil, commentError(spec.OrigName, err)
 			}
 		}
 
+		if spec.SQLType == "decimal" || spec.StringFormat == StringFormatDecimal {
+			spec.initDecimalBound()
+		}
+
 		if spec.MinLen == 0 {
 			spec.MinLen = int(float64(spec.TypeLen) * 0.75)
+			if spec.SQLType == "binary" {
+				// binary(n) values are always n bytes long.
+				spec.MinLen = spec.TypeLen
+			}
 		}
 		spec.MinLen = min(spec.TypeLen, spec.MinLen)
 
 		specs = append(specs, spec)
+		specByOffset[col.Offset] = spec
 	}
 
 	if tbInfo.PKIsHandle {
 		for _, col := range tbInfo.Columns {
 			if mysql.HasPriKeyFlag(col.GetFlag()) {
-				specs[col.Offset].IsUnique = true
+				if spec := specByOffset[col.Offset]; spec != nil {
+					spec.IsUnique = true
+				}
 				break
 			}
 		}
@@ -480,12 +1568,40 @@ func GetSpecFromSQL(sqlPath string) ([]*ColumnSpec, error) {
 	for _, index := range tbInfo.Indices {
 		if index.Primary || index.Unique {
 			for _, col := range index.Columns {
-				if col.Offset < len(specs) && col.Offset >= 0 {
-					specs[col.Offset].IsUnique = true
+				if col.Offset < len(specByOffset) && col.Offset >= 0 && specByOffset[col.Offset] != nil {
+					specByOffset[col.Offset].IsUnique = true
 				}
 			}
 		}
 	}
 
+	for _, spec := range specs {
+		if err := spec.checkDupKey(); err != nil {
+			return nil, newColumnError(spec.OrigName, ErrInvalidOption, err)
+		}
+		if err := spec.checkGap(); err != nil {
+			return nil, newColumnError(spec.OrigName, ErrInvalidOption, err)
+		}
+		if err := spec.checkFaker(); err != nil {
+			return nil, newColumnError(spec.OrigName, ErrInvalidOption, err)
+		}
+		if err := spec.checkFloat(); err != nil {
+			return nil, newColumnError(spec.OrigName, ErrInvalidOption, err)
+		}
+		if err := spec.checkDictCardinality(); err != nil {
+			return nil, newColumnError(spec.OrigName, ErrInvalidOption, err)
+		}
+		if err := spec.checkFKRange(); err != nil {
+			return nil, newColumnError(spec.OrigName, ErrInvalidOption, err)
+		}
+		if err := spec.checkStringFormat(); err != nil {
+			return nil, newColumnError(spec.OrigName, ErrInvalidOption, err)
+		}
+	}
+
+	if len(specs) == 0 {
+		return nil, errors.New("no supported columns in table")
+	}
+
 	return specs, nil
 }
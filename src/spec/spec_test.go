@@ -0,0 +1,43 @@
+package spec
+
+import (
+	"testing"
+)
+
+// testSpecs parses a CREATE TABLE statement into column specs.
+func testSpecs(t *testing.T, sql string) []*ColumnSpec {
+	t.Helper()
+	tbInfo, err := parseTableInfo(sql, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	specs, err := specsFromTableInfo(tbInfo, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return specs
+}
+
+// testSpec parses a table with a single column definition.
+func testSpec(t *testing.T, column string) *ColumnSpec {
+	t.Helper()
+	return testSpecs(t, "CREATE TABLE t ("+column+");")[0]
+}
+
+func TestValidateOutputMaxFileBytes(t *testing.T) {
+	out := OutputLayout{Format: "csv", RowsPerFile: 100, MaxRowsPerFile: 100, MaxFileBytes: true}
+	for _, column := range []string{
+		"id bigint COMMENT 'order=sequence, sequence_scope=file'",
+		"id bigint COMMENT 'order=sequence, sequence_scope=partition'",
+		"id bigint UNIQUE COMMENT 'dup_key_percent=5'",
+		"s varchar(10) COMMENT 'null_count=10'",
+	} {
+		if err := ValidateOutput([]*ColumnSpec{testSpec(t, column)}, out); err == nil {
+			t.Errorf("%s with max_file_bytes is accepted", column)
+		}
+	}
+	c := testSpec(t, "id bigint COMMENT 'order=sequence'")
+	if err := ValidateOutput([]*ColumnSpec{c}, out); err != nil {
+		t.Errorf("global sequence with max_file_bytes: %v", err)
+	}
+}
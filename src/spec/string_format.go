@@ -0,0 +1,81 @@
+package spec
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+)
+
+// StringFormat decides what string columns hold instead of random
+// characters.
+type StringFormat int
+
+const (
+	// StringFormatNone draws random characters.
+	StringFormatNone StringFormat = iota
+	// StringFormatDecimal writes decimals of Precision and Scale, e.g.
+	// "1234.56", grouped by NumberFormat.
+	StringFormatDecimal
+)
+
+// setDecimalStringFormat validates format=decimal with its precision and
+// scale options. hasPrecision and hasScale tell whether they were given.
+func (c *ColumnSpec) setDecimalStringFormat(hasPrecision, hasScale bool) error {
+	if c.StringFormat != StringFormatDecimal {
+		if hasPrecision || hasScale {
+			return fmt.Errorf("precision and scale require format=decimal for column %s", c.OrigName)
+		}
+		return nil
+	}
+	switch c.SQLType {
+	case "char", "varchar", "text":
+	default:
+		return fmt.Errorf("format=decimal is only supported for char, varchar and text columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	if !hasPrecision {
+		return fmt.Errorf("format=decimal requires precision for column %s", c.OrigName)
+	}
+	if c.Precision < 1 || c.Precision > mysql.MaxDecimalWidth {
+		return fmt.Errorf("precision of column %s must be between 1 and %d, got %d", c.OrigName, mysql.MaxDecimalWidth, c.Precision)
+	}
+	if c.Scale < 0 || c.Scale > min(c.Precision, mysql.MaxDecimalScale) {
+		return fmt.Errorf("scale of column %s must be between 0 and %d, got %d", c.OrigName, min(c.Precision, mysql.MaxDecimalScale), c.Scale)
+	}
+	if c.regex != nil || c.Faker != FakerNone || len(c.ValueSet) > 0 || c.Charset != CharsetASCII {
+		return fmt.Errorf("format=decimal cannot be combined with regex, faker, set or charset for column %s", c.OrigName)
+	}
+	if n := c.maxDecimalStringLen(); n > c.TypeLen {
+		return fmt.Errorf("format=decimal values of column %s take up to %d characters, more than its length %d", c.OrigName, n, c.TypeLen)
+	}
+	return nil
+}
+
+// maxDecimalStringLen returns the length of the longest formatted value:
+// sign, integer digits with group separators, point and fraction.
+func (c *ColumnSpec) maxDecimalStringLen() int {
+	intDigits := max(c.Precision-c.Scale, 1)
+	n := 1 + intDigits
+	if c.NumberFormat != NumberFormatNone {
+		n += (intDigits - 1) / 3
+	}
+	if c.Scale > 0 {
+		n += 1 + c.Scale
+	}
+	return n
+}
+
+// generateDecimalText returns a random decimal formatted for a string
+// column.
+func (c *ColumnSpec) generateDecimalText(rowID int, rng *rand.Rand) string {
+	return c.NumberFormat.apply(c.generateDecimalString(rowID, rng))
+}
+
+// checkStringFormat validates format once the unique keys of the table are
+// known.
+func (c *ColumnSpec) checkStringFormat() error {
+	if c.StringFormat == StringFormatDecimal && c.IsUnique {
+		return fmt.Errorf("format=decimal cannot be used on unique column %s", c.OrigName)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package spec
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/parquet"
+)
+
+func TestCSVAndParquetStringLengths(t *testing.T) {
+	const rows = 8192
+	c := testSpec(t, "s varchar(50) NOT NULL COMMENT 'min_length=10'")
+
+	csvLens := make(map[int]int)
+	rng := rand.New(rand.NewSource(1))
+	for i := range rows {
+		csvLens[len(GenerateSingleField(i, c, rng))]++
+	}
+
+	parquetLens := make(map[int]int)
+	rng = rand.New(rand.NewSource(2))
+	for start := 0; start < rows; start += 1024 {
+		out := make([]parquet.ByteArray, 1024)
+		if err := c.FillParquetBatch(start, out, make([]int16, len(out)), rng); err != nil {
+			t.Fatal(err)
+		}
+		batchLens := make(map[int]bool)
+		for _, v := range out {
+			parquetLens[len(v)]++
+			batchLens[len(v)] = true
+		}
+		if len(batchLens) < 30 {
+			t.Fatalf("batch at row %d has %d distinct lengths, want lengths drawn per row", start, len(batchLens))
+		}
+	}
+
+	// Lengths are uniform in [10, 50] either way, so every length has about
+	// 1/41 of the values in both formats.
+	for n := range 60 {
+		csvShare, parquetShare := float64(csvLens[n])/rows, float64(parquetLens[n])/rows
+		if n < 10 || n > 50 {
+			if csvLens[n]+parquetLens[n] > 0 {
+				t.Errorf("length %d is outside [10, 50]: %d CSV and %d Parquet values", n, csvLens[n], parquetLens[n])
+			}
+			continue
+		}
+		if math.Abs(csvShare-parquetShare) > 0.012 {
+			t.Errorf("length %d has share %.4f in CSV and %.4f in Parquet", n, csvShare, parquetShare)
+		}
+	}
+}
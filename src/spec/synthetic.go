@@ -0,0 +1,169 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/charset"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	parsertypes "github.com/pingcap/tidb/pkg/parser/types"
+	"github.com/pingcap/tidb/pkg/types"
+)
+
+// SyntheticColumns is a group of columns of one type for SyntheticSpecs.
+type SyntheticColumns struct {
+	Count int
+	// Type is the column type as written in SQL, e.g. int or varchar(64).
+	Type string
+	// Options are column options as written in a column comment.
+	Options string
+}
+
+var syntheticTypeRe = regexp.MustCompile(`^([a-z]+)\s*(?:\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\))?$`)
+
+// SyntheticSpecs builds the specs of a table from column groups instead of
+// a CREATE TABLE statement. Columns are named after their type, e.g. int_0,
+// int_1, varchar_0, in the order of the groups.
+func SyntheticSpecs(groups []SyntheticColumns, opts ParseOptions) ([]*ColumnSpec, error) {
+	tbInfo := &model.TableInfo{Name: ast.NewCIStr("synthetic")}
+	counts := make(map[string]int)
+	for _, g := range groups {
+		if g.Count <= 0 {
+			return nil, fmt.Errorf("synthetic column count must be positive, got %d for type %q", g.Count, g.Type)
+		}
+		ft, name, err := syntheticFieldType(g.Type)
+		if err != nil {
+			return nil, err
+		}
+		for range g.Count {
+			offset := len(tbInfo.Columns)
+			tbInfo.Columns = append(tbInfo.Columns, &model.ColumnInfo{
+				ID:        int64(offset + 1),
+				Name:      ast.NewCIStr(name + "_" + strconv.Itoa(counts[name])),
+				Offset:    offset,
+				FieldType: *ft.Clone(),
+				Comment:   g.Options,
+				State:     model.StatePublic,
+			})
+			counts[name]++
+		}
+	}
+	return specsFromTableInfo(tbInfo, opts)
+}
+
+// NamedColumn is a column of NamedSpecs.
+type NamedColumn struct {
+	Name string
+	// Type is the column type as written in SQL, e.g. bigint or varchar(64).
+	Type string
+}
+
+// NamedSpecs builds the specs of a table from column names and types instead
+// of a CREATE TABLE statement, e.g. for the header of a CSV file. Names are
+// lowercased like SQL column names and must be unique.
+func NamedSpecs(cols []NamedColumn, opts ParseOptions) ([]*ColumnSpec, error) {
+	tbInfo := &model.TableInfo{Name: ast.NewCIStr("named")}
+	seen := make(map[string]struct{}, len(cols))
+	for i, c := range cols {
+		if c.Name == "" {
+			return nil, fmt.Errorf("column %d has an empty name", i+1)
+		}
+		name := ast.NewCIStr(c.Name)
+		if _, ok := seen[name.L]; ok {
+			return nil, fmt.Errorf("duplicate column name %s", c.Name)
+		}
+		seen[name.L] = struct{}{}
+		ft, _, err := syntheticFieldType(c.Type)
+		if err != nil {
+			return nil, err
+		}
+		tbInfo.Columns = append(tbInfo.Columns, &model.ColumnInfo{
+			ID:        int64(i + 1),
+			Name:      name,
+			Offset:    i,
+			FieldType: *ft,
+			State:     model.StatePublic,
+		})
+	}
+	return specsFromTableInfo(tbInfo, opts)
+}
+
+// syntheticFieldType parses a SQL column type like decimal(10,2) and returns
+// its field type and base name.
+func syntheticFieldType(typ string) (*types.FieldType, string, error) {
+	m := syntheticTypeRe.FindStringSubmatch(strings.ToLower(strings.TrimSpace(typ)))
+	if m == nil {
+		return nil, "", fmt.Errorf("invalid synthetic column type %q", typ)
+	}
+	name := m[1]
+	tp := parsertypes.StrToType(name)
+	if _, ok := DefaultSpecs[tp]; !ok || tp == mysql.TypeEnum || tp == mysql.TypeSet {
+		return nil, "", fmt.Errorf("unsupported synthetic column type %q", typ)
+	}
+
+	ft := types.NewFieldType(tp)
+	flen, decimal := mysql.GetDefaultFieldLengthAndDecimal(tp)
+	if tp == mysql.TypeString {
+		flen = 1
+	}
+	var args []int
+	for _, s := range m[2:] {
+		if s != "" {
+			v, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid synthetic column type %q", typ)
+			}
+			args = append(args, v)
+		}
+	}
+
+	switch tp {
+	case mysql.TypeNewDecimal:
+		if len(args) > 0 {
+			flen, decimal = args[0], 0
+		}
+		if len(args) > 1 {
+			decimal = args[1]
+		}
+		if flen > mysql.MaxDecimalWidth {
+			return nil, "", fmt.Errorf("decimal precision %d of synthetic column type %q exceeds %d", flen, typ, mysql.MaxDecimalWidth)
+		}
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong:
+		// A display width like int(11) is accepted and has no effect.
+		if len(args) > 1 {
+			return nil, "", fmt.Errorf("synthetic column type %q takes at most a display width", typ)
+		}
+	case mysql.TypeString, mysql.TypeVarchar:
+		if len(args) > 1 || (len(args) == 0 && tp == mysql.TypeVarchar) {
+			return nil, "", fmt.Errorf("synthetic column type %q needs a single length", typ)
+		}
+		if len(args) == 1 {
+			flen = args[0]
+		}
+	case mysql.TypeDatetime, mysql.TypeTimestamp, mysql.TypeDuration:
+		if len(args) > 1 {
+			return nil, "", fmt.Errorf("synthetic column type %q takes at most a fractional seconds precision", typ)
+		}
+		if len(args) == 1 {
+			decimal = args[0]
+		}
+		if decimal > types.MaxFsp {
+			return nil, "", fmt.Errorf("fractional seconds precision of synthetic column type %q exceeds %d", typ, types.MaxFsp)
+		}
+	default:
+		if len(args) > 0 {
+			return nil, "", fmt.Errorf("synthetic column type %q takes no length", typ)
+		}
+	}
+	ft.SetFlen(flen)
+	ft.SetDecimal(decimal)
+	if strings.Contains(name, "binary") || strings.Contains(name, "blob") {
+		ft.SetCharset(charset.CharsetBin)
+		ft.SetCollate(charset.CollationBin)
+	}
+	return ft, name, nil
+}
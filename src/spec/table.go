@@ -0,0 +1,44 @@
+package spec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Table is one table of a SQL schema.
+type Table struct {
+	Specs []*ColumnSpec
+	// Broadcast gives every file the same rows, for small dimension tables.
+	// It is set by the table comment broadcast=true.
+	Broadcast bool
+}
+
+// parseComment sets the options of a table comment. Unlike column comments,
+// table comments may hold free text, which is ignored.
+func (t *Table) parseComment(comment string) error {
+	comment = strings.ReplaceAll(comment, " ", "")
+	if comment == "" {
+		return nil
+	}
+
+	opts, err := splitCommentOpts(comment)
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		k, v, ok := strings.Cut(opt, "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "broadcast":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid broadcast: %q", v)
+			}
+			t.Broadcast = b
+		}
+	}
+	return nil
+}
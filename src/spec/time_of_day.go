@@ -0,0 +1,108 @@
+package spec
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/schema"
+)
+
+// microsPerDay bounds time column values, which are times of day in
+// [00:00:00, 24:00:00).
+const microsPerDay = int64(24 * time.Hour / time.Microsecond)
+
+// setTimeOfDayUnit selects the Parquet type of a time column for
+// timestamp_unit: INT32 TIME_MILLIS, INT64 TIME_MICROS or the nanosecond TIME
+// logical type on INT64.
+func (c *ColumnSpec) setTimeOfDayUnit(u TimestampUnit) error {
+	c.TimestampUnit = u
+	c.Type, c.Converted, c.Logical = parquet.Types.Int64, schema.ConvertedTypes.TimeMicros, nil
+	switch u {
+	case TimestampMillis:
+		if c.FSP > 3 {
+			return fmt.Errorf("timestamp_unit=millis holds up to 3 fractional digits, column %s has fsp %d", c.OrigName, c.FSP)
+		}
+		c.Type, c.Converted = parquet.Types.Int32, schema.ConvertedTypes.TimeMillis
+	case TimestampNanos:
+		c.Converted = schema.ConvertedTypes.None
+		c.Logical = schema.NewTimeLogicalType(true, schema.TimeUnitNanos)
+	case TimestampInt96:
+		return fmt.Errorf("timestamp_unit=int96 is not supported for time column %s", c.OrigName)
+	}
+	return nil
+}
+
+// generateTimeOfDay draws a time of day as microseconds since midnight,
+// truncated to the column's fsp. time_profile=business_hours puts most values
+// between 9:00 and 17:00.
+func (c *ColumnSpec) generateTimeOfDay(rng *rand.Rand) int64 {
+	var v int64
+	if c.TimeProfile == TimeBusinessHours && rng.Intn(100) < businessHoursPercent {
+		v = (9 * time.Hour).Microseconds() + rng.Int63n((8 * time.Hour).Microseconds())
+	} else {
+		v = rng.Int63n(microsPerDay)
+	}
+	unit := int64(1)
+	for range 6 - min(max(c.FSP, 0), 6) {
+		unit *= 10
+	}
+	return v - v%unit
+}
+
+// formatTimeOfDay formats microseconds since midnight as HH:MM:SS with the
+// column's fractional seconds.
+func (c *ColumnSpec) formatTimeOfDay(micros int64) string {
+	return time.UnixMicro(micros).UTC().Format(c.timeLayout(time.TimeOnly))
+}
+
+// timeOfDayValue returns microseconds since midnight as the Parquet value of
+// the column's unit: int32 millis, int64 micros or int64 nanos.
+func (c *ColumnSpec) timeOfDayValue(micros int64) any {
+	switch c.TimestampUnit {
+	case TimestampMillis:
+		return int32(micros / 1000)
+	case TimestampNanos:
+		return micros * 1000
+	default:
+		return micros
+	}
+}
+
+func (c *ColumnSpec) generateTimeOfDayParquet(rowID int, out []int64, defLevel []int16, rng *rand.Rand) {
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
+	for i := range len(out) {
+		if nullMap[i] {
+			defLevel[i] = 0
+		} else {
+			defLevel[i] = 1
+			out[i] = c.generateTimeOfDay(rng)
+			if c.TimestampUnit == TimestampNanos {
+				out[i] *= 1000
+			}
+		}
+	}
+}
+
+func (c *ColumnSpec) generateTimeOfDayMillisParquet(rowID int, out []int32, defLevel []int16, rng *rand.Rand) {
+	nullMap := c.generateBatchNull(rowID, len(out), rng)
+	for i := range len(out) {
+		if nullMap[i] {
+			defLevel[i] = 0
+		} else {
+			defLevel[i] = 1
+			out[i] = int32(c.generateTimeOfDay(rng) / 1000)
+		}
+	}
+}
+
+// parseTimeOfDay parses HH:MM:SS with optional fractional seconds into
+// microseconds since midnight.
+func parseTimeOfDay(s string) (int64, error) {
+	t, err := time.Parse(time.TimeOnly, s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)).Microseconds(), nil
+}
@@ -0,0 +1,43 @@
+package spec
+
+import (
+	"math/rand"
+	"time"
+)
+
+// TimeProfile decides how generated times spread over the days and hours of
+// the column's window.
+type TimeProfile int
+
+const (
+	// TimeUniform spreads times evenly.
+	TimeUniform TimeProfile = iota
+	// TimeBusinessHours puts most times on weekdays between 9:00 and 17:00.
+	TimeBusinessHours
+)
+
+// businessHoursPercent is the share of time_profile=business_hours values
+// placed in business hours, the rest stay uniform.
+const businessHoursPercent = 80
+
+// businessHoursTime returns a time of [start, end) on a weekday between 9:00
+// and 17:00 for businessHoursPercent of the values, and a uniform time
+// otherwise or when the window has no business hours.
+func businessHoursTime(start, end time.Time, rng *rand.Rand) time.Time {
+	startMicro := start.UnixMicro()
+	span := end.UnixMicro() - startMicro
+	if rng.Intn(100) < businessHoursPercent {
+		for range 8 {
+			t := time.UnixMicro(startMicro + rng.Int63n(span)).In(start.Location())
+			if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+				continue
+			}
+			y, m, d := t.Date()
+			t = time.Date(y, m, d, 9, 0, 0, 0, t.Location()).Add(time.Duration(rng.Int63n((8 * time.Hour).Microseconds())) * time.Microsecond)
+			if !t.Before(start) && t.Before(end) {
+				return t
+			}
+		}
+	}
+	return time.UnixMicro(startMicro + rng.Int63n(span)).In(start.Location())
+}
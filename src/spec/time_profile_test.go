@@ -0,0 +1,73 @@
+package spec
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestBusinessHoursHistogram(t *testing.T) {
+	const samples = 20_000
+	c := testSpec(t, "v datetime NOT NULL COMMENT 'time_profile=business_hours, date_start=2024-01-01, date_end=2025-01-01'")
+	rng := rand.New(rand.NewSource(1))
+	var hours [24]int
+	business, weekend := 0, 0
+	for i := range samples {
+		s := GenerateRawField(i, c, rng)
+		ts, err := time.Parse(time.DateTime, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ts.Year() != 2024 {
+			t.Fatalf("%s is outside the window", s)
+		}
+		hours[ts.Hour()]++
+		switch wd := ts.Weekday(); {
+		case wd == time.Saturday || wd == time.Sunday:
+			weekend++
+		case ts.Hour() >= 9 && ts.Hour() < 17:
+			business++
+		}
+	}
+
+	// 80% in business hours, plus the uniform rest that lands there:
+	// 0.8 + 0.2 * 5/7 * 8/24 = 0.848. Weekends only get 0.2 * 2/7 = 0.057.
+	if share := float64(business) / samples; share < 0.82 || share > 0.875 {
+		t.Errorf("business hours hold %.3f of the values, want about 0.85", share)
+	}
+	if share := float64(weekend) / samples; share > 0.075 {
+		t.Errorf("weekends hold %.3f of the values, want about 0.06", share)
+	}
+	minBusiness, maxNight := samples, 0
+	for h, n := range hours {
+		if h >= 9 && h < 17 {
+			minBusiness = min(minBusiness, n)
+		} else {
+			maxNight = max(maxNight, n)
+		}
+	}
+	if minBusiness < 5*maxNight {
+		t.Errorf("hour histogram %v doesn't peak in business hours", hours)
+	}
+}
+
+func TestBusinessHoursTimeOfDay(t *testing.T) {
+	const samples = 10_000
+	c := testSpec(t, "v time NOT NULL COMMENT 'time_profile=business_hours'")
+	rng := rand.New(rand.NewSource(1))
+	inHours := 0
+	for i := range samples {
+		s := GenerateRawField(i, c, rng)
+		ts, err := time.Parse(time.TimeOnly, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ts.Hour() >= 9 && ts.Hour() < 17 {
+			inHours++
+		}
+	}
+	// 0.8 + 0.2 * 8/24 = 0.867.
+	if share := float64(inHours) / samples; share < 0.84 || share > 0.895 {
+		t.Errorf("%.3f of the times are between 09:00 and 17:00, want about 0.87", share)
+	}
+}
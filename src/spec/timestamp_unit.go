@@ -0,0 +1,123 @@
+package spec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/schema"
+)
+
+// TimestampUnit decides how timestamp and datetime columns are stored in
+// Parquet.
+type TimestampUnit int
+
+const (
+	// TimestampMicros is an INT64 with the TIMESTAMP_MICROS converted type.
+	TimestampMicros TimestampUnit = iota
+	// TimestampMillis is an INT64 with the TIMESTAMP_MILLIS converted type.
+	TimestampMillis
+	// TimestampNanos is an INT64 with the nanosecond TIMESTAMP logical type,
+	// which has no converted type.
+	TimestampNanos
+	// TimestampInt96 is the legacy INT96 of Impala and older Spark: the
+	// nanoseconds of the day followed by the Julian day, little endian.
+	TimestampInt96
+)
+
+var timestampUnitNames = map[string]TimestampUnit{
+	"micros": TimestampMicros,
+	"millis": TimestampMillis,
+	"nanos":  TimestampNanos,
+	"int96":  TimestampInt96,
+}
+
+func (u TimestampUnit) String() string {
+	for name, v := range timestampUnitNames {
+		if v == u {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// julianDayOfEpoch is the Julian day number of 1970-01-01.
+const julianDayOfEpoch = 2440588
+
+// nanosTimeRange bounds the instants an int64 of nanoseconds since the epoch
+// can hold.
+var nanosTimeRange = [2]time.Time{time.Unix(0, math.MinInt64), time.Unix(0, math.MaxInt64)}
+
+// setTimestampUnit selects the Parquet type of a timestamp or datetime
+// column for timestamp_unit.
+func (c *ColumnSpec) setTimestampUnit(u TimestampUnit) error {
+	if c.SQLType == "time" {
+		return c.setTimeOfDayUnit(u)
+	}
+	if c.SQLType != "timestamp" && c.SQLType != "datetime" {
+		return fmt.Errorf("timestamp_unit is only supported for timestamp, datetime and time columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	c.TimestampUnit = u
+	c.Type, c.Converted, c.Logical = parquet.Types.Int64, schema.ConvertedTypes.TimestampMicros, nil
+	switch u {
+	case TimestampMillis:
+		c.Converted = schema.ConvertedTypes.TimestampMillis
+	case TimestampNanos:
+		c.Logical = schema.NewTimestampLogicalType(true, schema.TimeUnitNanos)
+		start, end := c.timeWindow()
+		if start.Before(nanosTimeRange[0]) || end.After(nanosTimeRange[1]) {
+			return fmt.Errorf("timestamp_unit=nanos holds times from %d to %d, column %s is out of range", nanosTimeRange[0].Year(), nanosTimeRange[1].Year(), c.OrigName)
+		}
+	case TimestampInt96:
+		c.Type, c.Converted = parquet.Types.Int96, schema.ConvertedTypes.None
+	}
+	return nil
+}
+
+// timeUnit returns the logical time unit of an INT64 timestamp column.
+func (c *ColumnSpec) timeUnit() schema.TimeUnitType {
+	switch c.TimestampUnit {
+	case TimestampMillis:
+		return schema.TimeUnitMillis
+	case TimestampNanos:
+		return schema.TimeUnitNanos
+	default:
+		return schema.TimeUnitMicros
+	}
+}
+
+// timestampInt64 returns t as the INT64 value of the column's unit.
+func (c *ColumnSpec) timestampInt64(t time.Time) int64 {
+	switch c.TimestampUnit {
+	case TimestampMillis:
+		return t.UnixMilli()
+	case TimestampNanos:
+		return t.UnixNano()
+	default:
+		return t.UnixMicro()
+	}
+}
+
+// Int96FromTime encodes t as an INT96 timestamp.
+func Int96FromTime(t time.Time) parquet.Int96 {
+	secs := t.Unix()
+	days := secs / 86400
+	if secs%86400 < 0 {
+		days--
+	}
+	nanosOfDay := (secs-days*86400)*int64(time.Second) + int64(t.Nanosecond())
+
+	var v parquet.Int96
+	binary.LittleEndian.PutUint64(v[:8], uint64(nanosOfDay))
+	binary.LittleEndian.PutUint32(v[8:], uint32(days+julianDayOfEpoch))
+	return v
+}
+
+// TimeFromInt96 decodes an INT96 timestamp, in UTC.
+func TimeFromInt96(v parquet.Int96) time.Time {
+	nanosOfDay := int64(binary.LittleEndian.Uint64(v[:8]))
+	days := int64(binary.LittleEndian.Uint32(v[8:])) - julianDayOfEpoch
+	return time.Unix(days*86400, nanosOfDay).UTC()
+}
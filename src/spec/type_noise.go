@@ -0,0 +1,40 @@
+package spec
+
+import "fmt"
+
+// numberNoise and timeNoise are the tokens written instead of the values of
+// numeric and time columns by type_noise_percent. They contain no comma, tab,
+// pipe, semicolon or quote, so they never break a CSV row.
+var (
+	numberNoise = []string{"N/A", "abc", "12x", "1.2.3", "#VALUE!", "--", "unknown", "1e", "0x1G"}
+	timeNoise   = []string{"N/A", "not-a-date", "2025-13-45", "31/02/2025", "yesterday", "00:61:99", "TBD"}
+)
+
+// TypeNoise replaces s with a token that does not parse as the column's type
+// for type_noise_percent of the rows, picked from the row ID and the run
+// seed. Other rows keep s.
+func (c *ColumnSpec) TypeNoise(rowID int, s string) string {
+	if c.TypeNoisePercent == 0 {
+		return s
+	}
+	src := splitmixSource{state: c.runContext().Seed ^ c.runSalt ^ (uint64(rowID) * 0xc2b2ae3d27d4eb4f)}
+	if float64(src.Uint64()%1_000_000) >= c.TypeNoisePercent*10_000 {
+		return s
+	}
+	tokens := numberNoise
+	if isTimeType(c.SQLType) {
+		tokens = timeNoise
+	}
+	return tokens[src.Uint64()%uint64(len(tokens))]
+}
+
+// checkTypeNoise validates type_noise_percent.
+func (c *ColumnSpec) checkTypeNoise() error {
+	if c.TypeNoisePercent == 0 {
+		return nil
+	}
+	if !c.IsNumeric() && !isTimeType(c.SQLType) {
+		return fmt.Errorf("type_noise_percent is only supported for numeric and time columns, column %s is %s", c.OrigName, c.SQLType)
+	}
+	return nil
+}
@@ -14,17 +14,34 @@ const (
 )
 
 func CSVSeparatorAndEndline(cfg config.CSVConfig) (string, string) {
-	separator := cfg.Separator
+	separator := unescapeOrRaw(cfg.Separator)
 	if separator == "" {
 		separator = defaultCSVSeparator
 	}
-	endline := cfg.EndLine
+	endline := unescapeOrRaw(cfg.EndLine)
 	if endline == "" {
 		endline = defaultCSVEndLine
 	}
 	return separator, endline
 }
 
+// unescapeOrRaw decodes escapes like \t, keeping s as written if it has
+// invalid ones, which config.Validate reports.
+func unescapeOrRaw(s string) string {
+	if v, err := config.UnescapeCSV(s); err == nil {
+		return v
+	}
+	return s
+}
+
+// CSVNullString returns the token of NULL values in CSV.
+func CSVNullString(cfg config.CSVConfig) string {
+	if cfg.NullString != nil {
+		return *cfg.NullString
+	}
+	return spec.NullValue
+}
+
 // Streaming data structure for chunk-based processing
 type FileChunk struct {
 	Data   []byte
@@ -77,15 +94,24 @@ func (c *chunkCalculator) EstimateRowSize(specs []*spec.ColumnSpec) int {
 		}
 	}
 
-	// Add overhead for delimiters (CSV) or encoding (Parquet)
-	if c.cfg.Common.FileFormat == "csv" {
+	// Add overhead for delimiters (CSV), keys (NDJSON) or encoding (Parquet)
+	switch c.cfg.Common.FileFormat {
+	case "csv":
 		separator, endline := CSVSeparatorAndEndline(c.cfg.CSV)
 		delimiterOverhead := len(endline)
 		if len(specs) > 0 {
 			delimiterOverhead += (len(specs) - 1) * len(separator)
 		}
+		if c.cfg.CSV.QuoteMode() == "always" {
+			delimiterOverhead += 2 * len(specs)
+		}
 		totalSize += delimiterOverhead
-	} else {
+	case "ndjson":
+		totalSize += len("{}\n")
+		for _, columnSpec := range specs {
+			totalSize += len(columnSpec.OrigName) + len(`"":"",`)
+		}
+	default:
 		totalSize = int(float64(totalSize) * 1.2) // 20% overhead for Parquet encoding
 	}
 
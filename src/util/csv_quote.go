@@ -0,0 +1,113 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"dataWriter/src/config"
+)
+
+// CSVQuoter writes and splits CSV fields quoted as in RFC 4180: wrapped in
+// double quotes, with the quotes inside doubled.
+type CSVQuoter struct {
+	mode      string
+	separator string
+	endline   string
+}
+
+// NewCSVQuoter returns the quoter for csv.quote and the separator and
+// endline of cfg.
+func NewCSVQuoter(cfg config.CSVConfig) CSVQuoter {
+	separator, endline := CSVSeparatorAndEndline(cfg)
+	return CSVQuoter{mode: cfg.QuoteMode(), separator: separator, endline: endline}
+}
+
+// Enabled reports whether fields may be quoted.
+func (q CSVQuoter) Enabled() bool {
+	return q.mode != "never"
+}
+
+// Separator returns the field separator.
+func (q CSVQuoter) Separator() string {
+	return q.separator
+}
+
+// Append appends s to buf, quoted if the mode asks for it.
+func (q CSVQuoter) Append(buf []byte, s string) []byte {
+	if !q.needsQuote(s) {
+		return append(buf, s...)
+	}
+	buf = append(buf, '"')
+	for {
+		i := strings.IndexByte(s, '"')
+		if i < 0 {
+			break
+		}
+		buf = append(buf, s[:i+1]...)
+		buf = append(buf, '"')
+		s = s[i+1:]
+	}
+	buf = append(buf, s...)
+	return append(buf, '"')
+}
+
+func (q CSVQuoter) needsQuote(s string) bool {
+	switch q.mode {
+	case "always":
+		return true
+	case "necessary":
+		return strings.ContainsAny(s, "\"\r\n") || strings.Contains(s, q.separator) || strings.Contains(s, q.endline)
+	default:
+		return false
+	}
+}
+
+// Split splits a record into its fields, removing the quotes.
+func (q CSVQuoter) Split(record string) ([]string, error) {
+	if !q.Enabled() || !strings.Contains(record, `"`) {
+		return strings.Split(record, q.separator), nil
+	}
+	var fields []string
+	for {
+		if !strings.HasPrefix(record, `"`) {
+			i := strings.Index(record, q.separator)
+			if i < 0 {
+				return append(fields, record), nil
+			}
+			fields = append(fields, record[:i])
+			record = record[i+len(q.separator):]
+			continue
+		}
+
+		var b strings.Builder
+		rest := record[1:]
+		for {
+			i := strings.IndexByte(rest, '"')
+			if i < 0 {
+				return nil, errors.New("unterminated quoted field")
+			}
+			b.WriteString(rest[:i])
+			rest = rest[i+1:]
+			if !strings.HasPrefix(rest, `"`) {
+				break
+			}
+			b.WriteByte('"')
+			rest = rest[1:]
+		}
+		fields = append(fields, b.String())
+		if rest == "" {
+			return fields, nil
+		}
+		if !strings.HasPrefix(rest, q.separator) {
+			return nil, fmt.Errorf("unexpected %q after quoted field", rest[:1])
+		}
+		record = rest[len(q.separator):]
+	}
+}
+
+// LineComplete reports whether line holds whole records, i.e. an endline
+// found at its end is not inside a quoted field.
+func (q CSVQuoter) LineComplete(line string) bool {
+	return !q.Enabled() || strings.Count(line, `"`)%2 == 0
+}
@@ -0,0 +1,15 @@
+package util
+
+import "dataWriter/src/util/logger"
+
+// Debugf logs a debug message.
+func Debugf(format string, args ...any) { logger.Debugf(format, args...) }
+
+// Infof logs an info message.
+func Infof(format string, args ...any) { logger.Infof(format, args...) }
+
+// Warnf logs a warning.
+func Warnf(format string, args ...any) { logger.Warnf(format, args...) }
+
+// Errorf logs an error.
+func Errorf(format string, args ...any) { logger.Errorf(format, args...) }
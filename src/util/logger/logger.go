@@ -0,0 +1,151 @@
+// Package logger is the leveled logger of data-writer. It imports nothing
+// else from data-writer, so every package can log through it.
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Level is the severity of a log message.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = [...]string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return "unknown"
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses debug, info, warn or error.
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q, must be debug, info, warn or error", s)
+}
+
+// Logger receives the messages of data-writer. Programs embedding it can
+// route them to their own logging with SetLogger.
+type Logger interface {
+	Log(level Level, msg string)
+}
+
+var currentLogger atomic.Pointer[Logger]
+
+func init() {
+	SetLogger(NewConsoleLogger(os.Stderr, LevelInfo))
+}
+
+// SetLogger replaces the logger, the console logger at info level by default.
+func SetLogger(l Logger) {
+	currentLogger.Store(&l)
+}
+
+func logf(level Level, format string, args ...any) {
+	(*currentLogger.Load()).Log(level, fmt.Sprintf(format, args...))
+}
+
+// Debugf logs a debug message.
+func Debugf(format string, args ...any) { logf(LevelDebug, format, args...) }
+
+// Infof logs an info message.
+func Infof(format string, args ...any) { logf(LevelInfo, format, args...) }
+
+// Warnf logs a warning.
+func Warnf(format string, args ...any) { logf(LevelWarn, format, args...) }
+
+// Errorf logs an error.
+func Errorf(format string, args ...any) { logf(LevelError, format, args...) }
+
+// consoleLogger writes human-readable lines like the standard log package,
+// with the level in front of warnings, errors and debug messages.
+type consoleLogger struct {
+	mu  sync.Mutex
+	w   io.Writer
+	min Level
+}
+
+// NewConsoleLogger returns a logger writing messages of level min and above
+// to w as plain lines.
+func NewConsoleLogger(w io.Writer, min Level) Logger {
+	return &consoleLogger{w: w, min: min}
+}
+
+var consolePrefixes = [...]string{"Debug: ", "", "Warning: ", "Error: "}
+
+func (l *consoleLogger) Log(level Level, msg string) {
+	if level < l.min {
+		return
+	}
+	line := time.Now().Format("2006/01/02 15:04:05 ") + consolePrefixes[level] + msg + "\n"
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = io.WriteString(l.w, line)
+}
+
+// jsonLogger writes one JSON object per message, e.g.
+// {"time":"2025-01-02T15:04:05.123Z","level":"warn","msg":"..."}.
+type jsonLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	min Level
+}
+
+// NewJSONLogger returns a logger writing messages of level min and above to
+// w as JSON lines.
+func NewJSONLogger(w io.Writer, min Level) Logger {
+	return &jsonLogger{enc: json.NewEncoder(w), min: min}
+}
+
+func (l *jsonLogger) Log(level Level, msg string) {
+	if level < l.min {
+		return
+	}
+	entry := struct {
+		Time  string `json:"time"`
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+	}{time.Now().UTC().Format(time.RFC3339Nano), level.String(), msg}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_ = l.enc.Encode(entry)
+}
+
+// RedirectStdLog sends what dependencies write with the standard log
+// package to the logger.
+// Lines starting with "Warning: " are logged as warnings, others as info.
+func RedirectStdLog() {
+	log.SetFlags(0)
+	log.SetOutput(stdLogWriter{})
+}
+
+type stdLogWriter struct{}
+
+func (stdLogWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	level := LevelInfo
+	if rest, ok := strings.CutPrefix(msg, "Warning: "); ok {
+		level, msg = LevelWarn, rest
+	}
+	(*currentLogger.Load()).Log(level, msg)
+	return len(p), nil
+}
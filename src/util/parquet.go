@@ -0,0 +1,28 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/parquet/compress"
+)
+
+// ParquetCompressionCodec returns the codec named by parquet.compression.
+func ParquetCompressionCodec(name string) (compress.Compression, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "snappy":
+		return compress.Codecs.Snappy, nil
+	case "zstd":
+		return compress.Codecs.Zstd, nil
+	case "gzip":
+		return compress.Codecs.Gzip, nil
+	case "brotli":
+		return compress.Codecs.Brotli, nil
+	case "lz4_raw", "lz4":
+		return compress.Codecs.Lz4Raw, nil
+	case "uncompressed", "none":
+		return compress.Codecs.Uncompressed, nil
+	default:
+		return compress.Codecs.Uncompressed, fmt.Errorf("unsupported parquet compression: %q", name)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 	"unicode/utf8"
@@ -37,6 +38,20 @@ type ProgressLogger struct {
 	bytes      atomic.Int64
 	format     string
 	platform   string
+	// inFlight is the bytes of streamed chunks generated but not yet
+	// written, tracked with common.max_memory.
+	inFlight      atomic.Int64
+	trackInFlight atomic.Bool
+	// status replaces the action in the box while it is set.
+	status atomic.Pointer[string]
+	// detail lists the files under the box when set.
+	detail atomic.Pointer[fileDetail]
+	// sink receives the counts on every tick.
+	sink atomic.Pointer[ProgressSink]
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
 }
 
 var (
@@ -57,6 +72,7 @@ func InitializeProgressLogger(
 			format:     "-",
 			platform:   "-",
 		}
+		globalProgressLogger.SetSink(defaultProgressSink(globalProgressLogger))
 		globalProgressLogger.start()
 	}
 	return globalProgressLogger
@@ -82,6 +98,18 @@ func (p *ProgressLogger) UpdateFiles(delta int32) {
 	p.files.Add(delta)
 }
 
+// UpdateInFlight adds delta to the in-flight bytes, which are shown from
+// the first call on.
+func (p *ProgressLogger) UpdateInFlight(delta int64) {
+	p.trackInFlight.Store(true)
+	p.inFlight.Add(delta)
+}
+
+// inFlightBytes returns the in-flight bytes and whether they are tracked.
+func (p *ProgressLogger) inFlightBytes() (int64, bool) {
+	return p.inFlight.Load(), p.trackInFlight.Load()
+}
+
 // SetContext sets the format/platform for display.
 func (p *ProgressLogger) SetContext(format string, platform string) {
 	if format != "" {
@@ -92,63 +120,70 @@ func (p *ProgressLogger) SetContext(format string, platform string) {
 	}
 }
 
+// SetStatus shows status instead of the action, an empty status restores it.
+func (p *ProgressLogger) SetStatus(status string) {
+	p.status.Store(&status)
+}
+
 // Snapshot returns the current file and byte counts.
 func (p *ProgressLogger) Snapshot() (int64, int64) {
 	return int64(p.files.Load()), p.bytes.Load()
 }
 
+// Stop renders the final state and waits for the render loop to exit, so
+// nothing else is printed to stdout after it returns.
+func (p *ProgressLogger) Stop() {
+	if p.done == nil {
+		return
+	}
+	p.stopOnce.Do(func() { close(p.stop) })
+	<-p.done
+}
+
 func (p *ProgressLogger) start() {
 	if p.totalFiles <= 0 {
 		return
 	}
 
-	first := true
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+
 	go func() {
-		// Update the same 2-line box in place using ANSI cursor moves.
+		defer close(p.done)
+
 		ticker := time.NewTicker(p.interval)
 		defer ticker.Stop()
 
-		prevFiles := int64(p.files.Load())
-		prevBytes := p.bytes.Load()
-		prevTime := time.Now()
-
-		for range ticker.C {
-			curFiles := int64(p.files.Load())
-			curBytes := p.bytes.Load()
-			now := time.Now()
-			elapsed := now.Sub(prevTime).Seconds()
-
-			bytesPerSec := progressRate(curBytes-prevBytes, elapsed)
-			filesPerSec := progressRate(curFiles-prevFiles, elapsed)
-
-			box := progressBox(
-				p.totalFiles,
-				curFiles,
-				curBytes,
-				bytesPerSec,
-				filesPerSec,
-				p.action,
-				p.format,
-				p.platform,
-			)
-
-			if !first {
-				fmt.Fprintf(os.Stdout, "\033[%dA", progressLines)
-			}
-			fmt.Fprint(os.Stdout, box)
-			first = false
-
-			prevFiles = curFiles
-			prevBytes = curBytes
-			prevTime = now
+		report := func() bool {
+			curFiles := int(p.files.Load())
+			sink := p.Sink()
+			sink.OnFiles(curFiles, p.totalFiles)
+			sink.OnBytes(p.bytes.Load())
+			return curFiles >= p.totalFiles
+		}
 
-			if int(curFiles) >= p.totalFiles {
-				break
+		for {
+			select {
+			case <-ticker.C:
+				if report() {
+					return
+				}
+			case <-p.stop:
+				report()
+				return
 			}
 		}
 	}()
 }
 
+// currentAction returns the status if set, otherwise the action.
+func (p *ProgressLogger) currentAction() string {
+	if status := p.status.Load(); status != nil && *status != "" {
+		return *status
+	}
+	return p.action
+}
+
 func progressRate(delta int64, elapsedSeconds float64) float64 {
 	if elapsedSeconds <= 0 {
 		return 0
@@ -157,11 +192,18 @@ func progressRate(delta int64, elapsedSeconds float64) float64 {
 }
 
 // NewFileProgressBar creates a themed progress bar for file-based work.
+// Without a terminal the bar is uncolored and redrawn at most every
+// plainProgressInterval.
 func NewFileProgressBar(totalFiles int, action string) *progressbar.ProgressBar {
+	throttle := 65 * time.Millisecond
+	if !stdoutIsTerminal() {
+		throttle = plainProgressInterval
+	}
 	return progressbar.NewOptions(
 		totalFiles,
 		progressbar.OptionSetWriter(os.Stdout),
-		progressbar.OptionEnableColorCodes(true),
+		progressbar.OptionEnableColorCodes(stdoutIsTerminal()),
+		progressbar.OptionThrottle(throttle),
 		progressbar.OptionSetDescription(action),
 		progressbar.OptionSetPredictTime(false),
 		progressbar.OptionSetElapsedTime(false),
@@ -175,7 +217,8 @@ func NewFileProgressBar(totalFiles int, action string) *progressbar.ProgressBar
 }
 
 // progressBox renders a boxed 2x2 layout:
-// left: progress bar + throughput, right: format + platform.
+// left: progress bar + throughput, right: format (and in-flight bytes) +
+// platform.
 func progressBox(
 	total int,
 	files int64,
@@ -185,6 +228,8 @@ func progressBox(
 	action string,
 	format string,
 	platform string,
+	inFlight int64,
+	showInFlight bool,
 ) string {
 	rightColumnWidth := progressBoxInnerWidth - leftColumnWidth - spaceBetweenColumns - borderSidesWidth
 
@@ -195,6 +240,9 @@ func progressBox(
 	bar := renderBar(percent, progressBarWidth)
 	leftTop := fmt.Sprintf("%3d%% %s", int(percent*100), bar)
 	rightTop := "Format: " + format
+	if showInFlight {
+		rightTop += "  In flight: " + units.BytesSize(float64(inFlight))
+	}
 
 	leftBottom := fmt.Sprintf(
 		"%s %s (%s/s, %.2f files/s)",
@@ -0,0 +1,120 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/docker/go-units"
+)
+
+// maxDetailFiles is the most files listed under the progress box, larger
+// runs only show the box.
+const maxDetailFiles = 32
+
+const (
+	detailNameWidth  = 56
+	detailStateWidth = 10
+	colorRed         = "\x1b[91m"
+)
+
+// FileState is the stage of a file in the per-file progress table.
+type FileState int32
+
+const (
+	FilePending FileState = iota
+	FileGenerating
+	// FileWriting means generation finished and the file is being flushed.
+	FileWriting
+	FileDone
+	FileFailed
+)
+
+func (s FileState) String() string {
+	switch s {
+	case FileGenerating:
+		return "generating"
+	case FileWriting:
+		return "writing"
+	case FileDone:
+		return "done"
+	case FileFailed:
+		return "failed"
+	default:
+		return "pending"
+	}
+}
+
+// fileDetail holds the state and written bytes of each file of the run.
+type fileDetail struct {
+	startFileNo int
+	names       []string
+	states      []atomic.Int32
+	bytes       []atomic.Int64
+}
+
+// EnableFileDetail lists the files, named by names from startFileNo on,
+// under the progress box. It does nothing for more than maxDetailFiles files.
+func (p *ProgressLogger) EnableFileDetail(startFileNo int, names []string) {
+	if len(names) > maxDetailFiles || p.detail.Load() != nil {
+		return
+	}
+	p.detail.Store(&fileDetail{
+		startFileNo: startFileNo,
+		names:       names,
+		states:      make([]atomic.Int32, len(names)),
+		bytes:       make([]atomic.Int64, len(names)),
+	})
+}
+
+// SetFileState updates the state of a file in the per-file table.
+func (p *ProgressLogger) SetFileState(fileNo int, state FileState) {
+	d := p.detail.Load()
+	if d == nil {
+		return
+	}
+	if i := fileNo - d.startFileNo; i >= 0 && i < len(d.states) {
+		d.states[i].Store(int32(state))
+	}
+}
+
+// UpdateFileBytes increments the byte counter and the bytes of fileNo.
+func (p *ProgressLogger) UpdateFileBytes(fileNo int, delta int64) {
+	p.UpdateBytes(delta)
+	d := p.detail.Load()
+	if d == nil || delta == 0 {
+		return
+	}
+	if i := fileNo - d.startFileNo; i >= 0 && i < len(d.bytes) {
+		d.bytes[i].Add(delta)
+	}
+}
+
+// render draws one line per file, colored by state.
+func (d *fileDetail) render() string {
+	var b strings.Builder
+	for i, name := range d.names {
+		state := FileState(d.states[i].Load())
+		stateText := padOrTrim(state.String(), detailStateWidth)
+		switch state {
+		case FileGenerating, FileWriting:
+			stateText = colorMagenta + stateText + colorReset
+		case FileFailed:
+			stateText = colorRed + stateText + colorReset
+		case FilePending:
+			stateText = colorDarkGray + stateText + colorReset
+		}
+		line := fmt.Sprintf("  %s %s %s",
+			padOrTrim(name, detailNameWidth),
+			stateText,
+			units.BytesSize(float64(d.bytes[i].Load())),
+		)
+		b.WriteString(padOrTrim(line, progressBoxInnerWidth+2))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (d *fileDetail) lines() int {
+	return len(d.names)
+}
@@ -0,0 +1,146 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/docker/go-units"
+	"golang.org/x/term"
+)
+
+// plainProgressInterval is how often progress is printed when stdout is not
+// a terminal.
+const plainProgressInterval = 10 * time.Second
+
+// ProgressSink receives progress from a ProgressLogger. On every tick, and
+// once more when the logger stops, OnFiles is called with the finished and
+// total file counts, then OnBytes with the bytes written so far. The calls
+// come from a single goroutine.
+type ProgressSink interface {
+	OnFiles(done, total int)
+	OnBytes(n int64)
+}
+
+// SetSink replaces the sink of the logger, e.g. to send progress to a
+// metrics system instead of the terminal.
+func (p *ProgressLogger) SetSink(sink ProgressSink) {
+	p.sink.Store(&sink)
+}
+
+// Sink returns the current sink of the logger.
+func (p *ProgressLogger) Sink() ProgressSink {
+	return *p.sink.Load()
+}
+
+// defaultProgressSink draws the box when stdout is a terminal and prints
+// plain lines otherwise, so logs captured by CI stay free of ANSI escapes.
+func defaultProgressSink(p *ProgressLogger) ProgressSink {
+	if stdoutIsTerminal() {
+		return NewTerminalProgressSink(p)
+	}
+	return NewPlainProgressSink(p, os.Stdout)
+}
+
+func stdoutIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// terminalSink redraws the progress box, and the file table if enabled, in
+// place using ANSI cursor moves.
+type terminalSink struct {
+	p         *ProgressLogger
+	files     int64
+	total     int
+	prevFiles int64
+	prevBytes int64
+	prevTime  time.Time
+	prevLines int
+}
+
+// NewTerminalProgressSink returns a sink drawing the progress box of p on
+// stdout.
+func NewTerminalProgressSink(p *ProgressLogger) ProgressSink {
+	return &terminalSink{p: p, prevTime: time.Now()}
+}
+
+func (s *terminalSink) OnFiles(done, total int) {
+	s.files, s.total = int64(done), total
+}
+
+func (s *terminalSink) OnBytes(n int64) {
+	now := time.Now()
+	elapsed := now.Sub(s.prevTime).Seconds()
+
+	inFlight, showInFlight := s.p.inFlightBytes()
+	out := progressBox(
+		s.total,
+		s.files,
+		n,
+		progressRate(n-s.prevBytes, elapsed),
+		progressRate(s.files-s.prevFiles, elapsed),
+		s.p.currentAction(),
+		s.p.format,
+		s.p.platform,
+		inFlight,
+		showInFlight,
+	)
+	lines := progressLines
+	if d := s.p.detail.Load(); d != nil {
+		out += d.render()
+		lines += d.lines()
+	}
+
+	if s.prevLines > 0 {
+		fmt.Fprintf(os.Stdout, "\033[%dA", s.prevLines)
+	}
+	fmt.Fprint(os.Stdout, out)
+	s.prevLines = lines
+
+	s.prevFiles = s.files
+	s.prevBytes = n
+	s.prevTime = now
+}
+
+// plainSink prints a line without escapes at most every
+// plainProgressInterval while the counts change, and once all files are done.
+type plainSink struct {
+	p         *ProgressLogger
+	w         io.Writer
+	files     int
+	total     int
+	prevFiles int
+	prevBytes int64
+	prevTime  time.Time
+}
+
+// NewPlainProgressSink returns a sink printing the progress of p to w as
+// plain lines.
+func NewPlainProgressSink(p *ProgressLogger, w io.Writer) ProgressSink {
+	return &plainSink{p: p, w: w, prevTime: time.Now()}
+}
+
+func (s *plainSink) OnFiles(done, total int) {
+	s.files, s.total = done, total
+}
+
+func (s *plainSink) OnBytes(n int64) {
+	now := time.Now()
+	changed := s.files != s.prevFiles || n != s.prevBytes
+	finished := s.files >= s.total && s.prevFiles < s.total
+	if !finished && (!changed || now.Sub(s.prevTime) < plainProgressInterval) {
+		return
+	}
+	line := fmt.Sprintf("written %d/%d files, %s", s.files, s.total, units.BytesSize(float64(n)))
+	if inFlight, ok := s.p.inFlightBytes(); ok {
+		line += fmt.Sprintf(", %s in flight", units.BytesSize(float64(inFlight)))
+	}
+	if status := s.p.status.Load(); status != nil && *status != "" {
+		line = *status + ": " + line
+	}
+	fmt.Fprintln(s.w, line)
+	s.prevFiles = s.files
+	s.prevBytes = n
+	s.prevTime = now
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"dataWriter/src/config"
+	"dataWriter/src/generator"
+	"dataWriter/src/spec"
+	"dataWriter/src/util"
+
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/schema"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"golang.org/x/sync/errgroup"
+)
+
+// storeReaderAt adapts an ExternalFileReader to the io.ReaderAt the parquet
+// reader needs.
+type storeReaderAt struct {
+	mu sync.Mutex
+	r  storage.ExternalFileReader
+}
+
+func (s *storeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.r.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(s.r, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (s *storeReaderAt) Seek(offset int64, whence int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Seek(offset, whence)
+}
+
+// validateResult is the outcome of checking one Parquet file.
+type validateResult struct {
+	path     string
+	rows     int64
+	problems []string
+}
+
+// validateParquetFile checks the schema of a Parquet file against specs, the
+// dictionary size of dict_cardinality columns, and that no column chunk is
+// larger than maxChunkBytes if it is set.
+func validateParquetFile(ctx context.Context, store storage.ExternalStorage, path string, specs []*spec.ColumnSpec, maxChunkBytes int64) (*validateResult, error) {
+	reader, err := store.Open(ctx, path, nil)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to open file: %s", path)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	res := &validateResult{path: path}
+	pr, err := file.NewParquetReader(&storeReaderAt{r: reader})
+	if err != nil {
+		res.problems = append(res.problems, fmt.Sprintf("not a valid parquet file: %v", err))
+		return res, nil
+	}
+	defer func() {
+		_ = pr.Close()
+	}()
+
+	res.rows = pr.NumRows()
+	fileSchema := pr.MetaData().Schema
+	if fileSchema.NumColumns() != len(specs) {
+		res.problems = append(res.problems, fmt.Sprintf("has %d columns, schema has %d", fileSchema.NumColumns(), len(specs)))
+		return res, nil
+	}
+	for i, columnSpec := range specs {
+		col := fileSchema.Column(i)
+		if col.Name() != columnSpec.OrigName {
+			res.problems = append(res.problems, fmt.Sprintf("column %d is named %s, expected %s", i, col.Name(), columnSpec.OrigName))
+			continue
+		}
+		node, err := columnSpec.ParquetNode()
+		if err != nil {
+			return nil, errors.Annotatef(err, "column %s", columnSpec.OrigName)
+		}
+		expected := node.(*schema.PrimitiveNode)
+		if col.PhysicalType() != expected.PhysicalType() {
+			res.problems = append(res.problems, fmt.Sprintf("column %s has type %s, expected %s", col.Name(), col.PhysicalType(), expected.PhysicalType()))
+			continue
+		}
+		if col.PhysicalType() == parquet.Types.FixedLenByteArray && col.TypeLength() != expected.TypeLength() {
+			res.problems = append(res.problems, fmt.Sprintf("column %s has type length %d, expected %d", col.Name(), col.TypeLength(), expected.TypeLength()))
+		}
+	}
+	for i, columnSpec := range specs {
+		if columnSpec.DictCardinality == 0 {
+			continue
+		}
+		for rg := range pr.NumRowGroups() {
+			nullable := columnSpec.NullPercent > 0 || columnSpec.NullCount > 0
+			problem, err := checkDictPage(pr.RowGroup(rg), i, columnSpec.DictCardinality, nullable)
+			if err != nil {
+				return nil, errors.Annotatef(err, "row group %d of %s", rg, path)
+			}
+			if problem != "" {
+				res.problems = append(res.problems, fmt.Sprintf("column %s of row group %d %s", columnSpec.OrigName, rg, problem))
+			}
+		}
+	}
+	if maxChunkBytes > 0 {
+		for rg := range pr.NumRowGroups() {
+			rgMeta := pr.MetaData().RowGroup(rg)
+			for i := range rgMeta.NumColumns() {
+				chunk, err := rgMeta.ColumnChunk(i)
+				if err != nil {
+					return nil, errors.Annotatef(err, "row group %d of %s", rg, path)
+				}
+				if size := chunk.TotalUncompressedSize(); size > maxChunkBytes {
+					res.problems = append(res.problems, fmt.Sprintf("column %s of row group %d has %d bytes, more than max_column_chunk_bytes",
+						fileSchema.Column(i).Name(), rg, size))
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// checkDictPage checks that column i of a row group is dictionary encoded
+// with exactly k entries, if the row group has at least k rows. NULLs may
+// leave out some of the values of a nullable column.
+func checkDictPage(rgReader *file.RowGroupReader, i, k int, nullable bool) (string, error) {
+	if rgReader.NumRows() < int64(k) {
+		return "", nil
+	}
+	pageReader, err := rgReader.GetColumnPageReader(i)
+	if err != nil {
+		return "", err
+	}
+	if !pageReader.Next() {
+		return "", pageReader.Err()
+	}
+	dictPage, ok := pageReader.Page().(*file.DictionaryPage)
+	if !ok {
+		return "is not dictionary encoded, expected dict_cardinality", nil
+	}
+	if n := int(dictPage.NumValues()); n > k || (n < k && !nullable) {
+		return fmt.Sprintf("has %d dictionary entries, expected dict_cardinality=%d", n, k), nil
+	}
+	return "", nil
+}
+
+// isDataFile reports whether file, relative to common.path, is a file of
+// the configured prefix: prefix.N.suffix in common.path, a partNNNNN folder,
+// or with common.partition_by a <column>=<value> directory. Files of other
+// prefixes and tables are left out.
+func isDataFile(cfg *config.Config, file string) bool {
+	dir, name := path.Split(strings.TrimPrefix(file, "/"))
+	if _, ok := config.ParseFileNo(name, cfg.Common.Prefix, cfg.FileSuffix()); !ok {
+		return false
+	}
+	dir = strings.TrimSuffix(dir, "/")
+	if cfg.Common.PartitionBy != "" {
+		column, _, ok := strings.Cut(dir, "=")
+		return ok && !strings.Contains(dir, "/") && strings.EqualFold(column, cfg.Common.PartitionBy)
+	}
+	return dir == "" || cfg.Common.Folders > 1 && strings.HasPrefix(dir, "part") && !strings.Contains(dir, "/")
+}
+
+// partitionFileSpecs returns the columns of the written files: the specs
+// without the common.partition_by column, which is only in the directory
+// names.
+func partitionFileSpecs(cfg *config.Config, specs []*spec.ColumnSpec) ([]*spec.ColumnSpec, error) {
+	if cfg.Common.PartitionBy == "" {
+		return specs, nil
+	}
+	i := slices.IndexFunc(specs, func(s *spec.ColumnSpec) bool {
+		return strings.EqualFold(s.OrigName, cfg.Common.PartitionBy)
+	})
+	if i < 0 {
+		return nil, errors.Errorf("common.partition_by column %s is not in the schema", cfg.Common.PartitionBy)
+	}
+	return slices.Delete(slices.Clone(specs), i, i+1), nil
+}
+
+// ValidateFiles reads back every Parquet or CSV file of the configured
+// prefix under the configured path and checks its columns against the schema in sqlPath. CSV files are
+// only checked for sampleRate of their data.
+func ValidateFiles(cfg *config.Config, sqlPath string, threads int, sampleRate float64) error {
+	format := strings.ToLower(cfg.Common.FileFormat)
+	if format != "parquet" && format != "csv" {
+		return errors.Errorf("validate only supports parquet and csv files, file_format is %s", cfg.Common.FileFormat)
+	}
+	if sampleRate <= 0 || sampleRate > 1 {
+		return errors.Errorf("sample rate must be in (0, 1], got %v", sampleRate)
+	}
+	if cfg.Common.FileNameTmpl != nil {
+		return errors.New("validate only recognizes prefix.N.suffix file names, not common.filename_template")
+	}
+	specs, err := generator.LoadSpecs(cfg, sqlPath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if specs, err = partitionFileSpecs(cfg, specs); err != nil {
+		return errors.Trace(err)
+	}
+
+	store, err := config.GetStore(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	var paths []string
+	if err := store.WalkDir(ctx, &storage.WalkOption{}, func(path string, size int64) error {
+		if isDataFile(cfg, path) {
+			paths = append(paths, path)
+		}
+		return nil
+	}); err != nil {
+		return errors.Trace(err)
+	}
+	if len(paths) == 0 {
+		return errors.Errorf("no %s files found under %s", format, cfg.Common.Path)
+	}
+	slices.Sort(paths)
+
+	results := make([]*validateResult, len(paths))
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(threads)
+	for i, path := range paths {
+		eg.Go(func() error {
+			var (
+				res *validateResult
+				err error
+			)
+			if format == "csv" {
+				res, err = validateCSVFile(egCtx, store, path, specs, cfg.CSV, sampleRate)
+			} else {
+				res, err = validateParquetFile(egCtx, store, path, specs, cfg.Parquet.MaxColumnChunkBytes)
+			}
+			if err != nil {
+				return err
+			}
+			results[i] = res
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return errors.Trace(err)
+	}
+
+	var totalRows int64
+	problems := 0
+	for _, res := range results {
+		totalRows += res.rows
+		if len(res.problems) == 0 {
+			util.Infof("OK: %s, %d rows", res.path, res.rows)
+			continue
+		}
+		for _, p := range res.problems {
+			util.Errorf("FAIL: %s: %s", res.path, p)
+		}
+		problems += len(res.problems)
+	}
+
+	util.Infof("Validated %d files, %d rows, %d problems", len(paths), totalRows, problems)
+	if problems > 0 {
+		return errors.Errorf("%d problems found", problems)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"strings"
+
+	"dataWriter/src/config"
+	"dataWriter/src/converter"
+	"dataWriter/src/spec"
+	"dataWriter/src/util"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+)
+
+// csvSampleBlock is the unit of CSV sampling: with a sample rate below 1,
+// each block of the file is either checked entirely or skipped.
+const csvSampleBlock = units.MiB
+
+// csvLineReader reads the lines of a CSV file and skips blocks of it,
+// seeking when the file is not compressed.
+type csvLineReader struct {
+	src     storage.ExternalFileReader
+	br      *bufio.Reader
+	seek    bool
+	endline string
+	quoter  util.CSVQuoter
+	offset  int64
+}
+
+// next returns the line starting at the current offset, without the endline.
+func (r *csvLineReader) next() (string, error) {
+	var b strings.Builder
+	last := r.endline[len(r.endline)-1]
+	for {
+		s, err := r.br.ReadString(last)
+		b.WriteString(s)
+		r.offset += int64(len(s))
+		if err != nil {
+			if err == io.EOF && b.Len() > 0 {
+				return b.String(), nil
+			}
+			return "", err
+		}
+		if line := b.String(); strings.HasSuffix(line, r.endline) && r.quoter.LineComplete(line) {
+			return strings.TrimSuffix(line, r.endline), nil
+		}
+	}
+}
+
+// skipTo moves to the first line starting at or after offset. Quoted fields
+// may hold line breaks, so with csv.quote the lines in between are read.
+func (r *csvLineReader) skipTo(offset int64) error {
+	if r.quoter.Enabled() {
+		for r.offset < offset {
+			if _, err := r.next(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if offset <= r.offset {
+		return nil
+	}
+	if n := offset - r.offset; r.seek && n > int64(r.br.Buffered()) {
+		if _, err := r.src.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		r.br.Reset(r.src)
+	} else if _, err := r.br.Discard(int(n)); err != nil {
+		return err
+	}
+	r.offset = offset
+	// Drop the rest of the line the offset falls in.
+	_, err := r.next()
+	return err
+}
+
+// validateCSVFile checks that every line of a CSV file has a field per column
+// and that each field parses as its column's type, stopping at the first
+// violation. With sampleRate below 1 the first block and that share of the
+// other blocks are checked, picked at random from the file name.
+func validateCSVFile(ctx context.Context, store storage.ExternalStorage, path string, specs []*spec.ColumnSpec, cfg config.CSVConfig, sampleRate float64) (*validateResult, error) {
+	reader, err := store.Open(ctx, path, nil)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to open file: %s", path)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	res := &validateResult{path: path}
+	_, endline := util.CSVSeparatorAndEndline(cfg)
+	r := &csvLineReader{src: reader, seek: !cfg.IsGzip(), endline: endline, quoter: util.NewCSVQuoter(cfg)}
+	if cfg.IsGzip() {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			res.problems = append(res.problems, fmt.Sprintf("not a valid gzip file: %v", err))
+			return res, nil
+		}
+		defer gz.Close()
+		r.br = bufio.NewReaderSize(gz, units.MiB)
+	} else {
+		r.br = bufio.NewReaderSize(reader, units.MiB)
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(path))
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+
+	// Line numbers are only known until the first skipped block.
+	line, skipped := 0, false
+	for block := int64(0); ; block++ {
+		end := (block + 1) * csvSampleBlock
+		if block > 0 && sampleRate < 1 && rng.Float64() >= sampleRate {
+			if err := r.skipTo(end); err == io.EOF {
+				return res, nil
+			} else if err != nil {
+				return nil, errors.Annotatef(err, "failed to read file: %s", path)
+			}
+			skipped = true
+			continue
+		}
+		for r.offset < end {
+			lineOffset := r.offset
+			record, err := r.next()
+			if err == io.EOF {
+				return res, nil
+			} else if err != nil {
+				return nil, errors.Annotatef(err, "failed to read file: %s", path)
+			}
+			line++
+			if err := converter.CheckCSVRecord(specs, record, cfg); err != nil {
+				where := fmt.Sprintf("line %d", line)
+				if skipped {
+					where = fmt.Sprintf("line at byte %d", lineOffset)
+				}
+				res.problems = append(res.problems, fmt.Sprintf("%s: %v", where, err))
+				return res, nil
+			}
+			res.rows++
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dataWriter/src/config"
+
+	"github.com/BurntSushi/toml"
+)
+
+// testConfig decodes, normalizes and validates a TOML config.
+func testConfig(t *testing.T, text string) *config.Config {
+	t.Helper()
+	var cfg config.Config
+	if _, err := toml.Decode(text, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Normalize(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Validate(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	return &cfg
+}
+
+// writeSchema writes a SQL schema into dir and returns its path.
+func writeSchema(t *testing.T, dir, name, sql string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(sql), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func parquetConfig(path, prefix string) string {
+	return fmt.Sprintf(`
+[common]
+path = %q
+prefix = %q
+start_fileno = 0
+end_fileno = 2
+rows = 100
+format = "parquet"
+seed = 1
+
+[parquet]
+row_groups = 1
+compression = "zstd"
+`, path, prefix)
+}
+
+func TestValidateFilesOnlyChecksPrefix(t *testing.T) {
+	out := t.TempDir()
+	schemas := t.TempDir()
+	a := writeSchema(t, schemas, "a.sql", "CREATE TABLE a (id bigint, name varchar(20));")
+	b := writeSchema(t, schemas, "b.sql", "CREATE TABLE b (id int);")
+
+	if err := GenerateFiles(testConfig(t, parquetConfig(out, "a")), a, 2); err != nil {
+		t.Fatal(err)
+	}
+	// Files of another prefix in the same directory have another schema.
+	if err := GenerateFiles(testConfig(t, parquetConfig(out, "b")), b, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ValidateFiles(testConfig(t, parquetConfig(out, "a")), a, 2, 1); err != nil {
+		t.Errorf("validate prefix a: %v", err)
+	}
+	if err := ValidateFiles(testConfig(t, parquetConfig(out, "b")), b, 2, 1); err != nil {
+		t.Errorf("validate prefix b: %v", err)
+	}
+	// The schema of b doesn't match the files of a.
+	if err := ValidateFiles(testConfig(t, parquetConfig(out, "a")), b, 2, 1); err == nil {
+		t.Error("validate prefix a with the schema of b succeeded")
+	}
+	if err := ValidateFiles(testConfig(t, parquetConfig(out, "c")), a, 2, 1); err == nil {
+		t.Error("validate prefix c without files succeeded")
+	}
+}
+
+func TestValidateFilesPartitioned(t *testing.T) {
+	out := t.TempDir()
+	sqlPath := writeSchema(t, t.TempDir(), "t.sql",
+		"CREATE TABLE t (id bigint, region enum('us','eu','ap'), amount int);")
+	cfgText := fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+start_fileno = 0
+end_fileno = 2
+rows = 300
+format = "csv"
+seed = 1
+partition_by = "region"
+`, out)
+	if err := GenerateFiles(testConfig(t, cfgText), sqlPath, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateFiles(testConfig(t, cfgText), sqlPath, 2, 1); err != nil {
+		t.Errorf("validate partitioned files: %v", err)
+	}
+}
+
+func TestIsDataFile(t *testing.T) {
+	cfg := &config.Config{Common: config.CommonConfig{Prefix: "t", FileFormat: "parquet"}}
+	folders := &config.Config{Common: config.CommonConfig{Prefix: "t", FileFormat: "parquet", Folders: 2}}
+	partitioned := &config.Config{Common: config.CommonConfig{Prefix: "t", FileFormat: "parquet", PartitionBy: "region"}}
+	tests := []struct {
+		cfg  *config.Config
+		file string
+		want bool
+	}{
+		{cfg, "t.0.parquet", true},
+		{cfg, "/t.12.parquet", true},
+		{cfg, "u.0.parquet", false},
+		{cfg, "t.x.parquet", false},
+		{cfg, "t.0.csv", false},
+		{cfg, "t.schema.json", false},
+		{cfg, "orders/orders.0.parquet", false},
+		{cfg, "part00000/t.0.parquet", false},
+		{folders, "part00001/t.3.parquet", true},
+		{folders, "other/t.3.parquet", false},
+		{partitioned, "region=us/t.0.parquet", true},
+		{partitioned, "REGION=eu/t.1.parquet", true},
+		{partitioned, "t.0.parquet", false},
+		{partitioned, "kind=a/t.0.parquet", false},
+	}
+	for _, tt := range tests {
+		if got := isDataFile(tt.cfg, tt.file); got != tt.want {
+			t.Errorf("isDataFile(%+v, %s) = %v, want %v", tt.cfg.Common, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestValidateAndConvertUnquotedJSON(t *testing.T) {
+	out := t.TempDir()
+	sqlPath := writeSchema(t, t.TempDir(), "t.sql", "CREATE TABLE t (id bigint, doc json, name varchar(20));")
+	cfgText := fmt.Sprintf(`
+[common]
+path = %q
+prefix = "t"
+start_fileno = 0
+end_fileno = 1
+rows = 100
+format = "csv"
+seed = 1
+`, out)
+	if err := GenerateFiles(testConfig(t, cfgText), sqlPath, 1); err != nil {
+		t.Fatal(err)
+	}
+	// The separator inside the unquoted JSON values is not a field boundary.
+	if err := ValidateFiles(testConfig(t, cfgText), sqlPath, 1, 1); err != nil {
+		t.Errorf("validate: %v", err)
+	}
+	inputs, err := filepath.Glob(filepath.Join(out, "t*.csv"))
+	if err != nil || len(inputs) != 1 {
+		t.Fatalf("generated files = %v, %v", inputs, err)
+	}
+	output := filepath.Join(t.TempDir(), "t.parquet")
+	if err := ConvertFile(testConfig(t, cfgText), sqlPath, inputs[0], output); err != nil {
+		t.Errorf("convert: %v", err)
+	}
+}